@@ -2,10 +2,19 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	goruntime "runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -15,6 +24,7 @@ type App struct {
 	files        []FileEntry
 	currentIndex int
 	windowID     string
+	instance     string // --instance name, selecting the state file ("" for the shared one)
 	config       Config
 	mu           sync.RWMutex
 	// Initial window position to set on startup (if shouldSetPosition is true)
@@ -23,18 +33,125 @@ type App struct {
 	initialWidth      int
 	initialHeight     int
 	shouldSetPosition bool
+	// Enforced minimum window size (config or --min-size)
+	minWidth  int
+	minHeight int
 	// Cached geometry to avoid redundant saves
 	lastSavedGeometry WindowState
+	// emit sends events to the frontend (runtime.EventsEmit, replaced in tests)
+	emit eventEmitter
+	// execJS evaluates JavaScript in the window (runtime.WindowExecJS, replaced in tests)
+	execJS jsExecutor
+	// quit closes the window and ends the process (runtime.Quit, replaced in tests)
+	quit func(ctx context.Context)
+	// Anchor to scroll to once the frontend has rendered the current content
+	pendingAnchor string
+	// startProcess launches a GUI subprocess (see guiProcessStarter, replaced in tests)
+	startProcess processStarter
+	// Chrome CSS pushed at runtime, taking precedence over the chrome_css file
+	runtimeChromeCSS string
+	// Snippets from header_html/footer_html, read when the config is loaded
+	headerHTML string
+	footerHTML string
+	// Files visited in this window, oldest first (see GetHistory)
+	history []HistoryEntry
+	// GetSelectionHTML requests awaiting a reply from the frontend, by request ID
+	selectionMu      sync.Mutex
+	selectionWaiters map[string]chan string
+	// Line to reveal once the frontend has rendered the current content
+	// (--line or -p file:line, 0 = none)
+	pendingLine int
+	// Page zoom factor set in the frontend (see GetZoom)
+	zoom float64
+	// Content width for responsive checks, 0 for the full window (see SetViewportWidth)
+	viewportWidth int
+	// Whether the frontend has rendered the current content (see NotifyRendered)
+	render renderState
+	// --content-type and --safe, reapplied when a file is re-read (see Reload)
+	forcedContentType string
+	safe              bool
+	// Command-line flags that take precedence over the config file,
+	// reapplied when it's reloaded (see ReloadConfig)
+	overrides configOverrides
+	// Followed files' pollers, by path (see followFile)
+	followers map[string]*FileFollower
+	// Non-fatal load problems shown by the frontend (see GetLoadErrors)
+	loadErrors []LoadError
+	// Whether the frontend reported losing focus (see SetWindowFocused)
+	unfocused bool
+	// Whether the system appearance is dark (see SetSystemAppearance)
+	systemDark bool
+	// notify shows desktop notifications (desktopNotify, replaced in tests)
+	notify notifier
+	// capture saves screenshots (captureWindow, replaced in tests)
+	capture screenCapturer
+	// printWindow opens the print dialog (runtime.WindowPrint, replaced in tests)
+	printWindow func(ctx context.Context)
+	// saveDialog asks where to save (runtime.SaveFileDialog, replaced in tests)
+	saveDialog saveDialogOpener
+	// ipcServer receives commands for this window (nil if it couldn't
+	// start); set before the window starts (see GetIPCStatus)
+	ipcServer *IPCServer
+	// Set once the window's saved state has been removed (close --forget),
+	// so closing doesn't write it again
+	stateForgotten bool
+	// Render stylesheets as they'd apply when printing (--print-css)
+	printMedia bool
 }
 
+// errNoWindow is returned by methods that need a running window
+var errNoWindow = errors.New("window is not running")
+
+// selectionTimeout bounds how long GetSelectionHTML waits for the frontend
+const selectionTimeout = 2 * time.Second
+
+// eventEmitter matches the signature of runtime.EventsEmit
+type eventEmitter func(ctx context.Context, eventName string, optionalData ...interface{})
+
+// jsExecutor matches the signature of runtime.WindowExecJS
+type jsExecutor func(ctx context.Context, js string)
+
+// saveDialogOpener matches the signature of runtime.SaveFileDialog
+type saveDialogOpener func(ctx context.Context, options runtime.SaveDialogOptions) (string, error)
+
+// processStarter launches a GUI subprocess with the given arguments
+type processStarter func(args []string) error
+
 // NewApp creates a new App with the given initial file
 func NewApp(file FileEntry, windowID string) *App {
-	return &App{
+	return newAppWithConfig(file, windowID, LoadConfig())
+}
+
+// newAppWithConfig is NewApp with a config the caller already loaded
+func newAppWithConfig(file FileEntry, windowID string, config Config) *App {
+	minWidth, minHeight := config.MinWindowSize()
+	app := &App{
+		history:      []HistoryEntry{{Name: file.Name, Path: file.Path}},
 		files:        []FileEntry{file},
 		currentIndex: 0,
 		windowID:     windowID,
-		config:       LoadConfig(),
+		config:       config,
+		minWidth:     minWidth,
+		minHeight:    minHeight,
+		emit:         runtime.EventsEmit,
+		execJS:       runtime.WindowExecJS,
+		quit:         runtime.Quit,
+		startProcess: guiProcessStarter(config.LogMaxSize),
+		notify:       desktopNotify,
+		capture:      captureWindow,
+		saveDialog:   runtime.SaveFileDialog,
+		printWindow:  runtime.WindowPrint,
 	}
+	app.headerHTML, app.footerHTML = app.loadSnippets(config)
+	return app
+}
+
+// emitEvent sends an event to the frontend if the app has started
+func (a *App) emitEvent(eventName string, data ...interface{}) {
+	if a.ctx == nil || a.emit == nil {
+		return
+	}
+	a.emit(a.ctx, eventName, data...)
 }
 
 // startup is called when the app starts
@@ -63,21 +180,29 @@ func (a *App) GetWindowGeometry() WindowState {
 	// Subtract title bar height since Wails options expect content height
 	// but WindowGetSize returns frame height
 	contentHeight := h - macOSTitleBarHeight
-	if contentHeight < MinWindowHeight {
-		contentHeight = MinWindowHeight
+	if contentHeight < a.minHeight {
+		contentHeight = a.minHeight
 	}
 
 	return WindowState{
-		Width:  w,
-		Height: contentHeight,
-		X:      x,
-		Y:      y,
+		Width:    w,
+		Height:   contentHeight,
+		X:        x,
+		Y:        y,
+		Viewport: a.GetViewportWidth(),
+		Zoom:     a.GetZoom(),
 	}
 }
 
 // SaveWindowGeometry saves the current window geometry if it has changed.
 // Called from frontend when window is moved or resized.
 func (a *App) SaveWindowGeometry() {
+	a.mu.RLock()
+	forgotten := a.stateForgotten
+	a.mu.RUnlock()
+	if forgotten {
+		return
+	}
 	geometry := a.GetWindowGeometry()
 	if !geometry.IsValid() {
 		return
@@ -87,15 +212,29 @@ func (a *App) SaveWindowGeometry() {
 	if geometry.Width == a.lastSavedGeometry.Width &&
 		geometry.Height == a.lastSavedGeometry.Height &&
 		geometry.X == a.lastSavedGeometry.X &&
-		geometry.Y == a.lastSavedGeometry.Y {
+		geometry.Y == a.lastSavedGeometry.Y &&
+		geometry.Viewport == a.lastSavedGeometry.Viewport &&
+		geometry.Zoom == a.lastSavedGeometry.Zoom {
 		return
 	}
 
-	if err := SaveWindowState(geometry); err == nil {
+	if err := SaveWindowState(a.instance, geometry); err == nil {
 		a.lastSavedGeometry = geometry
 	}
 }
 
+// forgetWindowState removes the window's saved state file and stops it
+// being saved again before the window closes (close --forget)
+func (a *App) forgetWindowState() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := RemoveWindowState(a.instance); err != nil {
+		return err
+	}
+	a.stateForgotten = true
+	return nil
+}
+
 // GetHTMLContent returns the HTML content of the currently selected file
 // This is called from the frontend to get the initial content
 func (a *App) GetHTMLContent() string {
@@ -104,10 +243,53 @@ func (a *App) GetHTMLContent() string {
 	if len(a.files) == 0 || a.currentIndex < 0 || a.currentIndex >= len(a.files) {
 		return ""
 	}
-	return a.files[a.currentIndex].Content
+	return a.displayContent(a.currentIndex)
+}
+
+// GetCurrentFileName returns the display name of the currently selected file
+func (a *App) GetCurrentFileName() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.files) == 0 || a.currentIndex < 0 || a.currentIndex >= len(a.files) {
+		return ""
+	}
+	return a.files[a.currentIndex].Name
+}
+
+// GetCurrentFilePath returns the path of the currently selected file
+// Returns empty string for stdin content (no file path)
+func (a *App) GetCurrentFilePath() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.files) == 0 || a.currentIndex < 0 || a.currentIndex >= len(a.files) {
+		return ""
+	}
+	return a.files[a.currentIndex].Path
+}
+
+// GetCurrentContentType returns the content type of the currently selected
+// file as it was loaded (e.g. text/markdown for Markdown rendered to HTML),
+// so the frontend knows what it's displaying and can choose the matching
+// parser (HTML vs XHTML/XML)
+func (a *App) GetCurrentContentType() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.files) == 0 || a.currentIndex < 0 || a.currentIndex >= len(a.files) {
+		return ""
+	}
+	f := a.files[a.currentIndex]
+	// The source view is always HTML, whatever the file's own type
+	if f.ContentType == "" || f.viewSource {
+		return ContentTypeHTML
+	}
+	if f.SourceType != "" {
+		return f.SourceType
+	}
+	return f.ContentType
 }
 
-// GetCurrentBasePath returns the directory containing the current file
+// GetCurrentBasePath returns the directory containing the current file,
+// or the file's explicit BasePath if set
 // Used by frontend to set <base> tag for resolving relative URLs
 // Returns empty string for stdin content (no file path)
 func (a *App) GetCurrentBasePath() string {
@@ -116,6 +298,10 @@ func (a *App) GetCurrentBasePath() string {
 	if len(a.files) == 0 || a.currentIndex < 0 || a.currentIndex >= len(a.files) {
 		return ""
 	}
+	// An explicit base path takes precedence over the file's location
+	if basePath := a.files[a.currentIndex].BasePath; basePath != "" {
+		return basePath
+	}
 	path := a.files[a.currentIndex].Path
 	if path == "" {
 		return ""
@@ -125,14 +311,87 @@ func (a *App) GetCurrentBasePath() string {
 	return dir
 }
 
+// ShouldApplyBasePath reports whether the frontend should resolve relative
+// URLs in the current file against its base path (see Config.BaseHrefMode)
+func (a *App) ShouldApplyBasePath() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.files) == 0 || a.currentIndex < 0 || a.currentIndex >= len(a.files) {
+		return false
+	}
+	return shouldApplyBasePath(a.config.BaseHrefMode, a.files[a.currentIndex].content())
+}
+
 // GetFiles returns all files for the sidebar
 func (a *App) GetFiles() []FileEntry {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 	// Return a copy to avoid race conditions
-	result := make([]FileEntry, len(a.files))
-	copy(result, a.files)
-	return result
+	return a.filesWithContent()
+}
+
+// fileSummary is a FileEntry without its content, for GetFilesJSON and
+// GetFileSummaries
+type fileSummary struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Updated     bool   `json:"updated"`
+	ContentType string `json:"content_type"`
+	BasePath    string `json:"base_path,omitempty"`
+	// Preview is a short text snippet (GetFileSummaries only)
+	Preview string `json:"preview,omitempty"`
+}
+
+// summary returns the entry's fileSummary, without a preview
+func (f *FileEntry) summary() fileSummary {
+	return fileSummary{
+		Name:        f.Name,
+		Path:        f.Path,
+		Updated:     f.Updated,
+		ContentType: f.ContentType,
+		BasePath:    f.BasePath,
+	}
+}
+
+// GetFilesJSON returns the file list as a JSON array. Content is omitted
+// unless includeContent is set, to avoid huge payloads.
+func (a *App) GetFilesJSON(includeContent bool) (string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var v interface{}
+	if includeContent {
+		v = a.filesWithContent()
+	} else {
+		summaries := make([]fileSummary, len(a.files))
+		for i := range a.files {
+			summaries[i] = a.files[i].summary()
+		}
+		v = summaries
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal files: %w", err)
+	}
+	return string(data), nil
+}
+
+// HasFile reports whether a file with the given path is loaded, and its
+// index in the sidebar (-1 if it isn't). Content without a path never
+// matches.
+func (a *App) HasFile(path string) (bool, int) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if path == "" {
+		return false, -1
+	}
+	for i, f := range a.files {
+		if f.Path == path {
+			return true, i
+		}
+	}
+	return false, -1
 }
 
 // GetCurrentIndex returns the index of the currently selected file
@@ -150,12 +409,92 @@ func (a *App) SelectFile(index int) string {
 		return ""
 	}
 	a.currentIndex = index
-	return a.files[index].Content
+	a.files[index].Updated = false
+	a.recordVisit(index)
+	a.render.markPending()
+	a.compactFiles()
+	return a.displayContent(index)
+}
+
+// SetCurrentByPath selects the file with the given path and returns its
+// content, resolving the path under the lock so a re-sort between the
+// frontend's render and a click can't select the wrong file.
+// Returns empty string if no file has that path.
+func (a *App) SetCurrentByPath(path string) string {
+	a.mu.Lock()
+	index := -1
+	for i, f := range a.files {
+		if f.Path == path {
+			index = i
+			break
+		}
+	}
+	if path == "" || index < 0 {
+		a.mu.Unlock()
+		return ""
+	}
+	a.currentIndex = index
+	a.files[index].Updated = false
+	a.recordVisit(index)
+	a.render.markPending()
+	a.compactFiles()
+	content := a.displayContent(index)
+	filesCopy := a.filesWithContent()
+	a.mu.Unlock()
+
+	a.emitEvent("file-selected", map[string]interface{}{
+		"files":        filesCopy,
+		"currentIndex": index,
+	})
+	return content
+}
+
+// SelectNextUpdated selects the next file (searching circularly from the
+// current index) whose updated flag is set, clears the flag, and returns its
+// content. Returns empty string if no file has been updated.
+func (a *App) SelectNextUpdated() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := len(a.files)
+	for offset := 1; offset <= n; offset++ {
+		i := (a.currentIndex + offset) % n
+		if i < 0 {
+			i += n
+		}
+		if a.files[i].Updated {
+			a.currentIndex = i
+			a.files[i].Updated = false
+			a.recordVisit(i)
+			a.render.markPending()
+			a.compactFiles()
+			return a.displayContent(i)
+		}
+	}
+	return ""
 }
 
 // AddFile adds a new file to the sidebar and emits an event to the frontend
 func (a *App) AddFile(entry FileEntry) {
+	a.addFile(entry, false)
+}
+
+// addFile implements AddFile. With show, the added file is also selected,
+// as the last of a batch sent with --select is.
+func (a *App) addFile(entry FileEntry, show bool) {
 	a.mu.Lock()
+	// New files arrive unseen; the frontend doesn't switch to them
+	entry.Updated = !show
+	// Piped entries all default to "stdin"; number repeats so they can be
+	// told apart and sort stably
+	if entry.Path == "" {
+		var names []string
+		for _, f := range a.files {
+			if f.Path == "" {
+				names = append(names, f.Name)
+			}
+		}
+		entry.Name = dedupeName(entry.Name, names)
+	}
 	a.files = append(a.files, entry)
 	sortFilesByName(a.files)
 	// Find the new index after sorting
@@ -166,31 +505,77 @@ func (a *App) AddFile(entry FileEntry) {
 			break
 		}
 	}
+	if show {
+		a.currentIndex = newIndex
+		a.recordVisit(newIndex)
+		a.render.markPending()
+	}
+	a.compactFiles()
 	// Copy files while holding the lock to avoid race condition
-	filesCopy := make([]FileEntry, len(a.files))
-	copy(filesCopy, a.files)
+	filesCopy := a.filesWithContent()
 	a.mu.Unlock()
 
-	// Emit event to frontend
-	if a.ctx != nil {
-		runtime.EventsEmit(a.ctx, "file-added", map[string]interface{}{
-			"files": filesCopy,
-			"index": newIndex,
+	if show {
+		a.emitEvent("files-changed", map[string]interface{}{
+			"files":        filesCopy,
+			"currentIndex": newIndex,
 		})
+		return
 	}
+	// Emit event to frontend
+	a.emitEvent("file-added", map[string]interface{}{
+		"files": filesCopy,
+		"index": newIndex,
+	})
 }
 
 // ReplaceFileContent replaces the content of a file by path, selects it, and emits an event
-// If the path is not found, adds it as a new file
+// If the path is not found, adds it as a new file. Content without a path
+// (stdin) replaces the first entry without one.
 func (a *App) ReplaceFileContent(path, content, name string) {
+	a.replaceEntry(FileEntry{Path: path, Content: content, Name: name})
+}
+
+// replaceEntry implements ReplaceFileContent for a full entry, so IPC senders
+// can also update optional fields such as BasePath
+func (a *App) replaceEntry(entry FileEntry) {
+	a.replaceEntryIfMatch(entry, "", false)
+}
+
+// entryMatches reports whether f is the entry a replace of path targets.
+// Content without a path matches the first entry without one, or with
+// byName (a sidebar --pipe-name upsert), the one with the same name.
+func entryMatches(f FileEntry, path, name string, byName bool) bool {
+	if f.Path != path {
+		return false
+	}
+	return path != "" || !byName || f.Name == name
+}
+
+// replaceEntryIfMatch is replaceEntry that, given an expectedHash, applies
+// the replacement only if the file's current content still has that hash.
+// Otherwise nothing changes and a *conflictError is returned.
+func (a *App) replaceEntryIfMatch(entry FileEntry, expectedHash string, byName bool) error {
+	path, content, name := entry.Path, entry.Content, entry.Name
 	a.mu.Lock()
+	if expectedHash != "" {
+		if err := a.checkContentHash(path, name, expectedHash, byName); err != nil {
+			a.mu.Unlock()
+			return err
+		}
+	}
 	found := false
 	for i, f := range a.files {
-		if f.Path == path {
+		if entryMatches(f, path, name, byName) {
 			a.files[i].Content = content
+			a.files[i].compressed = nil
 			if name != "" {
 				a.files[i].Name = name
 			}
+			if entry.BasePath != "" {
+				a.files[i].BasePath = entry.BasePath
+			}
+			a.files[i].Updated = false
 			a.currentIndex = i
 			found = true
 			break
@@ -199,53 +584,446 @@ func (a *App) ReplaceFileContent(path, content, name string) {
 	if !found {
 		// Add as new file
 		a.files = append(a.files, FileEntry{
-			Name:    name,
-			Path:    path,
-			Content: content,
+			Name:        name,
+			Path:        path,
+			Content:     content,
+			ContentType: contentTypeForPath(path),
+			BasePath:    entry.BasePath,
 		})
 		sortFilesByName(a.files)
 		// Find index after sorting
-		for i, f := range a.files {
-			if f.Path == path {
-				a.currentIndex = i
-				break
-			}
-		}
+		a.currentIndex = a.indexOfFile(name, path)
 	}
+	a.recordVisit(a.currentIndex)
+	a.render.markPending()
+	a.compactFiles()
 	// Copy data while holding the lock to avoid race condition
-	filesCopy := make([]FileEntry, len(a.files))
-	copy(filesCopy, a.files)
+	filesCopy := a.filesWithContent()
 	currentIndex := a.currentIndex
 	a.mu.Unlock()
 
 	// Emit event to frontend
-	if a.ctx != nil {
-		runtime.EventsEmit(a.ctx, "content-replaced", map[string]interface{}{
-			"files":        filesCopy,
-			"currentIndex": currentIndex,
+	a.emitEvent("content-replaced", map[string]interface{}{
+		"files":        filesCopy,
+		"currentIndex": currentIndex,
+	})
+	a.notifyReplaced(filesCopy[currentIndex].Name)
+	return nil
+}
+
+// updateFileContent replaces the content of the file at path in place, for
+// --follow. The selection doesn't change: the shown file is re-rendered and
+// any other is flagged as updated. It returns false if no file has path.
+func (a *App) updateFileContent(path, content string) bool {
+	a.mu.Lock()
+	index := -1
+	for i, f := range a.files {
+		if f.Path == path {
+			index = i
+			break
+		}
+	}
+	if path == "" || index < 0 {
+		a.mu.Unlock()
+		return false
+	}
+	a.files[index].Content = content
+	a.files[index].compressed = nil
+	shown := index == a.currentIndex
+	if shown {
+		a.render.markPending()
+	} else {
+		a.files[index].Updated = true
+	}
+	a.compactFiles()
+	filesCopy := a.filesWithContent()
+	currentIndex := a.currentIndex
+	a.mu.Unlock()
+
+	if !shown {
+		a.emitEvent("file-updated", map[string]interface{}{
+			"files": filesCopy,
+			"index": index,
 		})
+		return true
+	}
+	a.emitEvent("content-replaced", map[string]interface{}{
+		"files":        filesCopy,
+		"currentIndex": currentIndex,
+	})
+	a.notifyReplaced(filesCopy[currentIndex].Name)
+	return true
+}
+
+// SetFiles atomically replaces the entire file list and emits a single
+// files-changed event. The selected file is preserved by name if it is still
+// present; otherwise the first file is selected.
+func (a *App) SetFiles(files []FileEntry) {
+	a.setFiles(files, "")
+}
+
+// setFiles implements SetFiles, selecting the file named selectName instead
+// of the current one when it's given (IPC set-files with --select)
+func (a *App) setFiles(files []FileEntry, selectName string) {
+	a.mu.Lock()
+	selectedName := selectName
+	if selectedName == "" && a.currentIndex >= 0 && a.currentIndex < len(a.files) {
+		selectedName = a.files[a.currentIndex].Name
+	}
+	newFiles := make([]FileEntry, len(files))
+	copy(newFiles, files)
+	sortFilesByName(newFiles)
+	a.files = newFiles
+	a.currentIndex = 0
+	for i, f := range a.files {
+		if f.Name == selectedName {
+			a.currentIndex = i
+			break
+		}
+	}
+	a.render.markPending()
+	a.compactFiles()
+	// Copy data while holding the lock to avoid race condition
+	filesCopy := a.filesWithContent()
+	currentIndex := a.currentIndex
+	a.mu.Unlock()
+
+	a.emitEvent("files-changed", map[string]interface{}{
+		"files":        filesCopy,
+		"currentIndex": currentIndex,
+	})
+}
+
+// ScrollToAnchor scrolls the rendered content to the element with the given id
+func (a *App) ScrollToAnchor(id string) {
+	if a.ctx == nil || a.execJS == nil || id == "" {
+		return
+	}
+	a.execJS(a.ctx, scrollToAnchorJS(id))
+}
+
+// scrollToAnchorJS builds the JavaScript that scrolls an element into view
+func scrollToAnchorJS(id string) string {
+	quoted, _ := json.Marshal(id)
+	return fmt.Sprintf("document.getElementById(%s)?.scrollIntoView({block: 'start'})", quoted)
+}
+
+// Quit closes the window, ending its process
+func (a *App) Quit() error {
+	if a.ctx == nil || a.quit == nil {
+		return errNoWindow
+	}
+	a.quit(a.ctx)
+	return nil
+}
+
+// errPrintUnsupported is returned by PrintCurrent where the webview can't print
+var errPrintUnsupported = errors.New("printing is not supported by this webview")
+
+// PrintCurrent opens the system print dialog for the rendered content
+func (a *App) PrintCurrent() error {
+	if a.ctx == nil || a.printWindow == nil {
+		return errNoWindow
+	}
+	if err := checkPrintSupport(goruntime.GOOS, macOSVersion); err != nil {
+		return err
+	}
+	a.printWindow(a.ctx)
+	return nil
+}
+
+// checkPrintSupport returns errPrintUnsupported if the webview on goos can't
+// print. WKWebView ignores window.print, so on macOS Wails prints through
+// the native print operation, which needs macOS 11; version reports the
+// macOS version and is only called there.
+func checkPrintSupport(goos string, version func() string) error {
+	if goos != "darwin" {
+		return nil
+	}
+	major, _, _ := strings.Cut(version(), ".")
+	if n, err := strconv.Atoi(major); err != nil || n < 11 {
+		return fmt.Errorf("%w: printing needs macOS 11 or later", errPrintUnsupported)
+	}
+	return nil
+}
+
+// macOSVersion returns the macOS version, such as 14.5, or "" if it can't be
+// read
+func macOSVersion() string {
+	out, err := exec.Command("sw_vers", "-productVersion").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// GetSelectionHTML returns the current selection in the rendered content as
+// HTML, or "" if nothing is selected. WindowExecJS can't return a value, so
+// the frontend sends the result back through ReceiveSelectionHTML.
+func (a *App) GetSelectionHTML() (string, error) {
+	if a.ctx == nil || a.execJS == nil {
+		return "", errNoWindow
+	}
+
+	id := uuid.New().String()
+	reply := make(chan string, 1)
+	a.selectionMu.Lock()
+	if a.selectionWaiters == nil {
+		a.selectionWaiters = make(map[string]chan string)
+	}
+	a.selectionWaiters[id] = reply
+	a.selectionMu.Unlock()
+	defer func() {
+		a.selectionMu.Lock()
+		delete(a.selectionWaiters, id)
+		a.selectionMu.Unlock()
+	}()
+
+	a.execJS(a.ctx, selectionHTMLJS(id))
+	select {
+	case html := <-reply:
+		return html, nil
+	case <-time.After(selectionTimeout):
+		return "", errors.New("timed out waiting for the selection")
+	}
+}
+
+// ReceiveSelectionHTML delivers the selection for a GetSelectionHTML request.
+// Called from the frontend; replies to unknown or expired requests are dropped.
+func (a *App) ReceiveSelectionHTML(id, html string) {
+	a.selectionMu.Lock()
+	reply, ok := a.selectionWaiters[id]
+	a.selectionMu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case reply <- html:
+	default:
+	}
+}
+
+// selectionHTMLJS builds the JavaScript that serializes the selection and
+// hands it back to ReceiveSelectionHTML
+func selectionHTMLJS(id string) string {
+	quoted, _ := json.Marshal(id)
+	return fmt.Sprintf(`(() => {
+	const selection = window.getSelection();
+	const container = document.createElement('div');
+	for (let i = 0; i < selection.rangeCount; i++) {
+		container.appendChild(selection.getRangeAt(i).cloneContents());
 	}
+	window.go.main.App.ReceiveSelectionHTML(%s, container.innerHTML);
+})()`, quoted)
+}
+
+// SetPendingAnchor records an anchor to scroll to after the next render
+func (a *App) SetPendingAnchor(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pendingAnchor = id
+}
+
+// ConsumePendingAnchor returns and clears the pending anchor.
+// Called from frontend after rendering content so it can scroll into place.
+func (a *App) ConsumePendingAnchor() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	id := a.pendingAnchor
+	a.pendingAnchor = ""
+	return id
+}
+
+// SetPendingLine records a line to reveal after the next render
+func (a *App) SetPendingLine(line int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pendingLine = line
+}
+
+// ConsumePendingLine returns and clears the pending line, or 0 for none.
+// Called from frontend after rendering content so it can scroll to and
+// highlight the line in the first <pre>.
+func (a *App) ConsumePendingLine() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	line := a.pendingLine
+	a.pendingLine = 0
+	return line
 }
 
 // GetWindowID returns the window ID
 func (a *App) GetWindowID() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return a.windowID
 }
 
+// GetRecentFiles returns recently opened file paths, most recent first
+func (a *App) GetRecentFiles() []string {
+	return LoadRecentFiles()
+}
+
+// OpenRecent loads a file from the recents list and selects it
+func (a *App) OpenRecent(path string) error {
+	a.mu.RLock()
+	safe, config := a.safe, a.config
+	a.mu.RUnlock()
+	if err := config.checkExtensionAllowed(path); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	entry := FileEntry{
+		Name:        filepath.Base(path),
+		Path:        path,
+		Content:     string(content),
+		ContentType: loadContentType(path),
+	}
+	applyContentType(&entry, "", config.NormalizeLineEndings)
+	sanitizeEntry(&entry, false, safe, config)
+	a.replaceEntry(entry)
+	return rememberFile(path, config)
+}
+
+// OpenLinkedFile selects the file at path, adding it to the sidebar first if
+// it isn't there, when a link in the content points at it. A file already in
+// the sidebar keeps its name.
+func (a *App) OpenLinkedFile(path string) error {
+	if err := a.GetConfig().checkExtensionAllowed(path); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	entry := FileEntry{Path: path, Content: string(content), ContentType: loadContentType(path)}
+	a.mu.RLock()
+	safe, config := a.safe, a.config
+	a.mu.RUnlock()
+	// --content-type was for the file on the command line, not the pages it links to
+	applyContentType(&entry, "", config.NormalizeLineEndings)
+	sanitizeEntry(&entry, false, safe, config)
+	if found, _ := a.HasFile(path); !found {
+		entry.Name = filepath.Base(path)
+	}
+	a.replaceEntry(entry)
+	return nil
+}
+
+// DuplicateToNewWindow opens the current file in a separate window with a
+// fresh window ID. Content without a path (stdin) is passed via a temp file.
+func (a *App) DuplicateToNewWindow() error {
+	a.mu.RLock()
+	if len(a.files) == 0 || a.currentIndex < 0 || a.currentIndex >= len(a.files) {
+		a.mu.RUnlock()
+		return fmt.Errorf("no file to duplicate")
+	}
+	entry := a.files[a.currentIndex]
+	opts := a.copyOptions()
+	a.mu.RUnlock()
+	opts.Name = entry.Name
+	opts.FromStdin = entry.Path == ""
+	args, err := guiProcessArgs(entry, opts)
+	if err != nil {
+		return err
+	}
+	if err := a.startProcess(args); err != nil {
+		if tmpPath := tempFileArg(args); tmpPath != "" {
+			os.Remove(tmpPath)
+		}
+		return err
+	}
+	return nil
+}
+
+// copyOptions returns the options for a GUI subprocess that opens a copy of
+// this window under a fresh window ID. The copy keeps the flags that decide
+// how content is rendered and what it's allowed to do, so a --safe window
+// never duplicates into one that runs the file's scripts. The caller holds
+// a.mu.
+func (a *App) copyOptions() guiOptions {
+	return guiOptions{
+		WindowID:     uuid.New().String(),
+		Instance:     a.instance,
+		ContentType:  a.forcedContentType,
+		Safe:         a.safe,
+		PrintCSS:     a.printMedia,
+		NoLocalFiles: a.overrides.NoLocalFiles,
+		MinSize:      a.overrides.MinSize,
+	}
+}
+
 // GetConfig returns the application configuration
 func (a *App) GetConfig() Config {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	return a.config
 }
 
-// GetChromeCSS returns the content of the custom chrome CSS file
-// Returns empty string if no file is configured or file can't be read
+// ReloadConfig re-reads the config file and emits a config-changed event so
+// the frontend can re-apply font size and chrome CSS without a restart. The
+// window's command-line overrides still take precedence.
+func (a *App) ReloadConfig() {
+	a.mu.RLock()
+	overrides := a.overrides
+	a.mu.RUnlock()
+	// The overrides were validated when the window started
+	config, _ := LoadConfig().applyOverrides(overrides)
+	a.clearConfigLoadErrors()
+	header, footer := a.loadSnippets(config)
+
+	a.mu.Lock()
+	a.config = config
+	a.headerHTML, a.footerHTML = header, footer
+	a.compactFiles()
+	a.mu.Unlock()
+
+	a.emitEvent("config-changed", config)
+}
+
+// GetChromeCSS returns the runtime chrome CSS if set, otherwise the content
+// of the custom chrome CSS file
+// Returns empty string if no file is configured or the file can't be read,
+// which is recorded as a load error
 func (a *App) GetChromeCSS() string {
-	if a.config.ChromeCSS == "" {
+	a.mu.RLock()
+	runtimeCSS := a.runtimeChromeCSS
+	a.mu.RUnlock()
+	if runtimeCSS != "" {
+		return runtimeCSS
+	}
+
+	chromeCSS := a.GetConfig().ChromeCSS
+	if chromeCSS == "" {
 		return ""
 	}
-	content, err := os.ReadFile(a.config.ChromeCSS)
+	content, err := os.ReadFile(chromeCSS)
 	if err != nil {
+		a.recordLoadError(LoadError{Path: chromeCSS, Message: err.Error(), fromConfig: true})
 		return ""
 	}
 	return string(content)
 }
+
+// SetChromeCSSRuntime overrides the chrome CSS without touching the config
+// file, for trying out themes live. An empty string reverts to the configured
+// chrome_css file.
+func (a *App) SetChromeCSSRuntime(css string) {
+	a.mu.Lock()
+	a.runtimeChromeCSS = css
+	a.mu.Unlock()
+
+	a.emitEvent("chrome-css-changed", a.GetChromeCSS())
+}
+
+// ReloadChromeCSS re-reads the chrome_css file and sends the CSS in effect to
+// the frontend, so theme edits show up without restarting. A missing or
+// unreadable file sends empty CSS. A runtime override still takes precedence.
+func (a *App) ReloadChromeCSS() string {
+	css := a.GetChromeCSS()
+	a.emitEvent("chrome-css-changed", css)
+	return css
+}
@@ -1,10 +1,58 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
 	"sync"
 	"testing"
+
+	"github.com/google/uuid"
 )
 
+// recordedEvent is an event captured by recordEvents
+type recordedEvent struct {
+	name string
+	data []interface{}
+}
+
+// eventRecorder collects events emitted by an App
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []recordedEvent
+}
+
+// recordEvents gives the app a context and replaces its emitter so tests can
+// inspect the events it sends to the frontend
+func recordEvents(app *App) *eventRecorder {
+	rec := &eventRecorder{}
+	app.ctx = context.Background()
+	app.emit = func(ctx context.Context, eventName string, optionalData ...interface{}) {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.events = append(rec.events, recordedEvent{name: eventName, data: optionalData})
+	}
+	return rec
+}
+
+// named returns the recorded events with the given name
+func (r *eventRecorder) named(name string) []recordedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []recordedEvent
+	for _, e := range r.events {
+		if e.name == name {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
 func TestNewApp(t *testing.T) {
 	entry := FileEntry{
 		Name:    "test.html",
@@ -37,7 +85,7 @@ func TestNewApp(t *testing.T) {
 }
 
 func TestGetHTMLContent(t *testing.T) {
-	content := "<html><body>Hello</body></html>"
+	content := `<html><head><meta charset="utf-8"></head><body>Hello</body></html>`
 	app := NewApp(FileEntry{Name: "test", Content: content}, "")
 
 	got := app.GetHTMLContent()
@@ -151,8 +199,8 @@ func TestSelectFile(t *testing.T) {
 	app.files = append(app.files, FileEntry{Name: "file3", Content: "<html>3</html>"})
 
 	content := app.SelectFile(1)
-	if content != "<html>2</html>" {
-		t.Errorf("SelectFile(1) returned %q, want %q", content, "<html>2</html>")
+	if want := `<html><head><meta charset="utf-8"></head>2</html>`; content != want {
+		t.Errorf("SelectFile(1) returned %q, want %q", content, want)
 	}
 
 	if app.currentIndex != 1 {
@@ -346,3 +394,947 @@ func TestSortFilesByNameSingle(t *testing.T) {
 		t.Errorf("Single file sort changed name: got %q", files[0].Name)
 	}
 }
+
+func TestAddFileMarksUpdated(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html>initial</html>"}, "")
+	app.AddFile(FileEntry{Name: "new", Path: "/tmp/new.html", Content: "<html>new</html>"})
+
+	for _, f := range app.GetFiles() {
+		want := f.Name == "new"
+		if f.Updated != want {
+			t.Errorf("File %q Updated = %v, want %v", f.Name, f.Updated, want)
+		}
+	}
+}
+
+func TestSelectNextUpdatedWrapsAround(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a", Content: "<html>a</html>"}, "")
+	app.files = append(app.files,
+		FileEntry{Name: "b", Content: "<html>b</html>", Updated: true},
+		FileEntry{Name: "c", Content: "<html>c</html>"},
+		FileEntry{Name: "d", Content: "<html>d</html>"},
+	)
+	app.currentIndex = 2
+
+	// Search starts after the current index and wraps to find "b"
+	content := app.SelectNextUpdated()
+	if want := `<html><head><meta charset="utf-8"></head>b</html>`; content != want {
+		t.Errorf("SelectNextUpdated() = %q, want %q", content, want)
+	}
+	if app.currentIndex != 1 {
+		t.Errorf("currentIndex should be 1, got %d", app.currentIndex)
+	}
+	if app.files[1].Updated {
+		t.Error("SelectNextUpdated() should clear the updated flag")
+	}
+
+	// Flag was cleared, so nothing is left to jump to
+	if content := app.SelectNextUpdated(); content != "" {
+		t.Errorf("SelectNextUpdated() after clearing should return empty string, got %q", content)
+	}
+}
+
+func TestSelectNextUpdatedNoUpdates(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a", Content: "<html>a</html>"}, "")
+	app.files = append(app.files, FileEntry{Name: "b", Content: "<html>b</html>"})
+
+	if content := app.SelectNextUpdated(); content != "" {
+		t.Errorf("SelectNextUpdated() with no updates should return empty string, got %q", content)
+	}
+	if app.currentIndex != 0 {
+		t.Errorf("currentIndex should be unchanged, got %d", app.currentIndex)
+	}
+}
+
+func TestSelectNextUpdatedEmpty(t *testing.T) {
+	app := &App{files: []FileEntry{}}
+
+	if content := app.SelectNextUpdated(); content != "" {
+		t.Errorf("SelectNextUpdated() with empty files should return empty string, got %q", content)
+	}
+}
+
+func TestSelectFileClearsUpdated(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a", Content: "<html>a</html>"}, "")
+	app.files = append(app.files, FileEntry{Name: "b", Content: "<html>b</html>", Updated: true})
+
+	app.SelectFile(1)
+	if app.files[1].Updated {
+		t.Error("SelectFile() should clear the updated flag")
+	}
+}
+
+func TestSetFiles(t *testing.T) {
+	app := NewApp(FileEntry{Name: "old", Path: "/tmp/old.html", Content: "<html>old</html>"}, "")
+	rec := recordEvents(app)
+
+	app.SetFiles([]FileEntry{
+		{Name: "charlie", Content: "<html>c</html>"},
+		{Name: "alpha", Content: "<html>a</html>"},
+		{Name: "bravo", Content: "<html>b</html>"},
+	})
+
+	files := app.GetFiles()
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 files, got %d", len(files))
+	}
+	expected := []string{"alpha", "bravo", "charlie"}
+	for i, name := range expected {
+		if files[i].Name != name {
+			t.Errorf("Position %d: got %q, want %q", i, files[i].Name, name)
+		}
+	}
+
+	// Selected file "old" no longer exists, so the first file is selected
+	if app.GetCurrentIndex() != 0 {
+		t.Errorf("Expected currentIndex 0, got %d", app.GetCurrentIndex())
+	}
+
+	// A single event replaces the N individual add/remove events
+	if len(rec.events) != 1 {
+		t.Fatalf("Expected exactly 1 event, got %d", len(rec.events))
+	}
+	if rec.events[0].name != "files-changed" {
+		t.Errorf("Expected files-changed event, got %q", rec.events[0].name)
+	}
+	data := rec.events[0].data[0].(map[string]interface{})
+	if got := len(data["files"].([]FileEntry)); got != 3 {
+		t.Errorf("Event should carry 3 files, got %d", got)
+	}
+}
+
+func TestSetFilesPreservesSelection(t *testing.T) {
+	app := NewApp(FileEntry{Name: "alpha", Content: "<html>a</html>"}, "")
+	app.files = append(app.files, FileEntry{Name: "bravo", Content: "<html>b</html>"})
+	app.currentIndex = 1 // bravo
+
+	app.SetFiles([]FileEntry{
+		{Name: "aardvark", Content: "<html>aa</html>"},
+		{Name: "alpha", Content: "<html>a2</html>"},
+		{Name: "bravo", Content: "<html>b2</html>"},
+	})
+
+	if app.GetCurrentIndex() != 2 {
+		t.Errorf("Expected selection to follow bravo to index 2, got %d", app.GetCurrentIndex())
+	}
+	if got := app.GetHTMLContent(); got != `<html><head><meta charset="utf-8"></head>b2</html>` {
+		t.Errorf("Expected new bravo content, got %q", got)
+	}
+}
+
+func TestSetFilesSelectsByName(t *testing.T) {
+	app := NewApp(FileEntry{Name: "alpha", Content: "<html>a</html>"}, "")
+
+	app.setFiles([]FileEntry{
+		{Name: "alpha", Content: "<html>a2</html>"},
+		{Name: "charlie", Content: "<html>c</html>"},
+		{Name: "bravo", Content: "<html>b</html>"},
+	}, "charlie")
+
+	if app.GetCurrentIndex() != 2 {
+		t.Errorf("Expected charlie (index 2) to be selected, got %d", app.GetCurrentIndex())
+	}
+}
+
+func TestResolveSelection(t *testing.T) {
+	files := []FileEntry{{Name: "a.html"}, {Name: "b.html"}, {Name: "c.html"}}
+
+	tests := []struct {
+		sel         string
+		want        int
+		wantWarning bool
+	}{
+		{"", 0, false},
+		{"2", 2, false},
+		{"0", 0, false},
+		{"b.html", 1, false},
+		{"3", 0, true},
+		{"-1", 0, true},
+		{"missing.html", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, warning := resolveSelection(files, tt.sel)
+		if got != tt.want || (warning != "") != tt.wantWarning {
+			t.Errorf("resolveSelection(%q) = %d, %q, want %d (warning: %v)", tt.sel, got, warning, tt.want, tt.wantWarning)
+		}
+	}
+}
+
+func TestResolveSelectionPrefersNames(t *testing.T) {
+	// A file named "10" is matched by name, not as an index
+	files := []FileEntry{{Name: "0"}, {Name: "1"}, {Name: "10"}}
+	if got, _ := resolveSelection(files, "10"); got != 2 {
+		t.Errorf("resolveSelection(\"10\") = %d, want the file named 10", got)
+	}
+}
+
+func TestSelectedEntryUsesSidebarOrder(t *testing.T) {
+	files := []FileEntry{{Name: "stdin-2"}, {Name: "stdin-3"}, {Name: "stdin-1"}}
+	if got := selectedEntry(files, "0"); got.Name != "stdin-1" {
+		t.Errorf("selectedEntry(0) = %q, want the first file by name", got.Name)
+	}
+	if got := selectedEntry(files, "stdin-3"); got.Name != "stdin-3" {
+		t.Errorf("selectedEntry(stdin-3) = %q", got.Name)
+	}
+	if got := selectedEntry(files, "nope"); got.Name != "stdin-1" {
+		t.Errorf("selectedEntry(nope) = %q, want the first file as a fallback", got.Name)
+	}
+}
+
+func TestSetFilesCopiesInput(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html></html>"}, "")
+	input := []FileEntry{{Name: "b"}, {Name: "a"}}
+
+	app.SetFiles(input)
+
+	// Sorting must not reorder the caller's slice
+	if input[0].Name != "b" {
+		t.Error("SetFiles() should not modify the input slice")
+	}
+}
+
+func TestSplitPathAnchor(t *testing.T) {
+	tests := []struct {
+		input      string
+		wantPath   string
+		wantAnchor string
+	}{
+		{"file.html#section2", "file.html", "section2"},
+		{"/tmp/docs/report.html#summary", "/tmp/docs/report.html", "summary"},
+		{"file.html", "file.html", ""},
+		{"file.html#", "file.html#", ""},
+		{"dir#1/file.html", "dir#1/file.html", ""},
+		{"a#b.html#c", "a#b.html", "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			path, anchor := splitPathAnchor(tt.input)
+			if path != tt.wantPath || anchor != tt.wantAnchor {
+				t.Errorf("splitPathAnchor(%q) = (%q, %q), want (%q, %q)",
+					tt.input, path, anchor, tt.wantPath, tt.wantAnchor)
+			}
+		})
+	}
+}
+
+func TestSplitPathLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantPath string
+		wantLine int
+	}{
+		{"main.go:42", "main.go", 42},
+		{"/var/log/app.log:7", "/var/log/app.log", 7},
+		{"file.txt", "file.txt", 0},
+		{"file.txt:", "file.txt:", 0},
+		{"file.txt:0", "file.txt:0", 0},
+		{"file.txt:-3", "file.txt:-3", 0},
+		{"file.txt:+3", "file.txt:+3", 0},
+		{"file.txt:12a", "file.txt:12a", 0},
+		{":42", ":42", 0},
+		{"a:b.txt:9", "a:b.txt", 9},
+		// Windows paths: the drive letter's colon is not a line separator
+		{`C:\logs\app.log`, `C:\logs\app.log`, 0},
+		{`C:\logs\app.log:42`, `C:\logs\app.log`, 42},
+		{"C:/src/main.go:3", "C:/src/main.go", 3},
+		{"C:12", "C:12", 0},
+		{"d:5", "d:5", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			path, line := splitPathLine(tt.input)
+			if path != tt.wantPath || line != tt.wantLine {
+				t.Errorf("splitPathLine(%q) = (%q, %d), want (%q, %d)",
+					tt.input, path, line, tt.wantPath, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestConsumePendingLine(t *testing.T) {
+	app := NewApp(FileEntry{Name: "log.txt", Content: "<pre>a\nb</pre>"}, "")
+	if got := app.ConsumePendingLine(); got != 0 {
+		t.Errorf("ConsumePendingLine() = %d, want 0 by default", got)
+	}
+	app.SetPendingLine(42)
+	if got := app.ConsumePendingLine(); got != 42 {
+		t.Errorf("ConsumePendingLine() = %d, want 42", got)
+	}
+	if got := app.ConsumePendingLine(); got != 0 {
+		t.Errorf("ConsumePendingLine() after consuming = %d, want 0", got)
+	}
+}
+
+func TestScrollToAnchor(t *testing.T) {
+	app := NewApp(FileEntry{Name: "test", Content: "<html></html>"}, "")
+
+	var executed []string
+	app.execJS = func(ctx context.Context, js string) {
+		executed = append(executed, js)
+	}
+
+	// No context yet - should be a no-op
+	app.ScrollToAnchor("section2")
+	if len(executed) != 0 {
+		t.Fatalf("ScrollToAnchor() without context should not execute JS, got %v", executed)
+	}
+
+	app.ctx = context.Background()
+	app.ScrollToAnchor("section2")
+	if len(executed) != 1 {
+		t.Fatalf("Expected 1 JS execution, got %d", len(executed))
+	}
+	want := `document.getElementById("section2")?.scrollIntoView({block: 'start'})`
+	if executed[0] != want {
+		t.Errorf("ScrollToAnchor() executed %q, want %q", executed[0], want)
+	}
+}
+
+func TestScrollToAnchorJSEscapesID(t *testing.T) {
+	js := scrollToAnchorJS(`x");alert("y`)
+	want := `document.getElementById("x\");alert(\"y")?.scrollIntoView({block: 'start'})`
+	if js != want {
+		t.Errorf("scrollToAnchorJS() = %q, want %q", js, want)
+	}
+}
+
+func TestConsumePendingAnchor(t *testing.T) {
+	app := NewApp(FileEntry{Name: "test", Content: "<html></html>"}, "")
+
+	if got := app.ConsumePendingAnchor(); got != "" {
+		t.Errorf("ConsumePendingAnchor() with no anchor = %q, want empty", got)
+	}
+
+	app.SetPendingAnchor("intro")
+	if got := app.ConsumePendingAnchor(); got != "intro" {
+		t.Errorf("ConsumePendingAnchor() = %q, want %q", got, "intro")
+	}
+	if got := app.ConsumePendingAnchor(); got != "" {
+		t.Errorf("ConsumePendingAnchor() should clear the anchor, got %q", got)
+	}
+}
+
+func TestContentTypeForPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/tmp/page.xhtml", ContentTypeXHTML},
+		{"/tmp/PAGE.XHTML", ContentTypeXHTML},
+		{"/tmp/page.xht", ContentTypeXHTML},
+		{"/tmp/feed.xml", ContentTypeXML},
+		{"/tmp/page.html", ContentTypeHTML},
+		{"/tmp/page.htm", ContentTypeHTML},
+		{"/tmp/noext", ContentTypeHTML},
+		{"", ContentTypeHTML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := contentTypeForPath(tt.path); got != tt.expected {
+				t.Errorf("contentTypeForPath(%q) = %q, want %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetCurrentContentType(t *testing.T) {
+	app := NewApp(FileEntry{Name: "page.xhtml", Path: "/tmp/page.xhtml", ContentType: ContentTypeXHTML}, "")
+	if got := app.GetCurrentContentType(); got != ContentTypeXHTML {
+		t.Errorf("GetCurrentContentType() = %q, want %q", got, ContentTypeXHTML)
+	}
+
+	// Entries without a content type (e.g. from older senders) default to HTML
+	app = NewApp(FileEntry{Name: "stdin"}, "")
+	if got := app.GetCurrentContentType(); got != ContentTypeHTML {
+		t.Errorf("GetCurrentContentType() = %q, want %q", got, ContentTypeHTML)
+	}
+
+	// Replacing with a new path derives the type from its extension
+	app.ReplaceFileContent("/tmp/new.xhtml", "<html/>", "new")
+	if got := app.GetCurrentContentType(); got != ContentTypeXHTML {
+		t.Errorf("GetCurrentContentType() after replace = %q, want %q", got, ContentTypeXHTML)
+	}
+
+	empty := &App{files: []FileEntry{}}
+	if got := empty.GetCurrentContentType(); got != "" {
+		t.Errorf("GetCurrentContentType() with empty files = %q, want empty", got)
+	}
+}
+
+func TestGetCurrentContentTypeReportsSourceType(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		forced  string
+		want    string
+	}{
+		{"/tmp/notes.md", "# Notes", "", ContentTypeMarkdown},
+		{"/tmp/data.json", `{"a": 1}`, "", ContentTypeJSON},
+		{"/tmp/change.diff", "--- a\n+++ b\n@@ -1 +1 @@\n-a\n+b\n", "", ContentTypeDiff},
+		{"/tmp/page.html", "<p>page</p>", "", ContentTypeHTML},
+		{"", "# piped", ContentTypeMarkdown, ContentTypeMarkdown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			entry := FileEntry{Name: "entry", Path: tt.path, Content: tt.content, ContentType: loadContentType(tt.path)}
+			applyContentType(&entry, tt.forced, false)
+			app := NewApp(entry, "")
+			if got := app.GetCurrentContentType(); got != tt.want {
+				t.Errorf("GetCurrentContentType() = %q, want %q", got, tt.want)
+			}
+			if entry.ContentType != ContentTypeHTML {
+				t.Errorf("ContentType = %q, want the rendered content kept as HTML", entry.ContentType)
+			}
+
+			// Rendering the HTML again doesn't lose the source type
+			source := entry.SourceType
+			applyContentType(&entry, "", false)
+			if entry.SourceType != source {
+				t.Errorf("SourceType = %q after re-applying, want %q", entry.SourceType, source)
+			}
+		})
+	}
+}
+
+func TestGetCurrentBasePathOverride(t *testing.T) {
+	app := NewApp(FileEntry{
+		Name:     "report.html",
+		Path:     "/tmp/generated/report.html",
+		BasePath: "/Users/test/project",
+		Content:  "<html></html>",
+	}, "")
+
+	if got := app.GetCurrentBasePath(); got != "/Users/test/project" {
+		t.Errorf("GetCurrentBasePath() = %q, want custom base path", got)
+	}
+
+	// Stdin content can resolve assets when given an explicit base path
+	app = NewApp(FileEntry{Name: "stdin", BasePath: "/Users/test/project"}, "")
+	if got := app.GetCurrentBasePath(); got != "/Users/test/project" {
+		t.Errorf("GetCurrentBasePath() for stdin = %q, want custom base path", got)
+	}
+}
+
+func TestGetCurrentBasePathEmptyOverrideFallsBack(t *testing.T) {
+	app := NewApp(FileEntry{Name: "test.html", Path: "/tmp/docs/test.html", BasePath: ""}, "")
+
+	if got := app.GetCurrentBasePath(); got != "/tmp/docs" {
+		t.Errorf("GetCurrentBasePath() = %q, want %q", got, "/tmp/docs")
+	}
+}
+
+func TestReplaceEntryBasePath(t *testing.T) {
+	app := NewApp(FileEntry{Name: "test", Path: "/tmp/test.html", Content: "<html>original</html>"}, "")
+
+	app.replaceEntry(FileEntry{Path: "/tmp/test.html", Content: "<html>new</html>", BasePath: "/srv/assets"})
+	if got := app.GetCurrentBasePath(); got != "/srv/assets" {
+		t.Errorf("GetCurrentBasePath() after replace = %q, want %q", got, "/srv/assets")
+	}
+
+	// Replacing without a base path keeps the existing override
+	app.ReplaceFileContent("/tmp/test.html", "<html>newer</html>", "")
+	if got := app.GetCurrentBasePath(); got != "/srv/assets" {
+		t.Errorf("GetCurrentBasePath() should keep override, got %q", got)
+	}
+}
+
+func TestDuplicateToNewWindow(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "")
+	var started []string
+	app.startProcess = func(args []string) error {
+		started = args
+		return nil
+	}
+
+	if err := app.DuplicateToNewWindow(); err != nil {
+		t.Fatalf("DuplicateToNewWindow() error = %v", err)
+	}
+
+	if argValue(started, "-p") != "/tmp/a.html" {
+		t.Errorf("-p = %q, want /tmp/a.html", argValue(started, "-p"))
+	}
+	if argValue(started, "-n") != "a.html" {
+		t.Errorf("-n = %q, want a.html", argValue(started, "-n"))
+	}
+	if _, err := uuid.Parse(argValue(started, "--id")); err != nil {
+		t.Errorf("--id = %q, want a fresh UUID", argValue(started, "--id"))
+	}
+}
+
+func TestDuplicateToNewWindowKeepsInstance(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html"}, "")
+	app.instance = "docs"
+	var started []string
+	app.startProcess = func(args []string) error {
+		started = args
+		return nil
+	}
+
+	if err := app.DuplicateToNewWindow(); err != nil {
+		t.Fatalf("DuplicateToNewWindow() error = %v", err)
+	}
+	if got := argValue(started, "--instance"); got != "docs" {
+		t.Errorf("--instance = %q, want docs so the new window shares the instance's saved geometry", got)
+	}
+}
+
+func TestDuplicateToNewWindowKeepsRenderFlags(t *testing.T) {
+	app := NewApp(FileEntry{Name: "notes.txt", Path: "/tmp/notes.txt"}, "")
+	app.safe = true
+	app.forcedContentType = ContentTypeMarkdown
+	app.printMedia = true
+	app.overrides = configOverrides{NoLocalFiles: true, MinSize: "640x480"}
+	var started []string
+	app.startProcess = func(args []string) error {
+		started = args
+		return nil
+	}
+
+	if err := app.DuplicateToNewWindow(); err != nil {
+		t.Fatalf("DuplicateToNewWindow() error = %v", err)
+	}
+	for _, flag := range []string{"--safe", "--no-local-files", "--print-css"} {
+		if !hasArg(started, flag) {
+			t.Errorf("args %v should include %s", started, flag)
+		}
+	}
+	if got := argValue(started, "--content-type"); got != ContentTypeMarkdown {
+		t.Errorf("--content-type = %q, want %s", got, ContentTypeMarkdown)
+	}
+	if got := argValue(started, "--min-size"); got != "640x480" {
+		t.Errorf("--min-size = %q, want 640x480", got)
+	}
+}
+
+func TestDuplicateToNewWindowStdin(t *testing.T) {
+	app := NewApp(FileEntry{Name: "stdin", Content: "<p>piped</p>"}, "")
+	var started []string
+	app.startProcess = func(args []string) error {
+		started = args
+		return nil
+	}
+
+	if err := app.DuplicateToNewWindow(); err != nil {
+		t.Fatalf("DuplicateToNewWindow() error = %v", err)
+	}
+	defer os.Remove(argValue(started, "-p"))
+
+	if !hasArg(started, "--temp-file") {
+		t.Error("stdin content should be passed via --temp-file")
+	}
+}
+
+func TestDuplicateToNewWindowSpawnError(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html"}, "")
+	app.startProcess = func(args []string) error {
+		return errors.New("spawn failed")
+	}
+
+	if err := app.DuplicateToNewWindow(); err == nil {
+		t.Error("DuplicateToNewWindow() should return the spawn error")
+	}
+}
+
+func TestGetChromeCSSPrefersRuntimeOverride(t *testing.T) {
+	cssPath := filepath.Join(t.TempDir(), "chrome.css")
+	if err := os.WriteFile(cssPath, []byte("#sidebar { color: red; }"), 0644); err != nil {
+		t.Fatalf("Could not write CSS file: %v", err)
+	}
+
+	app := NewApp(FileEntry{Name: "a.html"}, "")
+	app.config.ChromeCSS = cssPath
+
+	if got := app.GetChromeCSS(); got != "#sidebar { color: red; }" {
+		t.Errorf("GetChromeCSS() = %q, expected the configured file's CSS", got)
+	}
+
+	app.SetChromeCSSRuntime("#sidebar { color: blue; }")
+	if got := app.GetChromeCSS(); got != "#sidebar { color: blue; }" {
+		t.Errorf("GetChromeCSS() = %q, expected the runtime override", got)
+	}
+
+	app.SetChromeCSSRuntime("")
+	if got := app.GetChromeCSS(); got != "#sidebar { color: red; }" {
+		t.Errorf("GetChromeCSS() = %q, expected clearing the override to restore the file's CSS", got)
+	}
+}
+
+func TestSetChromeCSSRuntimeEmitsEvent(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html"}, "")
+	rec := recordEvents(app)
+
+	app.SetChromeCSSRuntime("#find-bar { display: none; }")
+
+	events := rec.named("chrome-css-changed")
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 chrome-css-changed event, got %d", len(events))
+	}
+	if css, _ := events[0].data[0].(string); css != "#find-bar { display: none; }" {
+		t.Errorf("event data = %v, expected the runtime CSS", events[0].data)
+	}
+}
+
+func TestReloadChromeCSSReadsUpdatedFile(t *testing.T) {
+	cssPath := filepath.Join(t.TempDir(), "chrome.css")
+	if err := os.WriteFile(cssPath, []byte("#sidebar { color: red; }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp(FileEntry{Name: "a.html"}, "")
+	app.config.ChromeCSS = cssPath
+	rec := recordEvents(app)
+
+	if err := os.WriteFile(cssPath, []byte("#sidebar { color: green; }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := app.ReloadChromeCSS(); got != "#sidebar { color: green; }" {
+		t.Errorf("ReloadChromeCSS() = %q, expected the updated file's CSS", got)
+	}
+
+	events := rec.named("chrome-css-changed")
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 chrome-css-changed event, got %d", len(events))
+	}
+	if css, _ := events[0].data[0].(string); css != "#sidebar { color: green; }" {
+		t.Errorf("event data = %v, expected the updated CSS", events[0].data)
+	}
+}
+
+func TestReloadChromeCSSMissingFile(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html"}, "")
+	app.config.ChromeCSS = filepath.Join(t.TempDir(), "missing.css")
+	rec := recordEvents(app)
+
+	if got := app.ReloadChromeCSS(); got != "" {
+		t.Errorf("ReloadChromeCSS() = %q, expected empty CSS for a missing file", got)
+	}
+	events := rec.named("chrome-css-changed")
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 chrome-css-changed event, got %d", len(events))
+	}
+	if css, _ := events[0].data[0].(string); css != "" {
+		t.Errorf("event data = %v, expected empty CSS", events[0].data)
+	}
+}
+
+func TestGetFilesJSON(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>", ContentType: ContentTypeHTML}, "")
+	app.AddFile(FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "<p>b</p>", ContentType: ContentTypeHTML})
+
+	data, err := app.GetFilesJSON(false)
+	if err != nil {
+		t.Fatalf("GetFilesJSON() error = %v", err)
+	}
+
+	var files []map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &files); err != nil {
+		t.Fatalf("GetFilesJSON() returned invalid JSON: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(files))
+	}
+	if files[0]["name"] != "a.html" || files[0]["path"] != "/tmp/a.html" {
+		t.Errorf("files[0] = %v, expected a.html", files[0])
+	}
+	if files[1]["updated"] != true || files[1]["content_type"] != ContentTypeHTML {
+		t.Errorf("files[1] = %v, expected snake_case updated and content_type fields", files[1])
+	}
+	for _, f := range files {
+		if _, ok := f["content"]; ok {
+			t.Errorf("content should be omitted, got %v", f)
+		}
+		if _, ok := f["Name"]; ok {
+			t.Errorf("field names should be snake_case, got %v", f)
+		}
+	}
+}
+
+func TestGetFilesJSONIncludeContent(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "")
+
+	data, err := app.GetFilesJSON(true)
+	if err != nil {
+		t.Fatalf("GetFilesJSON() error = %v", err)
+	}
+
+	var files []FileEntry
+	if err := json.Unmarshal([]byte(data), &files); err != nil {
+		t.Fatalf("GetFilesJSON() returned invalid JSON: %v", err)
+	}
+	if len(files) != 1 || files[0].Content != "<p>a</p>" {
+		t.Errorf("files = %+v, expected content to be included", files)
+	}
+}
+
+func TestSetCurrentByPath(t *testing.T) {
+	app := NewApp(FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "<p>b</p>"}, "")
+	app.AddFile(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"})
+	rec := recordEvents(app)
+
+	if got := app.SetCurrentByPath("/tmp/b.html"); got != "<p>b</p>" {
+		t.Errorf("SetCurrentByPath() = %q, expected b's content", got)
+	}
+	// a.html sorts first, so b.html is at index 1
+	if got := app.GetCurrentIndex(); got != 1 {
+		t.Errorf("GetCurrentIndex() = %d, expected 1", got)
+	}
+
+	events := rec.named("file-selected")
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 file-selected event, got %d", len(events))
+	}
+	data := events[0].data[0].(map[string]interface{})
+	if data["currentIndex"] != 1 {
+		t.Errorf("event currentIndex = %v, expected 1", data["currentIndex"])
+	}
+}
+
+func TestSetCurrentByPathNoMatch(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "")
+	rec := recordEvents(app)
+
+	if got := app.SetCurrentByPath("/tmp/missing.html"); got != "" {
+		t.Errorf("SetCurrentByPath() = %q, expected empty string", got)
+	}
+	if got := app.SetCurrentByPath(""); got != "" {
+		t.Errorf("SetCurrentByPath(\"\") = %q, expected empty string", got)
+	}
+	if got := app.GetCurrentIndex(); got != 0 {
+		t.Errorf("GetCurrentIndex() = %d, expected selection unchanged", got)
+	}
+	if events := rec.named("file-selected"); len(events) != 0 {
+		t.Errorf("Expected no file-selected events, got %d", len(events))
+	}
+}
+
+func TestSetCurrentByPathDuringResort(t *testing.T) {
+	app := NewApp(FileEntry{Name: "m.html", Path: "/tmp/m.html", Content: "<p>target</p>"}, "")
+
+	// Files added concurrently keep re-sorting the list around the target
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			name := fmt.Sprintf("%c%03d.html", 'a'+rune(i%26), i)
+			app.AddFile(FileEntry{Name: name, Path: "/tmp/" + name, Content: "<p>other</p>"})
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		if got := app.SetCurrentByPath("/tmp/m.html"); got != "<p>target</p>" {
+			t.Fatalf("SetCurrentByPath() = %q, expected the target's content", got)
+		}
+	}
+	wg.Wait()
+
+	app.SetCurrentByPath("/tmp/m.html")
+	if got := app.GetFiles()[app.GetCurrentIndex()].Path; got != "/tmp/m.html" {
+		t.Errorf("current file = %s, expected /tmp/m.html", got)
+	}
+}
+
+func TestGetCurrentFileNameAndPath(t *testing.T) {
+	app := NewApp(FileEntry{Name: "b.html", Path: "/tmp/b.html"}, "")
+	app.AddFile(FileEntry{Name: "a.html", Path: "/tmp/a.html"})
+	app.SetCurrentByPath("/tmp/b.html")
+
+	if got := app.GetCurrentFileName(); got != "b.html" {
+		t.Errorf("GetCurrentFileName() = %q, expected b.html", got)
+	}
+	if got := app.GetCurrentFilePath(); got != "/tmp/b.html" {
+		t.Errorf("GetCurrentFilePath() = %q, expected /tmp/b.html", got)
+	}
+
+	app.SelectFile(0)
+	if got := app.GetCurrentFileName(); got != "a.html" {
+		t.Errorf("GetCurrentFileName() = %q, expected a.html after selecting index 0", got)
+	}
+}
+
+func TestGetCurrentFileNameEmptyApp(t *testing.T) {
+	app := &App{}
+
+	if got := app.GetCurrentFileName(); got != "" {
+		t.Errorf("GetCurrentFileName() = %q, expected empty string", got)
+	}
+	if got := app.GetCurrentFilePath(); got != "" {
+		t.Errorf("GetCurrentFilePath() = %q, expected empty string", got)
+	}
+}
+
+func TestGetCurrentFileNameInvalidIndex(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html"}, "")
+
+	for _, index := range []int{-1, 5} {
+		app.currentIndex = index
+		if got := app.GetCurrentFileName(); got != "" {
+			t.Errorf("GetCurrentFileName() with index %d = %q, expected empty string", index, got)
+		}
+		if got := app.GetCurrentFilePath(); got != "" {
+			t.Errorf("GetCurrentFilePath() with index %d = %q, expected empty string", index, got)
+		}
+	}
+}
+
+func TestGetSelectionHTMLWithoutWindow(t *testing.T) {
+	app := NewApp(FileEntry{Name: "test", Content: "<html></html>"}, "")
+
+	var executed []string
+	app.execJS = func(ctx context.Context, js string) {
+		executed = append(executed, js)
+	}
+
+	html, err := app.GetSelectionHTML()
+	if !errors.Is(err, errNoWindow) {
+		t.Errorf("GetSelectionHTML() without context error = %v, want errNoWindow", err)
+	}
+	if html != "" {
+		t.Errorf("GetSelectionHTML() without context = %q, want empty", html)
+	}
+	if len(executed) != 0 {
+		t.Errorf("GetSelectionHTML() without context should not execute JS, got %v", executed)
+	}
+}
+
+func TestReceiveSelectionHTMLIgnoresUnknownRequest(t *testing.T) {
+	app := NewApp(FileEntry{Name: "test", Content: "<html></html>"}, "")
+
+	// No request is waiting, so this must not block or panic
+	app.ReceiveSelectionHTML("no-such-request", "<b>x</b>")
+}
+
+func TestPrintCurrent(t *testing.T) {
+	app := NewApp(FileEntry{Name: "test", Content: "<html></html>"}, "")
+
+	printed := 0
+	app.printWindow = func(ctx context.Context) { printed++ }
+
+	if err := app.PrintCurrent(); !errors.Is(err, errNoWindow) {
+		t.Errorf("PrintCurrent() without context error = %v, want errNoWindow", err)
+	}
+	if printed != 0 {
+		t.Fatal("PrintCurrent() without context should not print")
+	}
+
+	app.ctx = context.Background()
+	err := app.PrintCurrent()
+	if goruntime.GOOS == "darwin" && checkPrintSupport("darwin", macOSVersion) != nil {
+		if !errors.Is(err, errPrintUnsupported) || printed != 0 {
+			t.Errorf("PrintCurrent() on old macOS error = %v, printed %d times, want errPrintUnsupported", err, printed)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("PrintCurrent() error = %v", err)
+	}
+	if printed != 1 {
+		t.Errorf("printed %d times, want once", printed)
+	}
+}
+
+func TestCheckPrintSupport(t *testing.T) {
+	tests := []struct {
+		goos, version string
+		supported     bool
+	}{
+		{"linux", "", true},
+		{"windows", "", true},
+		{"darwin", "14.5", true},
+		{"darwin", "11.0", true},
+		{"darwin", "10.15.7", false},
+		{"darwin", "", false},
+	}
+	for _, tt := range tests {
+		err := checkPrintSupport(tt.goos, func() string { return tt.version })
+		if tt.supported && err != nil {
+			t.Errorf("checkPrintSupport(%s, %q) = %v, want nil", tt.goos, tt.version, err)
+		}
+		if !tt.supported && !errors.Is(err, errPrintUnsupported) {
+			t.Errorf("checkPrintSupport(%s, %q) = %v, want errPrintUnsupported", tt.goos, tt.version, err)
+		}
+	}
+}
+
+func TestHasFile(t *testing.T) {
+	app := &App{files: []FileEntry{
+		{Name: "stdin", Content: "<p>piped</p>"},
+		{Name: "a.html", Path: "/tmp/a.html"},
+		{Name: "b.html", Path: "/tmp/b.html"},
+	}}
+
+	tests := []struct {
+		path       string
+		wantExists bool
+		wantIndex  int
+	}{
+		{"/tmp/a.html", true, 1},
+		{"/tmp/b.html", true, 2},
+		{"/tmp/missing.html", false, -1},
+		{"", false, -1}, // content without a path is not matched
+	}
+
+	for _, tt := range tests {
+		exists, index := app.HasFile(tt.path)
+		if exists != tt.wantExists || index != tt.wantIndex {
+			t.Errorf("HasFile(%q) = (%v, %d), want (%v, %d)", tt.path, exists, index, tt.wantExists, tt.wantIndex)
+		}
+	}
+}
+
+func TestDedupeName(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		want     string
+	}{
+		{"stdin", nil, "stdin"},
+		{"stdin", []string{"report"}, "stdin"},
+		{"stdin", []string{"stdin"}, "stdin (2)"},
+		{"stdin", []string{"stdin", "stdin (2)"}, "stdin (3)"},
+		// A removed entry's slot is reused
+		{"stdin", []string{"stdin", "stdin (3)"}, "stdin (2)"},
+		{"stdin", []string{"stdin (2)", "stdin (3)"}, "stdin"},
+		{"stdin (2)", []string{"stdin (2)"}, "stdin (2) (2)"},
+	}
+
+	for _, tt := range tests {
+		if got := dedupeName(tt.name, tt.existing); got != tt.want {
+			t.Errorf("dedupeName(%q, %q) = %q, want %q", tt.name, tt.existing, got, tt.want)
+		}
+	}
+}
+
+func TestAddFileNumbersRepeatedStdin(t *testing.T) {
+	app := NewApp(FileEntry{Name: "stdin", Content: "<p>1</p>"}, "")
+	app.AddFile(FileEntry{Name: "stdin", Content: "<p>2</p>"})
+	app.AddFile(FileEntry{Name: "stdin", Content: "<p>3</p>"})
+	// Files with a path keep their names, even when they repeat
+	app.AddFile(FileEntry{Name: "index.html", Path: "/a/index.html"})
+	app.AddFile(FileEntry{Name: "index.html", Path: "/b/index.html"})
+
+	var names []string
+	for _, f := range app.GetFiles() {
+		names = append(names, f.Name)
+	}
+	want := []string{"index.html", "index.html", "stdin", "stdin (2)", "stdin (3)"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("names = %q, want %q", names, want)
+	}
+
+	// Dropping stdin (2) frees its number for the next pipe
+	app.SetFiles([]FileEntry{{Name: "stdin"}, {Name: "stdin (3)"}})
+	app.AddFile(FileEntry{Name: "stdin", Content: "<p>4</p>"})
+	names = nil
+	for _, f := range app.GetFiles() {
+		names = append(names, f.Name)
+	}
+	want = []string{"stdin", "stdin (2)", "stdin (3)"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("names after a removal = %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"container/list"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxCachedAssetSize is the largest file assetCache keeps; bigger files are
+// streamed from disk on every request
+const maxCachedAssetSize = 512 << 10
+
+// assetCache is a least-recently-used cache of local file contents, bounded
+// by their total size. Entries are keyed by path and are only used while the
+// file's modification time and size are unchanged. A nil cache stores nothing.
+type assetCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List               // most recently used first
+	entries  map[string]*list.Element // path -> element holding a *cachedAsset
+}
+
+// cachedAsset is a file's content as of its modification time and size
+type cachedAsset struct {
+	path    string
+	modTime time.Time
+	size    int64
+	data    []byte
+}
+
+// newAssetCache returns a cache holding up to maxBytes, or nil (no caching)
+// if maxBytes isn't positive
+func newAssetCache(maxBytes int64) *assetCache {
+	if maxBytes <= 0 {
+		return nil
+	}
+	return &assetCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// accepts reports whether a file of this size would be cached
+func (c *assetCache) accepts(size int64) bool {
+	return c != nil && size <= maxCachedAssetSize && size <= c.maxBytes
+}
+
+// load returns the cached content of path if it was stored for the file
+// described by info. A stale entry is dropped.
+func (c *assetCache) load(path string, info os.FileInfo) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[path]
+	if !ok {
+		return nil, false
+	}
+	asset := elem.Value.(*cachedAsset)
+	if !asset.modTime.Equal(info.ModTime()) || asset.size != info.Size() {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return asset.data, true
+}
+
+// store caches data as the content of path for the file described by info,
+// evicting the least recently used entries to stay within maxBytes
+func (c *assetCache) store(path string, info os.FileInfo, data []byte) {
+	if !c.accepts(int64(len(data))) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[path]; ok {
+		c.remove(elem)
+	}
+	c.entries[path] = c.order.PushFront(&cachedAsset{path: path, modTime: info.ModTime(), size: info.Size(), data: data})
+	c.size += int64(len(data))
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove drops elem from the cache. Must be called with c.mu held.
+func (c *assetCache) remove(elem *list.Element) {
+	asset := c.order.Remove(elem).(*cachedAsset)
+	delete(c.entries, asset.path)
+	c.size -= int64(len(asset.data))
+}
+
+// count returns the number of cached files
+func (c *assetCache) count() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newCachingHandler returns a handler serving files from dir with an asset
+// cache of maxBytes
+func newCachingHandler(t *testing.T, dir string, maxBytes int64) *LocalFileHandler {
+	t.Helper()
+	app := NewApp(FileEntry{Name: "test.html", Path: filepath.Join(dir, "test.html"), Content: "<html></html>"}, "")
+	app.config.AssetCacheBytes = maxBytes
+	return NewLocalFileHandler(app)
+}
+
+// serve requests /localfile/name and returns the response body
+func serve(t *testing.T, handler *LocalFileHandler, name string) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/localfile/"+name, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: status %d", name, w.Code)
+	}
+	return w.Body.String()
+}
+
+// rewriteKeepingModTime replaces the file's content without changing its
+// modification time, so only a cached copy would still show the old content
+func rewriteKeepingModTime(t *testing.T, path, content string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAssetCacheHit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "style.css")
+	os.WriteFile(path, []byte("body { color: red; }"), 0644)
+	handler := newCachingHandler(t, dir, DefaultAssetCacheBytes)
+
+	first := serve(t, handler, "style.css")
+	rewriteKeepingModTime(t, path, "body { color: tan; }")
+	if second := serve(t, handler, "style.css"); second != first {
+		t.Errorf("second response = %q, want the cached %q", second, first)
+	}
+	if handler.cache.count() != 1 {
+		t.Errorf("cache holds %d files, want 1", handler.cache.count())
+	}
+}
+
+func TestAssetCacheInvalidatedOnModTimeChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.js")
+	os.WriteFile(path, []byte("let v = 1;"), 0644)
+	handler := newCachingHandler(t, dir, DefaultAssetCacheBytes)
+
+	serve(t, handler, "app.js")
+	os.WriteFile(path, []byte("let v = 2;"), 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+
+	if got := serve(t, handler, "app.js"); got != "let v = 2;" {
+		t.Errorf("response = %q, want the file's new content", got)
+	}
+}
+
+func TestAssetCacheLargeFileBypass(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.js")
+	large := strings.Repeat("x", maxCachedAssetSize+1)
+	os.WriteFile(path, []byte(large), 0644)
+	handler := newCachingHandler(t, dir, 8*maxCachedAssetSize)
+
+	if got := serve(t, handler, "big.js"); got != large {
+		t.Errorf("large file served %d bytes, want %d", len(got), len(large))
+	}
+	if handler.cache.count() != 0 {
+		t.Error("files over maxCachedAssetSize should not be cached")
+	}
+}
+
+func TestAssetCacheDisabled(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "style.css")
+	os.WriteFile(path, []byte("body { color: red; }"), 0644)
+	handler := newCachingHandler(t, dir, 0)
+
+	serve(t, handler, "style.css")
+	rewriteKeepingModTime(t, path, "body { color: tan; }")
+	if got := serve(t, handler, "style.css"); got != "body { color: tan; }" {
+		t.Errorf("response = %q, want it re-read with asset_cache_bytes = 0", got)
+	}
+}
+
+func TestAssetCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		os.WriteFile(filepath.Join(dir, name), bytes.Repeat([]byte(name), 100), 0644)
+	}
+	cache := newAssetCache(250)
+	stat := func(name string) os.FileInfo {
+		info, _ := os.Stat(filepath.Join(dir, name))
+		return info
+	}
+
+	cache.store("a", stat("a"), bytes.Repeat([]byte("a"), 100))
+	cache.store("b", stat("b"), bytes.Repeat([]byte("b"), 100))
+	cache.load("a", stat("a")) // a is now more recent than b
+	cache.store("c", stat("c"), bytes.Repeat([]byte("c"), 100))
+
+	if _, ok := cache.load("b", stat("b")); ok {
+		t.Error("b should have been evicted as the least recently used")
+	}
+	for _, name := range []string{"a", "c"} {
+		if _, ok := cache.load(name, stat(name)); !ok {
+			t.Errorf("%s should still be cached", name)
+		}
+	}
+	if cache.size != 200 {
+		t.Errorf("cache size = %d, want 200", cache.size)
+	}
+}
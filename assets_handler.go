@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -13,11 +14,15 @@ import (
 // It intercepts requests to /localfile/* and serves them from the current file's directory
 type LocalFileHandler struct {
 	app *App
+	// disabled refuses every request (--no-local-files), regardless of config
+	disabled bool
+	// cache keeps recently served small files in memory (asset_cache_bytes)
+	cache *assetCache
 }
 
 // NewLocalFileHandler creates a new handler for serving local files
 func NewLocalFileHandler(app *App) *LocalFileHandler {
-	return &LocalFileHandler{app: app}
+	return &LocalFileHandler{app: app, cache: newAssetCache(app.GetConfig().AssetCacheBytes)}
 }
 
 // ServeHTTP handles requests for local files
@@ -28,6 +33,12 @@ func (h *LocalFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Local file access can be turned off entirely for untrusted content
+	if h.disabled || h.app.GetConfig().DisableLocalFiles {
+		http.NotFound(w, r)
+		return
+	}
+
 	path := r.URL.Path
 	if !strings.HasPrefix(path, "/localfile/") {
 		http.NotFound(w, r)
@@ -49,10 +60,12 @@ func (h *LocalFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Construct the full file path
+	// Construct the full file path; Join resolves any ".." segments, so a
+	// reference like sub/../shared/logo.png that stays within the serving
+	// root is allowed and only the check below decides
 	fullPath := filepath.Join(basePath, relativePath)
 
-	// Security check: ensure the resolved path is within the base directory
+	// Security check: ensure the resolved path is within the serving root
 	// This prevents directory traversal attacks (e.g., ../../../etc/passwd)
 	absBase, err := filepath.Abs(basePath)
 	if err != nil {
@@ -64,7 +77,7 @@ func (h *LocalFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	if !strings.HasPrefix(absPath, absBase+string(filepath.Separator)) && absPath != absBase {
+	if !isWithinDir(absPath, h.servingRoot(absBase)) {
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
@@ -86,23 +99,185 @@ func (h *LocalFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A followed link to another page would replace fenestro's own page, so
+	// open the file in the sidebar and send the window back to it instead
+	if isPageNavigation(r, fullPath) {
+		if err := h.app.OpenLinkedFile(absPath); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	// Prefer a pre-compressed sibling (style.css.gz) when the client takes gzip
+	servePath, serveInfo := fullPath, info
+	if acceptsGzip(r) {
+		if gzInfo, err := os.Stat(fullPath + ".gz"); err == nil && !gzInfo.IsDir() {
+			servePath, serveInfo = fullPath+".gz", gzInfo
+		}
+	}
+
+	// Set content type based on the requested file's extension, even when
+	// serving its .gz sibling
+	w.Header().Set("Content-Type", mimeTypeForExt(filepath.Ext(fullPath)))
+	w.Header().Set("Vary", "Accept-Encoding")
+	if servePath != fullPath {
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+
+	if data, ok := h.cache.load(servePath, serveInfo); ok {
+		w.Write(data)
+		return
+	}
+
 	// Open and serve the file
-	file, err := os.Open(fullPath)
+	file, err := os.Open(servePath)
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 	defer file.Close()
 
-	// Set content type based on file extension
-	ext := filepath.Ext(fullPath)
-	contentType := mime.TypeByExtension(ext)
-	if contentType == "" {
-		// Default to octet-stream for unknown types
-		contentType = "application/octet-stream"
+	// Small files are read whole and kept for the next request; anything
+	// else streams from disk
+	if h.cache.accepts(serveInfo.Size()) {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		h.cache.store(servePath, serveInfo, data)
+		w.Write(data)
+		return
 	}
-	w.Header().Set("Content-Type", contentType)
 
 	// Copy the file content to the response
 	io.Copy(w, file)
 }
+
+// isPageNavigation reports whether r is the window navigating to the HTML
+// file at path, as opposed to an iframe, fetch, or other subresource load.
+// It needs Sec-Fetch-Dest: document, or Sec-Fetch-Mode: navigate from a
+// webview that doesn't send a destination; a request with neither is
+// served as a file.
+func isPageNavigation(r *http.Request, path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+	default:
+		return false
+	}
+	dest := r.Header.Get("Sec-Fetch-Dest")
+	return dest == "document" || (dest == "" && r.Header.Get("Sec-Fetch-Mode") == "navigate")
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		// "gzip;q=0" explicitly refuses it
+		if q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// builtinMimeTypes covers extensions that the system mime database may not
+// know about, consulted before falling back to application/octet-stream
+var builtinMimeTypes = map[string]string{
+	".xhtml": "application/xhtml+xml",
+	".xht":   "application/xhtml+xml",
+	// Modern image formats, so the webview displays them inline
+	".webp": "image/webp",
+	".avif": "image/avif",
+	".heic": "image/heic",
+	".heif": "image/heif",
+	".jxl":  "image/jxl",
+	".apng": "image/apng",
+}
+
+// mimeTypeForExt returns the Content-Type to serve a file extension with
+func mimeTypeForExt(ext string) string {
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	if contentType := builtinMimeTypes[strings.ToLower(ext)]; contentType != "" {
+		return contentType
+	}
+	// Default to octet-stream for unknown types
+	return "application/octet-stream"
+}
+
+// servingRoot returns the directory that served files must stay within.
+// This is the configured (or auto-detected) serve_root when it is an ancestor
+// of the file's directory, otherwise the file's directory itself.
+func (h *LocalFileHandler) servingRoot(absBase string) string {
+	return servingRootFor(absBase, h.app.GetConfig())
+}
+
+// servingRootFor is servingRoot for the given config
+func servingRootFor(absBase string, config Config) string {
+	serveRoot := config.ServeRoot
+	if serveRoot == "" && config.AutoServeRoot {
+		serveRoot = findProjectRoot(absBase, projectRootMaxDepth, pathExists)
+	}
+	if serveRoot == "" {
+		return absBase
+	}
+	absRoot, err := filepath.Abs(serveRoot)
+	if err != nil || !isWithinDir(absBase, absRoot) {
+		// Misconfigured root (not an ancestor) - keep strict confinement
+		return absBase
+	}
+	return absRoot
+}
+
+// projectRootMaxDepth limits how many parent directories findProjectRoot checks
+const projectRootMaxDepth = 5
+
+// findProjectRoot walks up from dir looking for a project marker and returns
+// the directory containing it, or "" if none is found within maxDepth parents.
+// A .git directory or package.json marks a root at any level, including dir
+// itself; an index.html only counts in a parent directory, since the file
+// being viewed may itself be an index.html. exists reports whether a path
+// exists, so detection can be tested without touching the filesystem.
+func findProjectRoot(dir string, maxDepth int, exists func(path string) bool) string {
+	current := filepath.Clean(dir)
+	for depth := 0; depth <= maxDepth; depth++ {
+		if exists(filepath.Join(current, ".git")) || exists(filepath.Join(current, "package.json")) {
+			return current
+		}
+		if depth > 0 && exists(filepath.Join(current, "index.html")) {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break // Reached filesystem root
+		}
+		current = parent
+	}
+	return ""
+}
+
+// pathExists reports whether a file or directory exists at path
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// isWithinDir returns true if path is dir or is located beneath it.
+// Both paths must be absolute and clean.
+func isWithinDir(path, dir string) bool {
+	if path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
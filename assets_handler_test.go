@@ -175,10 +175,10 @@ func TestLocalFileHandler_ContentType(t *testing.T) {
 
 	// Create test files with different extensions
 	files := map[string]string{
-		"style.css":  ".test { }",
-		"script.js":  "console.log('test');",
-		"image.svg":  "<svg></svg>",
-		"data.json":  "{}",
+		"style.css": ".test { }",
+		"script.js": "console.log('test');",
+		"image.svg": "<svg></svg>",
+		"data.json": "{}",
 	}
 
 	for name, content := range files {
@@ -224,3 +224,602 @@ func TestLocalFileHandler_ContentType(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalFileHandler_ServeRoot(t *testing.T) {
+	// Create a project with a shared assets folder and an HTML file in a subfolder
+	tmpDir, err := os.MkdirTemp("", "fenestro-serve-root-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	projectDir := filepath.Join(tmpDir, "project")
+	sharedDir := filepath.Join(projectDir, "shared")
+	pagesDir := filepath.Join(projectDir, "pages")
+	for _, dir := range []string{sharedDir, pagesDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cssContent := "body { color: blue; }"
+	if err := os.WriteFile(filepath.Join(sharedDir, "style.css"), []byte(cssContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A file outside the project root that must never be served
+	if err := os.WriteFile(filepath.Join(tmpDir, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp(FileEntry{
+		Name:    "index.html",
+		Path:    filepath.Join(pagesDir, "index.html"),
+		Content: "<html></html>",
+	}, "")
+	app.config.ServeRoot = projectDir
+
+	handler := NewLocalFileHandler(app)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "serves up-one-level asset within root",
+			path:           "/localfile/../shared/style.css",
+			expectedStatus: http.StatusOK,
+			expectedBody:   cssContent,
+		},
+		{
+			name:           "rejects traversal outside root",
+			path:           "/localfile/../../secret.txt",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLocalFileHandler_ServeRootNotAncestor(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fenestro-serve-root-unrelated-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	htmlDir := filepath.Join(tmpDir, "html")
+	otherDir := filepath.Join(tmpDir, "other")
+	for _, dir := range []string{htmlDir, otherDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(otherDir, "style.css"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp(FileEntry{
+		Name:    "test.html",
+		Path:    filepath.Join(htmlDir, "test.html"),
+		Content: "<html></html>",
+	}, "")
+	// serve_root that isn't an ancestor of the file falls back to strict confinement
+	app.config.ServeRoot = otherDir
+
+	handler := NewLocalFileHandler(app)
+
+	req := httptest.NewRequest(http.MethodGet, "/localfile/../other/style.css", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d when serve_root is not an ancestor, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+// fakeFS returns an exists function backed by a fixed set of paths
+func fakeFS(paths ...string) func(string) bool {
+	set := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		set[filepath.FromSlash(p)] = true
+	}
+	return func(path string) bool {
+		return set[path]
+	}
+}
+
+func TestFindProjectRoot(t *testing.T) {
+	start := filepath.FromSlash("/home/user/site/docs/guide")
+
+	tests := []struct {
+		name     string
+		exists   func(string) bool
+		maxDepth int
+		expected string
+	}{
+		{
+			name:     "finds .git in ancestor",
+			exists:   fakeFS("/home/user/site/.git"),
+			maxDepth: 5,
+			expected: filepath.FromSlash("/home/user/site"),
+		},
+		{
+			name:     "finds package.json in ancestor",
+			exists:   fakeFS("/home/user/site/docs/package.json"),
+			maxDepth: 5,
+			expected: filepath.FromSlash("/home/user/site/docs"),
+		},
+		{
+			name:     "marker in start directory",
+			exists:   fakeFS("/home/user/site/docs/guide/.git"),
+			maxDepth: 5,
+			expected: start,
+		},
+		{
+			name:     "index.html at a higher level",
+			exists:   fakeFS("/home/user/site/index.html"),
+			maxDepth: 5,
+			expected: filepath.FromSlash("/home/user/site"),
+		},
+		{
+			name:     "index.html in start directory is ignored",
+			exists:   fakeFS("/home/user/site/docs/guide/index.html"),
+			maxDepth: 5,
+			expected: "",
+		},
+		{
+			name:     "nearest marker wins",
+			exists:   fakeFS("/home/user/.git", "/home/user/site/package.json"),
+			maxDepth: 5,
+			expected: filepath.FromSlash("/home/user/site"),
+		},
+		{
+			name:     "no markers",
+			exists:   fakeFS(),
+			maxDepth: 5,
+			expected: "",
+		},
+		{
+			name:     "marker within depth limit",
+			exists:   fakeFS("/home/user/.git"),
+			maxDepth: 3,
+			expected: filepath.FromSlash("/home/user"),
+		},
+		{
+			name:     "marker beyond depth limit",
+			exists:   fakeFS("/home/user/.git"),
+			maxDepth: 2,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findProjectRoot(start, tt.maxDepth, tt.exists)
+			if got != tt.expected {
+				t.Errorf("findProjectRoot() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLocalFileHandler_AutoServeRoot(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fenestro-auto-root-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	projectDir := filepath.Join(tmpDir, "project")
+	sharedDir := filepath.Join(projectDir, "shared")
+	pagesDir := filepath.Join(projectDir, "pages")
+	for _, dir := range []string{sharedDir, pagesDir, filepath.Join(projectDir, ".git")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(sharedDir, "style.css"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp(FileEntry{
+		Name:    "index.html",
+		Path:    filepath.Join(pagesDir, "index.html"),
+		Content: "<html></html>",
+	}, "")
+	handler := NewLocalFileHandler(app)
+
+	// Strict confinement by default
+	req := httptest.NewRequest(http.MethodGet, "/localfile/../shared/style.css", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d without auto_serve_root, got %d", http.StatusForbidden, w.Code)
+	}
+
+	// Detected project root allows the up-level reference
+	app.config.AutoServeRoot = true
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d with auto_serve_root, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestLocalFileHandler_XHTMLContentType(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fenestro-xhtml-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "page.xhtml"), []byte("<html/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp(FileEntry{
+		Name:    "test.html",
+		Path:    filepath.Join(tmpDir, "test.html"),
+		Content: "<html></html>",
+	}, "")
+	handler := NewLocalFileHandler(app)
+
+	req := httptest.NewRequest(http.MethodGet, "/localfile/page.xhtml", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/xhtml+xml") {
+		t.Errorf("Expected Content-Type application/xhtml+xml, got %q", contentType)
+	}
+}
+
+func TestLocalFileHandler_CustomBasePath(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fenestro-custom-base-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	assetsDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(assetsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(assetsDir, "style.css"), []byte("p {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Content generated in a temp dir, assets in a project dir
+	app := NewApp(FileEntry{
+		Name:     "report.html",
+		Path:     filepath.Join(tmpDir, "generated", "report.html"),
+		BasePath: assetsDir,
+		Content:  "<html></html>",
+	}, "")
+	handler := NewLocalFileHandler(app)
+
+	req := httptest.NewRequest(http.MethodGet, "/localfile/style.css", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 from custom base path, got %d", w.Code)
+	}
+	if w.Body.String() != "p {}" {
+		t.Errorf("Expected asset content from custom base path, got %q", w.Body.String())
+	}
+}
+
+func TestLocalFileHandler_Disabled(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "style.css"), []byte("body {}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		disable func(app *App, h *LocalFileHandler)
+	}{
+		{"flag", func(app *App, h *LocalFileHandler) { h.disabled = true }},
+		{"config", func(app *App, h *LocalFileHandler) { app.config.DisableLocalFiles = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApp(FileEntry{
+				Name:    "test.html",
+				Path:    filepath.Join(tmpDir, "test.html"),
+				Content: "<html><link rel=\"stylesheet\" href=\"style.css\"></html>",
+			}, "")
+			handler := NewLocalFileHandler(app)
+
+			// Sanity check: the asset is served while the handler is enabled
+			req := httptest.NewRequest(http.MethodGet, "/localfile/style.css", nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d before disabling, got %d", http.StatusOK, w.Code)
+			}
+
+			tt.disable(app, handler)
+
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != http.StatusNotFound {
+				t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+			}
+
+			// The main content is unaffected
+			if app.GetHTMLContent() == "" {
+				t.Error("Main content should still be available with local files disabled")
+			}
+		})
+	}
+}
+
+func TestMimeTypeForExtModernImages(t *testing.T) {
+	tests := []struct {
+		ext      string
+		expected string
+	}{
+		{".webp", "image/webp"},
+		{".avif", "image/avif"},
+		{".heic", "image/heic"},
+		{".HEIC", "image/heic"},
+		{".unknownext", "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			got := mimeTypeForExt(tt.ext)
+			if tt.expected == "application/octet-stream" {
+				if got != tt.expected {
+					t.Errorf("mimeTypeForExt(%q) = %q, expected %q", tt.ext, got, tt.expected)
+				}
+				return
+			}
+			// The system database may answer first (e.g. image/heif for .heic),
+			// but the result must be an inline-displayable image type
+			if !strings.HasPrefix(got, "image/") {
+				t.Errorf("mimeTypeForExt(%q) = %q, expected an image type", tt.ext, got)
+			}
+			// The built-in map must cover these even if the system database doesn't
+			if builtinMimeTypes[strings.ToLower(tt.ext)] != tt.expected {
+				t.Errorf("builtinMimeTypes[%q] = %q, expected %q", tt.ext, builtinMimeTypes[strings.ToLower(tt.ext)], tt.expected)
+			}
+		})
+	}
+}
+
+func TestLocalFileHandler_DotDotWithinBase(t *testing.T) {
+	tmpDir := t.TempDir()
+	htmlDir := filepath.Join(tmpDir, "html")
+	for _, dir := range []string{filepath.Join(htmlDir, "sub"), filepath.Join(htmlDir, "shared"), filepath.Join(tmpDir, "secret")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(htmlDir, "shared", "logo.png"), []byte("logo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "secret", "password.txt"), []byte("secret123"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp(FileEntry{
+		Name:    "test.html",
+		Path:    filepath.Join(htmlDir, "test.html"),
+		Content: "<html></html>",
+	}, "")
+	handler := NewLocalFileHandler(app)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"dot-dot that stays within the base", "/localfile/sub/../shared/logo.png", http.StatusOK, "logo"},
+		{"dot-dot at the start of a deeper path", "/localfile/sub/../sub/../shared/logo.png", http.StatusOK, "logo"},
+		{"dot-dot that escapes the base", "/localfile/sub/../../secret/password.txt", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLocalFileHandler_PreGzippedSibling(t *testing.T) {
+	tmpDir := t.TempDir()
+	cssContent := "body { color: red; }"
+	if err := os.WriteFile(filepath.Join(tmpDir, "style.css"), []byte(cssContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gzContent, err := compressContent(cssContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "style.css.gz"), gzContent, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp(FileEntry{
+		Name:    "test.html",
+		Path:    filepath.Join(tmpDir, "test.html"),
+		Content: "<html></html>",
+	}, "")
+	handler := NewLocalFileHandler(app)
+
+	t.Run("serves the .gz file when gzip is accepted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/localfile/style.css", nil)
+		req.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Errorf("Content-Encoding = %q, want gzip", got)
+		}
+		if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/css") {
+			t.Errorf("Content-Type = %q, want the original file's text/css", got)
+		}
+		body, err := decompressContent(w.Body.Bytes())
+		if err != nil {
+			t.Fatalf("body should be gzipped: %v", err)
+		}
+		if body != cssContent {
+			t.Errorf("decompressed body = %q, want %q", body, cssContent)
+		}
+	})
+
+	for _, acceptEncoding := range []string{"", "gzip;q=0", "br"} {
+		t.Run("falls back to the plain file for Accept-Encoding "+acceptEncoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/localfile/style.css", nil)
+			if acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Content-Encoding = %q, want none", got)
+			}
+			if w.Body.String() != cssContent {
+				t.Errorf("Expected body %q, got %q", cssContent, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestIsPageNavigation(t *testing.T) {
+	tests := []struct {
+		path string
+		dest string
+		mode string
+		want bool
+	}{
+		{"/site/other.html", "document", "navigate", true},
+		{"/site/other.HTM", "document", "", true},
+		{"/site/other.html", "", "navigate", true},
+		{"/site/other.html", "", "", false},
+		{"/site/other.html", "", "cors", false},
+		{"/site/frame.html", "iframe", "navigate", false},
+		{"/site/partial.html", "empty", "cors", false},
+		{"/site/style.css", "document", "navigate", false},
+		{"/site/app.js", "", "", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/localfile/x", nil)
+		if tt.dest != "" {
+			req.Header.Set("Sec-Fetch-Dest", tt.dest)
+		}
+		if tt.mode != "" {
+			req.Header.Set("Sec-Fetch-Mode", tt.mode)
+		}
+		if got := isPageNavigation(req, tt.path); got != tt.want {
+			t.Errorf("isPageNavigation(%s, Sec-Fetch-Dest %q, Sec-Fetch-Mode %q) = %v, want %v", tt.path, tt.dest, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestLocalFileHandlerSwitchesToLinkedPage(t *testing.T) {
+	dir := t.TempDir()
+	indexPath := filepath.Join(dir, "index.html")
+	otherPath := filepath.Join(dir, "other.html")
+	os.WriteFile(indexPath, []byte(`<a href="other.html">other</a>`), 0644)
+	os.WriteFile(otherPath, []byte("<p>other page</p>"), 0644)
+	os.WriteFile(filepath.Join(dir, "style.css"), []byte("p { color: red; }"), 0644)
+
+	app := NewApp(FileEntry{Name: "Docs", Path: indexPath, Content: `<a href="other.html">other</a>`}, "")
+	handler := NewLocalFileHandler(app)
+
+	req := httptest.NewRequest(http.MethodGet, "/localfile/other.html", nil)
+	req.Header.Set("Sec-Fetch-Dest", "document")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Fatalf("navigation response = %d to %q, want a redirect back to fenestro's page", w.Code, w.Header().Get("Location"))
+	}
+	if got := app.GetCurrentFilePath(); got != otherPath {
+		t.Errorf("current file = %q, want the linked page selected", got)
+	}
+	if got := app.GetHTMLContent(); got != "<p>other page</p>" {
+		t.Errorf("GetHTMLContent() = %q, want the linked page's content", got)
+	}
+	if got := len(app.GetFiles()); got != 2 {
+		t.Errorf("sidebar has %d files, want the linked page added", got)
+	}
+
+	// Following a link back selects the existing entry, keeping its name
+	req = httptest.NewRequest(http.MethodGet, "/localfile/index.html", nil)
+	req.Header.Set("Sec-Fetch-Mode", "navigate")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got := len(app.GetFiles()); got != 2 {
+		t.Errorf("sidebar has %d files, want the existing entry reused", got)
+	}
+	if got := app.GetCurrentFileName(); got != "Docs" {
+		t.Errorf("current file name = %q, want Docs kept", got)
+	}
+
+	// Assets and framed pages are still served as files
+	for _, tc := range []struct{ path, dest, body string }{
+		{"/localfile/style.css", "style", "p { color: red; }"},
+		{"/localfile/other.html", "iframe", "<p>other page</p>"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("Sec-Fetch-Dest", tc.dest)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.String() != tc.body {
+			t.Errorf("GET %s (%s) = %d %q, want the raw file", tc.path, tc.dest, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestOpenLinkedFileRespectsAllowedExtensions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "other.htm")
+	os.WriteFile(path, []byte("<p>other</p>"), 0644)
+
+	app := NewApp(FileEntry{Name: "index.html", Path: filepath.Join(dir, "index.html")}, "")
+	app.config.AllowedExtensions = []string{".html"}
+	if err := app.OpenLinkedFile(path); err == nil {
+		t.Error("OpenLinkedFile() should refuse extensions outside allowed_extensions")
+	}
+	if got := len(app.GetFiles()); got != 1 {
+		t.Errorf("sidebar has %d files, want the refused page not added", got)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import "regexp"
+
+// baseTagPattern matches an opening <base> tag (case-insensitive)
+var baseTagPattern = regexp.MustCompile(`(?i)<base[\s/>]`)
+
+// DocumentHasBaseTag returns true if the HTML content declares its own <base> tag
+func DocumentHasBaseTag(content string) bool {
+	return baseTagPattern.MatchString(content)
+}
+
+// shouldApplyBasePath decides whether the frontend should resolve relative
+// URLs against the file's directory, given the configured base href mode.
+// Unknown or empty modes behave like "auto".
+func shouldApplyBasePath(mode, content string) bool {
+	switch mode {
+	case BaseHrefAlways:
+		return true
+	case BaseHrefNever:
+		return false
+	default:
+		return !DocumentHasBaseTag(content)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+func TestDocumentHasBaseTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{"no base tag", "<html><head><title>x</title></head></html>", false},
+		{"base with href", `<html><head><base href="https://example.com/"></head></html>`, true},
+		{"uppercase base", `<HTML><HEAD><BASE HREF="/docs/"></HEAD></HTML>`, true},
+		{"self-closing base", `<head><base href="/"/></head>`, true},
+		{"bare base", `<head><base></head>`, true},
+		{"basefont is not base", `<head><basefont size="3"></head>`, false},
+		{"empty content", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DocumentHasBaseTag(tt.content); got != tt.expected {
+				t.Errorf("DocumentHasBaseTag(%q) = %v, want %v", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestShouldApplyBasePath(t *testing.T) {
+	withBase := `<html><head><base href="https://example.com/"></head></html>`
+	withoutBase := `<html><head></head><body><img src="a.png"></body></html>`
+
+	tests := []struct {
+		name     string
+		mode     string
+		content  string
+		expected bool
+	}{
+		{"auto without base", BaseHrefAuto, withoutBase, true},
+		{"auto with base", BaseHrefAuto, withBase, false},
+		{"always without base", BaseHrefAlways, withoutBase, true},
+		{"always with base", BaseHrefAlways, withBase, true},
+		{"never without base", BaseHrefNever, withoutBase, false},
+		{"never with base", BaseHrefNever, withBase, false},
+		{"empty mode behaves like auto", "", withBase, false},
+		{"unknown mode behaves like auto", "sometimes", withoutBase, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldApplyBasePath(tt.mode, tt.content); got != tt.expected {
+				t.Errorf("shouldApplyBasePath(%q, ...) = %v, want %v", tt.mode, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAppShouldApplyBasePath(t *testing.T) {
+	app := NewApp(FileEntry{
+		Name:    "test.html",
+		Path:    "/tmp/test.html",
+		Content: `<html><head><base href="/other/"></head></html>`,
+	}, "")
+
+	app.config.BaseHrefMode = BaseHrefAuto
+	if app.ShouldApplyBasePath() {
+		t.Error("ShouldApplyBasePath() should be false in auto mode when document has a <base>")
+	}
+
+	app.config.BaseHrefMode = BaseHrefAlways
+	if !app.ShouldApplyBasePath() {
+		t.Error("ShouldApplyBasePath() should be true in always mode")
+	}
+
+	empty := &App{files: []FileEntry{}}
+	if empty.ShouldApplyBasePath() {
+		t.Error("ShouldApplyBasePath() with empty files should be false")
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "regexp"
+
+var (
+	// charsetMetaPattern matches <meta charset="..."> and the older
+	// <meta http-equiv="Content-Type" content="text/html; charset=...">
+	charsetMetaPattern = regexp.MustCompile(`(?i)<meta\s[^>]*charset\s*=`)
+	headOpenPattern    = regexp.MustCompile(`(?i)<head(?:\s[^>]*)?>`)
+	htmlOpenPattern    = regexp.MustCompile(`(?i)<html(?:\s[^>]*)?>`)
+)
+
+// charsetMeta is the declaration added to documents that lack one
+const charsetMeta = `<meta charset="utf-8">`
+
+// ensureCharset declares UTF-8 in a full HTML document (one with an <html>
+// or <head> tag) that doesn't declare a charset, so non-ASCII text isn't
+// decoded with a legacy default. Fragments are left alone: they're rendered
+// into fenestro's own page, which already declares UTF-8.
+func ensureCharset(content string) string {
+	if charsetMetaPattern.MatchString(content) {
+		return content
+	}
+	if loc := headOpenPattern.FindStringIndex(content); loc != nil {
+		return content[:loc[1]] + charsetMeta + content[loc[1]:]
+	}
+	if loc := htmlOpenPattern.FindStringIndex(content); loc != nil {
+		return content[:loc[1]] + "<head>" + charsetMeta + "</head>" + content[loc[1]:]
+	}
+	return content
+}
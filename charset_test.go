@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func TestEnsureCharset(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			"charset meta present",
+			`<html><head><meta charset="iso-8859-1"><title>t</title></head><body>café</body></html>`,
+			`<html><head><meta charset="iso-8859-1"><title>t</title></head><body>café</body></html>`,
+		},
+		{
+			"http-equiv charset present",
+			`<HTML><HEAD><META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=utf-8"></HEAD></HTML>`,
+			`<HTML><HEAD><META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=utf-8"></HEAD></HTML>`,
+		},
+		{
+			"head without charset",
+			`<!DOCTYPE html><html lang="fr"><head><title>t</title></head><body>café</body></html>`,
+			`<!DOCTYPE html><html lang="fr"><head><meta charset="utf-8"><title>t</title></head><body>café</body></html>`,
+		},
+		{
+			"head with attributes",
+			`<head profile="x"><title>t</title></head>`,
+			`<head profile="x"><meta charset="utf-8"><title>t</title></head>`,
+		},
+		{
+			"html without head",
+			`<html><body>café</body></html>`,
+			`<html><head><meta charset="utf-8"></head><body>café</body></html>`,
+		},
+		{
+			"header element is not head",
+			`<html><header>h</header></html>`,
+			`<html><head><meta charset="utf-8"></head><header>h</header></html>`,
+		},
+		{"fragment left alone", `<p>café</p>`, `<p>café</p>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ensureCharset(tt.content); got != tt.want {
+				t.Errorf("ensureCharset(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetHTMLContentDeclaresCharset(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Content: "<html><head></head><body>é</body></html>", ContentType: ContentTypeHTML}, "")
+	if got, want := app.GetHTMLContent(), `<html><head><meta charset="utf-8"></head><body>é</body></html>`; got != want {
+		t.Errorf("GetHTMLContent() = %q, want %q", got, want)
+	}
+
+	// XML documents declare their encoding in the XML declaration instead
+	xml := `<?xml version="1.0"?><html xmlns="http://www.w3.org/1999/xhtml"><head></head></html>`
+	app = NewApp(FileEntry{Name: "a.xhtml", Content: xml, ContentType: ContentTypeXHTML}, "")
+	if got := app.GetHTMLContent(); got != xml {
+		t.Errorf("GetHTMLContent() = %q, want XHTML left as-is", got)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// logsDir holds per-window IPC command logs under the socket directory
+	logsDir = "logs"
+	// ipcLogEnv enables IPC command logging without editing the config
+	ipcLogEnv = "FENESTRO_IPC_LOG"
+	// DefaultIPCLogMaxSize is the size at which a command log is rotated when
+	// ipc_log_max_size is not set
+	DefaultIPCLogMaxSize = 1 << 20
+)
+
+// commandLogEntry is one line of a window's IPC command log
+type commandLogEntry struct {
+	Time  time.Time `json:"time"`
+	Cmd   string    `json:"cmd"`
+	Path  string    `json:"path,omitempty"`
+	Name  string    `json:"name,omitempty"`
+	Bytes int       `json:"bytes"`
+	OK    bool      `json:"ok"`
+}
+
+// newCommandLogEntry summarizes cmd for the log. Content itself is never
+// logged, only its size; for set-files the sizes of all files are summed.
+func newCommandLogEntry(cmd IPCCommand, resp IPCResponse, now time.Time) commandLogEntry {
+	entry := commandLogEntry{Time: now.UTC(), Cmd: cmd.Cmd, OK: resp.OK}
+	switch cmd.Cmd {
+	case "add-file":
+		entry.Path, entry.Name, entry.Bytes = cmd.Entry.Path, cmd.Entry.Name, len(cmd.Entry.Content)
+	case "replace":
+		entry.Path, entry.Name, entry.Bytes = cmd.Path, cmd.Name, len(cmd.Content)
+	case "set-files":
+		for _, f := range cmd.Files {
+			entry.Bytes += len(f.Content)
+		}
+	}
+	return entry
+}
+
+// commandLog appends a JSON line per processed IPC command to a window's
+// log file, rotating it to <file>.1 once it reaches maxSize bytes
+type commandLog struct {
+	path    string
+	maxSize int64
+	mu      sync.Mutex
+}
+
+// newCommandLog returns the command log for a window, or nil if logging is
+// off. It's enabled by ipc_log or the FENESTRO_IPC_LOG environment variable.
+func newCommandLog(config Config, windowID, instance string) *commandLog {
+	if !config.IPCLog && os.Getenv(ipcLogEnv) == "" {
+		return nil
+	}
+	maxSize := config.IPCLogMaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultIPCLogMaxSize
+	}
+	return &commandLog{path: getCommandLogPath(windowID, instance), maxSize: maxSize}
+}
+
+// getCommandLogPath returns ~/.fenestro/logs/<id>.log for a window ID, or
+// sidebar.log (sidebar-<instance>.log with --instance) in sidebar mode
+func getCommandLogPath(windowID, instance string) string {
+	name := windowID
+	if name == "" {
+		name = "sidebar"
+		if instance != "" {
+			name += "-" + instance
+		}
+	}
+	return filepath.Join(getSocketDir(), logsDir, name+".log")
+}
+
+// moveTo renames the log and its rotated copy to path, where commands are
+// recorded from then on, for a window whose ID changed (rename-id)
+func (l *commandLog) moveTo(path string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := l.path
+	l.path = path
+	for _, suffix := range []string{"", ".1"} {
+		// A log that was never written or rotated has nothing to move
+		if err := os.Rename(old+suffix, path+suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to move command log: %w", err)
+		}
+	}
+	return nil
+}
+
+// Record appends the log line for a processed command
+func (l *commandLog) Record(cmd IPCCommand, resp IPCResponse) error {
+	line, err := json.Marshal(newCommandLogEntry(cmd, resp, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to encode log entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f, err := openLogFile(l.path, l.maxSize)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
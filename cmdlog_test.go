@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandLogEntryFormat(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	cmd := IPCCommand{Cmd: "replace", Path: "/tmp/a.html", Name: "a.html", Content: "<p>hello</p>", Token: "secret"}
+
+	line, err := json.Marshal(newCommandLogEntry(cmd, IPCResponse{OK: true}, now))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"time":"2024-05-01T12:30:00Z","cmd":"replace","path":"/tmp/a.html","name":"a.html","bytes":12,"ok":true}`
+	if string(line) != want {
+		t.Errorf("log line = %s, want %s", line, want)
+	}
+	if strings.Contains(string(line), "secret") || strings.Contains(string(line), "hello") {
+		t.Error("log line should not include the token or content")
+	}
+}
+
+func TestCommandLogEntryFields(t *testing.T) {
+	now := time.Now()
+
+	add := newCommandLogEntry(IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "12345"}}, IPCResponse{OK: true}, now)
+	if add.Path != "/tmp/b.html" || add.Name != "b.html" || add.Bytes != 5 {
+		t.Errorf("add-file entry = %+v", add)
+	}
+
+	set := newCommandLogEntry(IPCCommand{Cmd: "set-files", Files: []FileEntry{{Content: "123"}, {Content: "4567"}}}, IPCResponse{OK: false}, now)
+	if set.Bytes != 7 || set.OK {
+		t.Errorf("set-files entry = %+v, want the summed size and ok=false", set)
+	}
+}
+
+func TestCommandLogRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "window.log")
+	log := &commandLog{path: path, maxSize: 200}
+
+	cmd := IPCCommand{Cmd: "replace", Path: "/tmp/a.html", Name: "a.html", Content: "<p>a</p>"}
+	for i := 0; i < 5; i++ {
+		if err := log.Record(cmd, IPCResponse{OK: true}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	rotated, err := os.Stat(path + ".1")
+	if err != nil {
+		t.Fatalf("log should have been rotated to .1: %v", err)
+	}
+	if rotated.Size() < 200 {
+		t.Errorf("rotated log is %d bytes, expected it to have reached the limit", rotated.Size())
+	}
+	current, err := os.Stat(path)
+	if err != nil || current.Size() >= 200 {
+		t.Errorf("current log should be below the limit after rotation (err = %v)", err)
+	}
+}
+
+func TestNewCommandLog(t *testing.T) {
+	t.Setenv(ipcLogEnv, "")
+	if log := newCommandLog(Config{}, "id", ""); log != nil {
+		t.Error("command log should be off by default")
+	}
+
+	log := newCommandLog(Config{IPCLog: true}, "id", "")
+	if log == nil || log.maxSize != DefaultIPCLogMaxSize {
+		t.Fatalf("newCommandLog() = %+v, want the default max size", log)
+	}
+
+	t.Setenv(ipcLogEnv, "1")
+	if newCommandLog(Config{}, "id", "") == nil {
+		t.Errorf("%s should enable the command log", ipcLogEnv)
+	}
+}
+
+func TestGetCommandLogPath(t *testing.T) {
+	dir := filepath.Join(getSocketDir(), logsDir)
+	tests := []struct {
+		windowID, instance, want string
+	}{
+		{"abc-123", "", filepath.Join(dir, "abc-123.log")},
+		{"", "", filepath.Join(dir, "sidebar.log")},
+		{"", "docs", filepath.Join(dir, "sidebar-docs.log")},
+	}
+	for _, tt := range tests {
+		if got := getCommandLogPath(tt.windowID, tt.instance); got != tt.want {
+			t.Errorf("getCommandLogPath(%q, %q) = %q, want %q", tt.windowID, tt.instance, got, tt.want)
+		}
+	}
+}
+
+func TestIPCServerLogsCommands(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial"}, "")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-cmdlog.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	logPath := filepath.Join(t.TempDir(), "window.log")
+	server.commandLog = &commandLog{path: logPath, maxSize: DefaultIPCLogMaxSize}
+	server.Start()
+	defer server.Close()
+
+	SendCommand(socketPath, IPCCommand{Cmd: "ping"})
+	SendCommand(socketPath, IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}})
+	SendCommand(socketPath, IPCCommand{Cmd: "replace", Path: "/tmp/a.html", Content: "<p>a2</p>"})
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		t.Fatalf("command log not written: %v", err)
+	}
+	defer f.Close()
+
+	var cmds []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry commandLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", scanner.Text(), err)
+		}
+		cmds = append(cmds, entry.Cmd)
+	}
+	if strings.Join(cmds, ",") != "add-file,replace" {
+		t.Errorf("logged commands = %v, want add-file then replace (pings skipped)", cmds)
+	}
+}
+
+func TestIPCServerRenameIDMovesCommandLog(t *testing.T) {
+	useTempSocketDir(t)
+	t.Setenv(ipcLogEnv, "1")
+	oldID := "123e4567-e89b-12d3-a456-426614174000"
+	newID := "9b2f6c1e-4d3a-4c5b-8e7f-0a1b2c3d4e5f"
+	app := NewApp(FileEntry{Name: "report.html", Content: "<p>r</p>"}, oldID)
+	startTestServer(t, app, getWindowSocketPath(oldID))
+
+	add := IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}}
+	if err := sendCommandOK(getWindowSocketPath(oldID), add); err != nil {
+		t.Fatalf("add-file failed: %v", err)
+	}
+	if err := sendCommandOK(getWindowSocketPath(oldID), IPCCommand{Cmd: "rename-id", NewID: newID}); err != nil {
+		t.Fatalf("rename-id failed: %v", err)
+	}
+	if err := sendCommandOK(getWindowSocketPath(newID), add); err != nil {
+		t.Fatalf("add-file after rename failed: %v", err)
+	}
+
+	if _, err := os.Stat(getCommandLogPath(oldID, "")); !os.IsNotExist(err) {
+		t.Errorf("the log should no longer be at the old ID's path, stat error = %v", err)
+	}
+	data, err := os.ReadFile(getCommandLogPath(newID, ""))
+	if err != nil {
+		t.Fatalf("command log not at the new ID's path: %v", err)
+	}
+	var cmds []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry commandLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		cmds = append(cmds, entry.Cmd)
+	}
+	if got := strings.Join(cmds, ","); got != "add-file,rename-id,add-file" {
+		t.Errorf("logged commands = %s, want the whole history in the new log", got)
+	}
+}
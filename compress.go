@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// compressContent gzips content for storage
+func compressContent(content string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(content)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressContent reverses compressContent
+func decompressContent(data []byte) (string, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// content returns the entry's content, decompressing it if it's stored
+// compressed
+func (f *FileEntry) content() string {
+	if f.compressed == nil {
+		return f.Content
+	}
+	content, err := decompressContent(f.compressed)
+	if err != nil {
+		return ""
+	}
+	return content
+}
+
+// compress moves the entry's content into compressed storage. Content that
+// fails to compress is left as-is.
+func (f *FileEntry) compress() {
+	if f.compressed != nil || f.Content == "" {
+		return
+	}
+	data, err := compressContent(f.Content)
+	if err != nil {
+		return
+	}
+	f.compressed = data
+	f.Content = ""
+}
+
+// decompress restores the entry's content from compressed storage
+func (f *FileEntry) decompress() {
+	if f.compressed == nil {
+		return
+	}
+	f.Content = f.content()
+	f.compressed = nil
+}
+
+// compactFiles compresses every file except the selected one when
+// compress_inactive is set, and decompresses everything otherwise.
+// Must be called with a.mu held for writing.
+func (a *App) compactFiles() {
+	for i := range a.files {
+		if a.config.CompressInactive && i != a.currentIndex {
+			a.files[i].compress()
+		} else {
+			a.files[i].decompress()
+		}
+	}
+}
+
+// filesWithContent returns a copy of the file list with all content
+// decompressed, for accessors and event payloads. Must be called with a.mu
+// held.
+func (a *App) filesWithContent() []FileEntry {
+	result := make([]FileEntry, len(a.files))
+	copy(result, a.files)
+	for i := range result {
+		result[i].decompress()
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompressContentRoundTrip(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"<p>hello</p>",
+		strings.Repeat("<tr><td>row</td></tr>\n", 10000),
+		"unicode: héllo wörld ✓ \x00 binary-ish",
+	} {
+		data, err := compressContent(content)
+		if err != nil {
+			t.Fatalf("compressContent() error = %v", err)
+		}
+		got, err := decompressContent(data)
+		if err != nil {
+			t.Fatalf("decompressContent() error = %v", err)
+		}
+		if got != content {
+			t.Errorf("round trip changed content of length %d", len(content))
+		}
+	}
+}
+
+func newCompressingApp(t *testing.T) *App {
+	t.Helper()
+	useTempConfigDir(t)
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "")
+	app.config.CompressInactive = true
+	app.AddFile(FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "<p>b</p>"})
+	app.AddFile(FileEntry{Name: "c.html", Path: "/tmp/c.html", Content: "<p>c</p>"})
+	return app
+}
+
+func TestCompressInactiveKeepsSelectedUncompressed(t *testing.T) {
+	app := newCompressingApp(t)
+
+	app.SelectFile(1)
+	for i, f := range app.files {
+		if i == app.currentIndex {
+			if f.compressed != nil || f.Content != "<p>b</p>" {
+				t.Errorf("selected file should be stored uncompressed, got %+v", f)
+			}
+		} else if f.compressed == nil || f.Content != "" {
+			t.Errorf("inactive file %s should be stored compressed", f.Name)
+		}
+	}
+}
+
+func TestCompressInactiveAccessorsReturnContent(t *testing.T) {
+	app := newCompressingApp(t)
+
+	if got := app.SelectFile(2); got != "<p>c</p>" {
+		t.Errorf("SelectFile(2) = %q, want <p>c</p>", got)
+	}
+	if got := app.SelectFile(0); got != "<p>a</p>" {
+		t.Errorf("SelectFile(0) = %q, want <p>a</p>", got)
+	}
+	if got := app.GetHTMLContent(); got != "<p>a</p>" {
+		t.Errorf("GetHTMLContent() = %q, want <p>a</p>", got)
+	}
+	if got := app.SetCurrentByPath("/tmp/b.html"); got != "<p>b</p>" {
+		t.Errorf("SetCurrentByPath() = %q, want <p>b</p>", got)
+	}
+
+	for _, f := range app.GetFiles() {
+		if want := "<p>" + strings.TrimSuffix(f.Name, ".html") + "</p>"; f.Content != want {
+			t.Errorf("GetFiles() content for %s = %q, want %q", f.Name, f.Content, want)
+		}
+	}
+	data, err := app.GetFilesJSON(true)
+	if err != nil {
+		t.Fatalf("GetFilesJSON() error = %v", err)
+	}
+	// json.Marshal escapes < and > in HTML
+	if !strings.Contains(data, `\u003cp\u003ec\u003c/p\u003e`) {
+		t.Errorf("GetFilesJSON(true) should include decompressed content, got %s", data)
+	}
+}
+
+func TestCompressInactiveReplaceUpdatesContent(t *testing.T) {
+	app := newCompressingApp(t)
+	app.SelectFile(0)
+
+	// Replacing an inactive, compressed file selects it with the new content
+	app.ReplaceFileContent("/tmp/c.html", "<p>c2</p>", "")
+	if got := app.GetHTMLContent(); got != "<p>c2</p>" {
+		t.Errorf("GetHTMLContent() after replace = %q, want <p>c2</p>", got)
+	}
+	if got := app.SelectFile(0); got != "<p>a</p>" {
+		t.Errorf("SelectFile(0) = %q, want <p>a</p>", got)
+	}
+	if got := app.SelectFile(2); got != "<p>c2</p>" {
+		t.Errorf("SelectFile(2) = %q, want the replaced content", got)
+	}
+}
+
+func TestCompressInactiveDisabledStoresPlain(t *testing.T) {
+	app := newCompressingApp(t)
+	app.config.CompressInactive = false
+	app.SelectFile(1)
+
+	for _, f := range app.files {
+		if f.compressed != nil {
+			t.Errorf("file %s should be decompressed once compress_inactive is off", f.Name)
+		}
+	}
+}
+
+func TestCompressInactiveEventsCarryContent(t *testing.T) {
+	app := newCompressingApp(t)
+	rec := recordEvents(app)
+
+	app.AddFile(FileEntry{Name: "d.html", Path: "/tmp/d.html", Content: "<p>d</p>"})
+	app.updateFileContent("/tmp/b.html", "<p>b2</p>")
+
+	for _, tt := range []struct {
+		event string
+		want  map[string]string
+	}{
+		{"file-added", map[string]string{"a.html": "<p>a</p>", "b.html": "<p>b</p>", "c.html": "<p>c</p>", "d.html": "<p>d</p>"}},
+		{"file-updated", map[string]string{"a.html": "<p>a</p>", "b.html": "<p>b2</p>", "c.html": "<p>c</p>", "d.html": "<p>d</p>"}},
+	} {
+		events := rec.named(tt.event)
+		if len(events) != 1 {
+			t.Fatalf("got %d %s events, want 1", len(events), tt.event)
+		}
+		files := events[0].data[0].(map[string]interface{})["files"].([]FileEntry)
+		for _, f := range files {
+			if f.Content != tt.want[f.Name] {
+				t.Errorf("%s payload content of %s = %q, want %q", tt.event, f.Name, f.Content, tt.want[f.Name])
+			}
+		}
+	}
+
+	for i, f := range app.files {
+		if i != app.currentIndex && f.compressed == nil {
+			t.Errorf("inactive file %s should still be stored compressed after emitting", f.Name)
+		}
+	}
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -22,12 +28,128 @@ type Config struct {
 	DefaultX int `toml:"default_x" json:"default_x"`
 	// DefaultY is the default window Y position in pixels (0 = use system default)
 	DefaultY int `toml:"default_y" json:"default_y"`
+	// hasDefaultPosition is set when --geometry gives a position, so that
+	// +0+0 is used rather than read as unset
+	hasDefaultPosition bool
+	// BaseHrefMode controls whether relative URLs are resolved against the
+	// file's directory: "auto" (skip if the document has its own <base>),
+	// "always", or "never"
+	BaseHrefMode string `toml:"base_href_mode" json:"base_href_mode"`
+	// ServeRoot is a project root directory that local assets may be served
+	// from, allowing ../ references above the file's own directory
+	ServeRoot string `toml:"serve_root" json:"serve_root"`
+	// AutoServeRoot detects the project root (by .git, package.json, or a
+	// higher-level index.html) when ServeRoot is not set
+	AutoServeRoot bool `toml:"auto_serve_root" json:"auto_serve_root"`
+	// IPCToken is a shared secret that IPC senders include with each command
+	IPCToken string `toml:"ipc_token" json:"-"`
+	// AllowedExtensions restricts which file extensions can be opened
+	// (e.g. [".html", ".htm"]). Empty allows all files.
+	AllowedExtensions []string `toml:"allowed_extensions" json:"allowed_extensions"`
+	// IPCWorkers is the number of workers processing IPC commands
+	// concurrently (0 = use DefaultIPCWorkers)
+	IPCWorkers int `toml:"ipc_workers" json:"ipc_workers"`
+	// DisableLocalFiles stops relative assets from being served from disk
+	DisableLocalFiles bool `toml:"disable_local_files" json:"disable_local_files"`
+	// LogMaxSize rotates the --log-file to <file>.1 once it reaches this many
+	// bytes (0 = never rotate)
+	LogMaxSize int64 `toml:"log_max_size" json:"log_max_size"`
+	// MinWidth is the minimum window width in pixels (0 = use app default)
+	MinWidth int `toml:"min_width" json:"min_width"`
+	// MinHeight is the minimum window height in pixels (0 = use app default)
+	MinHeight int `toml:"min_height" json:"min_height"`
+	// HeaderHTML is the path to an HTML snippet shown above every document
+	HeaderHTML string `toml:"header_html" json:"header_html"`
+	// FooterHTML is the path to an HTML snippet shown below every document
+	FooterHTML string `toml:"footer_html" json:"footer_html"`
+	// CompressInactive keeps the content of files other than the selected
+	// one gzipped in memory
+	CompressInactive bool `toml:"compress_inactive" json:"compress_inactive"`
+	// IPCLog appends a line per IPC command received to a per-window log in
+	// ~/.fenestro/logs (also enabled by FENESTRO_IPC_LOG)
+	IPCLog bool `toml:"ipc_log" json:"ipc_log"`
+	// IPCLogMaxSize rotates each IPC command log once it reaches this many
+	// bytes (0 = use DefaultIPCLogMaxSize)
+	IPCLogMaxSize int64 `toml:"ipc_log_max_size" json:"ipc_log_max_size"`
+	// MaxIPCBytes caps the size of a single IPC message; larger messages are
+	// rejected and their connection closed (0 = use DefaultMaxIPCBytes)
+	MaxIPCBytes int64 `toml:"max_ipc_bytes" json:"max_ipc_bytes"`
+	// NormalizeLineEndings converts CRLF to LF in text, code, and diff
+	// content when it's loaded; HTML is left as-is
+	NormalizeLineEndings bool `toml:"normalize_line_endings" json:"normalize_line_endings"`
+	// WatchChromeCSS polls the chrome_css file and restyles open windows
+	// when it changes
+	WatchChromeCSS bool `toml:"watch_chrome_css" json:"watch_chrome_css"`
+	// TrustStdin renders piped (and --url) content as-is, scripts included.
+	// When false, it's sanitized as with --safe; opened files are unaffected.
+	TrustStdin bool `toml:"trust_stdin" json:"trust_stdin"`
+	// AssetCacheBytes bounds the memory used to keep recently served local
+	// files (stylesheets, scripts, images) for reuse (0 = no cache)
+	AssetCacheBytes int64 `toml:"asset_cache_bytes" json:"asset_cache_bytes"`
+	// ExportMaxInlineBytes is the largest file --export-html embeds as a
+	// data: URI; larger ones are left as references (0 = no limit)
+	ExportMaxInlineBytes int64 `toml:"export_max_inline_bytes" json:"export_max_inline_bytes"`
+	// NotifyOnReplace shows a desktop notification when a window in the
+	// background has its content replaced (e.g. by fenestro -p file --id)
+	NotifyOnReplace bool `toml:"notify_on_replace" json:"notify_on_replace"`
+	// RecentsEnabled records opened files in the recents list; when false,
+	// nothing is added (existing entries are kept until cleared)
+	RecentsEnabled bool `toml:"recents_enabled" json:"recents_enabled"`
+	// GroupByCwd gives sidebar calls from each working directory their own
+	// window, as if --instance were set to a name derived from the directory
+	GroupByCwd bool `toml:"group_by_cwd" json:"group_by_cwd"`
+	// ContentClass is the class on the element holding the rendered
+	// document, for chrome_css rules to scope themselves with
+	ContentClass string `toml:"content_class" json:"content_class"`
+	// Theme is "system" (follow the OS appearance), "light", or "dark"
+	Theme string `toml:"theme" json:"theme"`
 }
 
+// DefaultIPCWorkers is the IPC worker pool size when ipc_workers is not set
+const DefaultIPCWorkers = 8
+
+// chromeCSSPollInterval is how often watch_chrome_css re-reads the file
+const chromeCSSPollInterval = time.Second
+
+// DefaultAssetCacheBytes is the local file cache size when asset_cache_bytes
+// is not set
+const DefaultAssetCacheBytes = 4 << 20
+
+// DefaultExportMaxInlineBytes is the export_max_inline_bytes default
+const DefaultExportMaxInlineBytes = 10 << 20
+
+// DefaultContentClass is the content element's class when content_class is
+// not set
+const DefaultContentClass = "fenestro-content"
+
+// cssClassName matches a class name usable unescaped in a CSS selector
+var cssClassName = regexp.MustCompile(`^-?[_a-zA-Z][_a-zA-Z0-9-]*$`)
+
+// DefaultMaxIPCBytes is the IPC message size cap when max_ipc_bytes is not set
+const DefaultMaxIPCBytes = 64 << 20
+
+// Base href modes for Config.BaseHrefMode
+const (
+	BaseHrefAuto   = "auto"
+	BaseHrefAlways = "always"
+	BaseHrefNever  = "never"
+)
+
 // DefaultConfig returns the default configuration values
 func DefaultConfig() Config {
 	return Config{
-		FontSize: 0, // 0 means use browser default
+		FontSize:     0, // 0 means use browser default
+		BaseHrefMode: BaseHrefAuto,
+		IPCWorkers:   DefaultIPCWorkers,
+		// CRLF renders as doubled lines in <pre> views and breaks diff parsing
+		NormalizeLineEndings: true,
+		// Piped content has always run its scripts
+		TrustStdin:           true,
+		AssetCacheBytes:      DefaultAssetCacheBytes,
+		ExportMaxInlineBytes: DefaultExportMaxInlineBytes,
+		ContentClass:         DefaultContentClass,
+		Theme:                ThemeSystem,
+		RecentsEnabled:       true,
 	}
 }
 
@@ -57,26 +179,182 @@ func getConfigPath() string {
 // LoadConfig loads the configuration from the config file
 // Returns default config if file doesn't exist or can't be read
 func LoadConfig() Config {
+	config, warnings := readConfig()
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	return config
+}
+
+// readConfig implements LoadConfig, returning the problems it found with
+// the config file instead of printing them
+func readConfig() (Config, []string) {
 	config := DefaultConfig()
 
 	configPath := getConfigPath()
 	if configPath == "" {
-		return config
+		return config, nil
 	}
 
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return config
+		return config, nil
 	}
 
 	// Parse the config file
 	if _, err := toml.DecodeFile(configPath, &config); err != nil {
-		// Log error to stderr but continue with defaults
-		// Don't fail startup due to config issues
-		fmt.Fprintf(os.Stderr, "Warning: Failed to parse config file %s: %v\n", configPath, err)
-		fmt.Fprintf(os.Stderr, "Using default configuration. Check TOML syntax (string values must be quoted).\n")
-		return DefaultConfig()
+		// Continue with defaults; don't fail startup due to config issues
+		return DefaultConfig(), []string{fmt.Sprintf("Failed to parse config file %s: %v\n"+
+			"Using default configuration. Check TOML syntax (string values must be quoted).", configPath, err)}
 	}
 
-	return config
+	var warnings []string
+	config.expandPaths()
+	if !cssClassName.MatchString(config.ContentClass) {
+		warnings = append(warnings, fmt.Sprintf("content_class %q is not a valid CSS class name, using %s", config.ContentClass, DefaultContentClass))
+		config.ContentClass = DefaultContentClass
+	}
+	if !validTheme(config.Theme) {
+		warnings = append(warnings, fmt.Sprintf("theme %q is not system, light, or dark, using %s", config.Theme, ThemeSystem))
+		config.Theme = ThemeSystem
+	}
+	return config, warnings
+}
+
+// expandPaths expands ~ and environment variables in the path-valued
+// settings
+func (c *Config) expandPaths() {
+	home, _ := os.UserHomeDir()
+	for _, p := range []*string{&c.ChromeCSS, &c.ServeRoot, &c.HeaderHTML, &c.FooterHTML} {
+		*p = expandPath(*p, home, os.Getenv)
+	}
+}
+
+// expandPath replaces a leading ~ with home and $VAR or ${VAR} with its
+// value from getenv. ~user forms are left alone, as is ~ when home is
+// unknown.
+func expandPath(path, home string, getenv func(string) string) string {
+	if home != "" && (path == "~" || strings.HasPrefix(path, "~/")) {
+		path = home + path[1:]
+	}
+	return os.Expand(path, getenv)
+}
+
+// MinWindowSize returns the enforced minimum window dimensions, using the
+// app defaults for unset or non-positive values
+func (c Config) MinWindowSize() (width, height int) {
+	width, height = MinWindowWidth, MinWindowHeight
+	if c.MinWidth > 0 {
+		width = c.MinWidth
+	}
+	if c.MinHeight > 0 {
+		height = c.MinHeight
+	}
+	return width, height
+}
+
+// parseSize parses a "WxH" size such as "320x200" into positive dimensions
+func parseSize(s string) (width, height int, err error) {
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	if ok {
+		width, err = strconv.Atoi(w)
+		if err == nil {
+			height, err = strconv.Atoi(h)
+		}
+	}
+	if !ok || err != nil || width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid size %q (expected WxH with positive values, e.g. 320x200)", s)
+	}
+	return width, height, nil
+}
+
+// IsExtensionAllowed reports whether a file path may be opened under the
+// allowed_extensions setting. Stdin content (empty path) is always allowed.
+func (c Config) IsExtensionAllowed(path string) bool {
+	if len(c.AllowedExtensions) == 0 || path == "" {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, allowed := range c.AllowedExtensions {
+		allowed = strings.ToLower(allowed)
+		if !strings.HasPrefix(allowed, ".") {
+			allowed = "." + allowed
+		}
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExtensionAllowed returns an error describing a disallowed file path
+func (c Config) checkExtensionAllowed(path string) error {
+	if c.IsExtensionAllowed(path) {
+		return nil
+	}
+	return fmt.Errorf("file type not allowed: %s (allowed_extensions: %s)",
+		filepath.Base(path), strings.Join(c.AllowedExtensions, ", "))
+}
+
+// configOverrides holds the command-line flags that take precedence over
+// config file values
+type configOverrides struct {
+	MinSize      string // --min-size WxH
+	Geometry     string // --geometry WxH[+X+Y]
+	NoLocalFiles bool   // --no-local-files
+}
+
+// applyOverrides returns config with the given flag overrides applied
+func (c Config) applyOverrides(o configOverrides) (Config, error) {
+	if o.MinSize != "" {
+		width, height, err := parseSize(o.MinSize)
+		if err != nil {
+			return c, fmt.Errorf("--min-size: %w", err)
+		}
+		c.MinWidth, c.MinHeight = width, height
+	}
+	if o.Geometry != "" {
+		g, err := ParseGeometry(o.Geometry)
+		if err != nil {
+			return c, fmt.Errorf("--geometry: %w", err)
+		}
+		_, c = ApplyGeometry(nil, c, g, false)
+	}
+	if o.NoLocalFiles {
+		c.DisableLocalFiles = true
+	}
+	return c, nil
+}
+
+// withEffectiveDefaults fills in the values fenestro uses for settings left
+// at 0, so printed config shows what is actually in effect
+func (c Config) withEffectiveDefaults() Config {
+	c.MinWidth, c.MinHeight = c.MinWindowSize()
+	if c.IPCWorkers <= 0 {
+		c.IPCWorkers = DefaultIPCWorkers
+	}
+	if c.IPCLogMaxSize <= 0 {
+		c.IPCLogMaxSize = DefaultIPCLogMaxSize
+	}
+	if c.MaxIPCBytes <= 0 {
+		c.MaxIPCBytes = DefaultMaxIPCBytes
+	}
+	return c
+}
+
+// writeConfig writes config as TOML, or as JSON if asJSON is set. The IPC
+// token is masked so the output is safe to share.
+func writeConfig(w io.Writer, c Config, asJSON bool) error {
+	if c.IPCToken != "" {
+		c.IPCToken = "********"
+	}
+	if !asJSON {
+		return toml.NewEncoder(w).Encode(c)
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
 }
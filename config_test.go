@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -12,6 +13,105 @@ func TestDefaultConfig(t *testing.T) {
 	if config.FontSize != 0 {
 		t.Errorf("Expected default FontSize to be 0, got %d", config.FontSize)
 	}
+	if !config.NormalizeLineEndings {
+		t.Error("Expected NormalizeLineEndings to default to true")
+	}
+	if !config.TrustStdin {
+		t.Error("Expected TrustStdin to default to true")
+	}
+	if config.ContentClass != "fenestro-content" {
+		t.Errorf("Expected default ContentClass fenestro-content, got %q", config.ContentClass)
+	}
+	if !config.RecentsEnabled {
+		t.Error("Expected RecentsEnabled to default to true")
+	}
+}
+
+func TestLoadConfigContentClass(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unset", "", DefaultContentClass},
+		{"custom", `content_class = "doc-body"`, "doc-body"},
+		{"empty", `content_class = ""`, DefaultContentClass},
+		{"invalid", `content_class = "two words"`, DefaultContentClass},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := useTempConfigDir(t)
+			configDir := filepath.Join(tmpDir, "fenestro")
+			if err := os.MkdirAll(configDir, 0755); err != nil {
+				t.Fatalf("Could not create config dir: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Could not write config file: %v", err)
+			}
+
+			app := NewApp(FileEntry{Name: "test", Content: "<p>test</p>"}, "")
+			if got := app.GetConfig().ContentClass; got != tt.want {
+				t.Errorf("GetConfig().ContentClass = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unset", "", ThemeSystem},
+		{"dark", `theme = "dark"`, ThemeDark},
+		{"light", `theme = "light"`, ThemeLight},
+		{"invalid", `theme = "solarized"`, ThemeSystem},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := useTempConfigDir(t)
+			configDir := filepath.Join(tmpDir, "fenestro")
+			if err := os.MkdirAll(configDir, 0755); err != nil {
+				t.Fatalf("Could not create config dir: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Could not write config file: %v", err)
+			}
+
+			if got := LoadConfig().Theme; got != tt.want {
+				t.Errorf("LoadConfig().Theme = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadConfigReturnsWarnings(t *testing.T) {
+	configDir := filepath.Join(useTempConfigDir(t), "fenestro")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Could not create config dir: %v", err)
+	}
+	content := "theme = \"solarized\"\ncontent_class = \"two words\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Could not write config file: %v", err)
+	}
+
+	config, warnings := readConfig()
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "content_class") || !strings.Contains(warnings[1], "solarized") {
+		t.Errorf("readConfig() warnings = %q, want one each for content_class and theme", warnings)
+	}
+	if config.Theme != ThemeSystem {
+		t.Error("an invalid theme should fall back to system")
+	}
+
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte("theme = "), 0644); err != nil {
+		t.Fatalf("Could not write config file: %v", err)
+	}
+	if _, warnings := readConfig(); len(warnings) != 1 || !strings.Contains(warnings[0], "Failed to parse") {
+		t.Errorf("readConfig() warnings = %q, want the parse error", warnings)
+	}
 }
 
 func TestGetConfigDirWithXDGConfigHome(t *testing.T) {
@@ -92,6 +192,55 @@ func TestLoadConfigFromFile(t *testing.T) {
 	}
 }
 
+func TestExpandPath(t *testing.T) {
+	getenv := func(name string) string {
+		return map[string]string{"HOME": "/home/ada", "THEMES": "/opt/themes"}[name]
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"~/themes/chrome.css", "/home/ada/themes/chrome.css"},
+		{"~", "/home/ada"},
+		{"$HOME/themes/chrome.css", "/home/ada/themes/chrome.css"},
+		{"${THEMES}/chrome.css", "/opt/themes/chrome.css"},
+		{"/etc/fenestro/chrome.css", "/etc/fenestro/chrome.css"},
+		{"~other/chrome.css", "~other/chrome.css"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := expandPath(tt.path, "/home/ada", getenv); got != tt.want {
+			t.Errorf("expandPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigExpandsPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, "fenestro")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Could not create config dir: %v", err)
+	}
+	configContent := "chrome_css = \"~/chrome.css\"\nheader_html = \"$FENESTRO_TEST_DIR/header.html\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Could not write config file: %v", err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	t.Setenv("HOME", "/home/ada")
+	t.Setenv("FENESTRO_TEST_DIR", "/srv/snippets")
+
+	config := LoadConfig()
+
+	if config.ChromeCSS != "/home/ada/chrome.css" {
+		t.Errorf("ChromeCSS = %q, want ~ expanded", config.ChromeCSS)
+	}
+	if config.HeaderHTML != "/srv/snippets/header.html" {
+		t.Errorf("HeaderHTML = %q, want $FENESTRO_TEST_DIR expanded", config.HeaderHTML)
+	}
+}
+
 func TestLoadConfigInvalidTOML(t *testing.T) {
 	// Create temp directory
 	tmpDir, err := os.MkdirTemp("", "fenestro-config-test")
@@ -198,6 +347,9 @@ func TestConfigJSONSerialization(t *testing.T) {
 	if !contains(jsonStr, `"chrome_css"`) {
 		t.Errorf("JSON should contain 'chrome_css' field, got: %s", jsonStr)
 	}
+	if !contains(jsonStr, `"content_class"`) {
+		t.Errorf("JSON should contain 'content_class' field, got: %s", jsonStr)
+	}
 
 	// Verify PascalCase field names are NOT present (would break frontend)
 	if contains(jsonStr, `"FontSize"`) {
@@ -234,3 +386,232 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestDefaultConfigBaseHrefMode(t *testing.T) {
+	config := DefaultConfig()
+	if config.BaseHrefMode != BaseHrefAuto {
+		t.Errorf("Expected default BaseHrefMode %q, got %q", BaseHrefAuto, config.BaseHrefMode)
+	}
+}
+
+func TestReloadConfigKeepsOverrides(t *testing.T) {
+	configDir := filepath.Join(useTempConfigDir(t), "fenestro")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Could not create config dir: %v", err)
+	}
+	configPath := filepath.Join(configDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte("min_width = 500\n"), 0644); err != nil {
+		t.Fatalf("Could not write config file: %v", err)
+	}
+
+	overrides := configOverrides{MinSize: "640x480", NoLocalFiles: true}
+	config, err := LoadConfig().applyOverrides(overrides)
+	if err != nil {
+		t.Fatalf("applyOverrides() error = %v", err)
+	}
+	app := newAppWithConfig(FileEntry{Name: "test", Content: "<html></html>"}, "", config)
+	app.overrides = overrides
+
+	if err := os.WriteFile(configPath, []byte("min_width = 700\nfont_size = 20\n"), 0644); err != nil {
+		t.Fatalf("Could not write config file: %v", err)
+	}
+	app.ReloadConfig()
+
+	got := app.GetConfig()
+	if got.FontSize != 20 {
+		t.Errorf("FontSize = %d, want the reloaded 20", got.FontSize)
+	}
+	if got.MinWidth != 640 || got.MinHeight != 480 {
+		t.Errorf("min size = %dx%d, want the --min-size override 640x480", got.MinWidth, got.MinHeight)
+	}
+	if !got.DisableLocalFiles {
+		t.Error("--no-local-files should survive ReloadConfig")
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fenestro-config-test")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	configDir := filepath.Join(tmpDir, "fenestro")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Could not create config dir: %v", err)
+	}
+	configPath := filepath.Join(configDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(`font_size = 14`), 0644); err != nil {
+		t.Fatalf("Could not write config file: %v", err)
+	}
+
+	// Save and restore XDG_CONFIG_HOME
+	original := os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("XDG_CONFIG_HOME", original)
+	os.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	app := NewApp(FileEntry{Name: "test", Content: "<html></html>"}, "")
+	rec := recordEvents(app)
+	if app.GetConfig().FontSize != 14 {
+		t.Fatalf("Expected initial FontSize 14, got %d", app.GetConfig().FontSize)
+	}
+
+	// Edit the config file and reload
+	if err := os.WriteFile(configPath, []byte("font_size = 22\nchrome_css = \"/tmp/chrome.css\""), 0644); err != nil {
+		t.Fatalf("Could not write config file: %v", err)
+	}
+	app.ReloadConfig()
+
+	config := app.GetConfig()
+	if config.FontSize != 22 {
+		t.Errorf("Expected reloaded FontSize 22, got %d", config.FontSize)
+	}
+	if config.ChromeCSS != "/tmp/chrome.css" {
+		t.Errorf("Expected reloaded ChromeCSS, got %q", config.ChromeCSS)
+	}
+
+	events := rec.named("config-changed")
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 config-changed event, got %d", len(events))
+	}
+	emitted, ok := events[0].data[0].(Config)
+	if !ok || emitted.FontSize != 22 {
+		t.Errorf("config-changed event should carry the new config, got %+v", events[0].data)
+	}
+}
+
+func TestIsExtensionAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowed  []string
+		path     string
+		expected bool
+	}{
+		{"empty list allows all", nil, "/tmp/script.sh", true},
+		{"allowed html", []string{".html", ".htm", ".md"}, "/tmp/page.html", true},
+		{"allowed md", []string{".html", ".htm", ".md"}, "/tmp/README.md", true},
+		{"denied extension", []string{".html", ".htm", ".md"}, "/tmp/data.json", false},
+		{"denied no extension", []string{".html"}, "/tmp/Makefile", false},
+		{"case insensitive", []string{".html"}, "/tmp/PAGE.HTML", true},
+		{"entries without leading dot", []string{"html"}, "/tmp/page.html", true},
+		{"stdin always allowed", []string{".html"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{AllowedExtensions: tt.allowed}
+			if got := config.IsExtensionAllowed(tt.path); got != tt.expected {
+				t.Errorf("IsExtensionAllowed(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckExtensionAllowedMessage(t *testing.T) {
+	config := Config{AllowedExtensions: []string{".html", ".md"}}
+
+	if err := config.checkExtensionAllowed("/tmp/page.html"); err != nil {
+		t.Errorf("Expected no error for allowed file, got %v", err)
+	}
+
+	err := config.checkExtensionAllowed("/tmp/secret.txt")
+	if err == nil {
+		t.Fatal("Expected error for disallowed file")
+	}
+	if !contains(err.Error(), "secret.txt") || !contains(err.Error(), ".html, .md") {
+		t.Errorf("Error should name the file and allowed extensions, got %q", err.Error())
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedWidth  int
+		expectedHeight int
+		expectErr      bool
+	}{
+		{"320x200", 320, 200, false},
+		{"320X200", 320, 200, false},
+		{"0x200", 0, 0, true},
+		{"320x-1", 0, 0, true},
+		{"320", 0, 0, true},
+		{"wide x tall", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			width, height, err := parseSize(tt.input)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("parseSize(%q) error = %v, expectErr %v", tt.input, err, tt.expectErr)
+			}
+			if width != tt.expectedWidth || height != tt.expectedHeight {
+				t.Errorf("parseSize(%q) = %dx%d, expected %dx%d", tt.input, width, height, tt.expectedWidth, tt.expectedHeight)
+			}
+		})
+	}
+}
+
+func TestPrintedConfigPrecedence(t *testing.T) {
+	tmpDir := useTempConfigDir(t)
+	configDir := filepath.Join(tmpDir, "fenestro")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Could not create config dir: %v", err)
+	}
+	configContent := "font_size = 18\nmin_width = 500\nmin_height = 350\ndefault_width = 1000\nipc_token = \"secret\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Could not write config file: %v", err)
+	}
+
+	config, err := LoadConfig().applyOverrides(configOverrides{
+		MinSize:      "320x200",
+		Geometry:     "1200x800+10+20",
+		NoLocalFiles: true,
+	})
+	if err != nil {
+		t.Fatalf("applyOverrides() error = %v", err)
+	}
+
+	var out strings.Builder
+	if err := writeConfig(&out, config.withEffectiveDefaults(), false); err != nil {
+		t.Fatalf("writeConfig() error = %v", err)
+	}
+	toml := out.String()
+	for _, line := range []string{
+		"font_size = 18",       // file value, no override
+		"min_width = 320",      // flag beats file
+		"min_height = 200",     // flag beats file
+		"default_width = 1200", // --geometry beats file
+		"default_x = 10",       // --geometry position
+		"disable_local_files = true",
+		"ipc_log_max_size = 1048576", // default filled in
+		"max_ipc_bytes = 67108864",   // default filled in
+	} {
+		if !strings.Contains(toml, line+"\n") {
+			t.Errorf("printed config should contain %q, got:\n%s", line, toml)
+		}
+	}
+	if strings.Contains(toml, "secret") {
+		t.Error("printed config should mask the IPC token")
+	}
+
+	out.Reset()
+	if err := writeConfig(&out, config, true); err != nil {
+		t.Fatalf("writeConfig() JSON error = %v", err)
+	}
+	var decoded Config
+	if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+		t.Fatalf("printed JSON is invalid: %v", err)
+	}
+	if decoded.FontSize != 18 || decoded.MinWidth != 320 || !decoded.DisableLocalFiles {
+		t.Errorf("printed JSON config = %+v", decoded)
+	}
+}
+
+func TestApplyOverridesInvalid(t *testing.T) {
+	if _, err := DefaultConfig().applyOverrides(configOverrides{MinSize: "big"}); err == nil {
+		t.Error("applyOverrides() should reject an invalid --min-size")
+	}
+	if _, err := DefaultConfig().applyOverrides(configOverrides{Geometry: "wide"}); err == nil {
+		t.Error("applyOverrides() should reject an invalid --geometry")
+	}
+}
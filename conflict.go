@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// contentHash returns the hex SHA-256 of content, the form expected_hash
+// takes in a conditional replace
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// conflictError is returned when a conditional replace finds the content
+// has changed since the sender last saw it
+type conflictError struct {
+	currentHash string // "" if the file isn't in the window
+}
+
+func (e *conflictError) Error() string {
+	if e.currentHash == "" {
+		return "conflict: file not found"
+	}
+	return fmt.Sprintf("conflict: content has changed (current hash %s)", e.currentHash)
+}
+
+// checkContentHash returns a *conflictError unless the file a replace of
+// path targets (see entryMatches) has content hashing to expectedHash. Must
+// be called with a.mu held.
+func (a *App) checkContentHash(path, name, expectedHash string, byName bool) error {
+	for i := range a.files {
+		f := &a.files[i]
+		if entryMatches(*f, path, name, byName) {
+			if current := contentHash(f.content()); current != expectedHash {
+				return &conflictError{currentHash: current}
+			}
+			return nil
+		}
+	}
+	return &conflictError{}
+}
+
+// ReplaceFileContentIfMatch is ReplaceFileContent with optimistic
+// concurrency: the content is replaced only if it still hashes to
+// expectedHash, so one writer can't clobber another's update. An empty
+// expectedHash replaces unconditionally.
+func (a *App) ReplaceFileContentIfMatch(path, content, name, expectedHash string) error {
+	return a.replaceEntryIfMatch(FileEntry{Path: path, Content: content, Name: name}, expectedHash, false)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContentHash(t *testing.T) {
+	// SHA-256 of "abc"
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := contentHash("abc"); got != want {
+		t.Errorf("contentHash(%q) = %s, want %s", "abc", got, want)
+	}
+}
+
+func TestReplaceFileContentIfMatchApplies(t *testing.T) {
+	app := &App{files: []FileEntry{{Name: "a.html", Path: "/tmp/a.html", Content: "<p>v1</p>"}}}
+
+	if err := app.ReplaceFileContentIfMatch("/tmp/a.html", "<p>v2</p>", "", contentHash("<p>v1</p>")); err != nil {
+		t.Fatalf("ReplaceFileContentIfMatch() error = %v, want the replace applied", err)
+	}
+	if got := app.GetHTMLContent(); got != "<p>v2</p>" {
+		t.Errorf("content = %q, want %q", got, "<p>v2</p>")
+	}
+}
+
+func TestReplaceFileContentIfMatchRejectsMismatch(t *testing.T) {
+	app := &App{files: []FileEntry{
+		{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"},
+		{Name: "b.html", Path: "/tmp/b.html", Content: "<p>v2</p>"},
+	}}
+	rec := recordEvents(app)
+
+	err := app.ReplaceFileContentIfMatch("/tmp/b.html", "<p>mine</p>", "", contentHash("<p>v1</p>"))
+	var conflict *conflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("ReplaceFileContentIfMatch() error = %v, want a conflict", err)
+	}
+	if conflict.currentHash != contentHash("<p>v2</p>") {
+		t.Errorf("conflict hash = %s, want the current content's hash", conflict.currentHash)
+	}
+	if app.files[1].Content != "<p>v2</p>" || app.GetCurrentIndex() != 0 {
+		t.Error("a rejected replace should leave the content and selection alone")
+	}
+	if len(rec.named("content-replaced")) != 0 {
+		t.Error("a rejected replace should not emit content-replaced")
+	}
+}
+
+func TestReplaceFileContentIfMatchMissingFile(t *testing.T) {
+	app := &App{files: []FileEntry{{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}}}
+
+	err := app.ReplaceFileContentIfMatch("/tmp/new.html", "<p>new</p>", "", contentHash(""))
+	var conflict *conflictError
+	if !errors.As(err, &conflict) || conflict.currentHash != "" {
+		t.Fatalf("error = %v, want a conflict for a file that isn't open", err)
+	}
+	if len(app.files) != 1 {
+		t.Error("a conditional replace should not add a missing file")
+	}
+}
+
+func TestReplaceFileContentIfMatchByName(t *testing.T) {
+	app := &App{files: []FileEntry{{Name: "status", Content: "old"}}}
+
+	if err := app.ReplaceFileContentIfMatch("", "new", "status", contentHash("old")); err != nil {
+		t.Fatalf("ReplaceFileContentIfMatch() error = %v", err)
+	}
+	if app.files[0].Content != "new" {
+		t.Errorf("content = %q, want %q", app.files[0].Content, "new")
+	}
+}
+
+func TestReplaceFileContentIfMatchCompressed(t *testing.T) {
+	app := &App{
+		config: Config{CompressInactive: true},
+		files:  []FileEntry{{Name: "a", Path: "/tmp/a.html", Content: "<p>a</p>"}, {Name: "b", Path: "/tmp/b.html", Content: "<p>b</p>"}},
+	}
+	app.compactFiles()
+
+	if err := app.ReplaceFileContentIfMatch("/tmp/b.html", "<p>b2</p>", "", contentHash("<p>b</p>")); err != nil {
+		t.Errorf("hash of compressed content should match: %v", err)
+	}
+}
+
+func TestIPCServerConditionalReplace(t *testing.T) {
+	app := NewApp(FileEntry{Name: "test.html", Path: "/tmp/test.html", Content: "<p>v1</p>"}, "")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-conditional.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	replace := func(content, expected string) IPCResponse {
+		t.Helper()
+		resp, err := SendCommand(socketPath, IPCCommand{Cmd: "replace", Path: "/tmp/test.html", Content: content, ExpectedHash: expected})
+		if err != nil {
+			t.Fatalf("SendCommand() failed: %v", err)
+		}
+		return resp
+	}
+
+	// The first writer saw v1 and wins
+	resp := replace("<p>writer one</p>", contentHash("<p>v1</p>"))
+	if !resp.OK || resp.Conflict {
+		t.Fatalf("matching replace failed: %+v", resp)
+	}
+	if resp.CurrentHash != contentHash("<p>writer one</p>") {
+		t.Errorf("CurrentHash = %s, want the new content's hash", resp.CurrentHash)
+	}
+
+	// The second writer also saw v1 and is told about the conflict
+	resp = replace("<p>writer two</p>", contentHash("<p>v1</p>"))
+	if resp.OK || !resp.Conflict {
+		t.Fatalf("stale replace = %+v, want a conflict", resp)
+	}
+	if resp.CurrentHash != contentHash("<p>writer one</p>") {
+		t.Errorf("conflict CurrentHash = %s, want the winning content's hash", resp.CurrentHash)
+	}
+	if got := app.GetHTMLContent(); got != "<p>writer one</p>" {
+		t.Errorf("content = %q, want the first writer's update kept", got)
+	}
+
+	// Without expected_hash, replace is unconditional as before
+	resp = replace("<p>forced</p>", "")
+	if !resp.OK || resp.CurrentHash != "" {
+		t.Errorf("unconditional replace = %+v, want a plain OK", resp)
+	}
+}
+
+func TestEnvelopeResponseConflict(t *testing.T) {
+	env := envelopeResponse([]byte("7"), IPCResponse{OK: false, Error: "conflict", Conflict: true, CurrentHash: "abc"})
+	if !env.Conflict || env.Result == nil || env.Result.CurrentHash != "abc" {
+		t.Errorf("envelope = %+v, want the conflict and current hash carried over", env)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// depthEnv counts the fenestro invocations above this process, so a loop
+// that re-runs fenestro (a difftool pointed back at itself, say) stops
+// instead of spawning windows without end
+const depthEnv = "FENESTRO_DEPTH"
+
+// maxSpawnDepth is the deepest nesting at which fenestro still opens windows
+const maxSpawnDepth = 8
+
+// spawnDepth reads the current nesting depth through getenv. A missing or
+// malformed value counts as 0.
+func spawnDepth(getenv func(string) string) int {
+	depth, err := strconv.Atoi(getenv(depthEnv))
+	if err != nil || depth < 0 {
+		return 0
+	}
+	return depth
+}
+
+// childDepth returns the depth a GUI subprocess is started at. Each command
+// line invocation adds one; windows a window opens itself (Duplicate) stay
+// at its depth, since they can't loop back through a command.
+func childDepth(depth int, fromGUI bool) int {
+	if fromGUI {
+		return depth
+	}
+	return depth + 1
+}
+
+// checkSpawnDepth returns an error if a process at depth may not open
+// another window
+func checkSpawnDepth(depth int) error {
+	if depth >= maxSpawnDepth {
+		return fmt.Errorf("fenestro is nested %d deep (%s); refusing to open another window in case it's invoking itself in a loop", depth, depthEnv)
+	}
+	return nil
+}
+
+// childEnv returns environ with depthEnv set to depth, replacing any value
+// already there
+func childEnv(environ []string, depth int) []string {
+	env := make([]string, 0, len(environ)+1)
+	for _, kv := range environ {
+		if !strings.HasPrefix(kv, depthEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	return append(env, depthEnv+"="+strconv.Itoa(depth))
+}
+
+// currentSpawnDepth is spawnDepth for this process's environment
+func currentSpawnDepth() int {
+	return spawnDepth(os.Getenv)
+}
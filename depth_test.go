@@ -0,0 +1,74 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSpawnDepth(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", 0},
+		{"3", 3},
+		{"junk", 0},
+		{"-2", 0},
+	}
+
+	for _, tt := range tests {
+		getenv := func(string) string { return tt.value }
+		if got := spawnDepth(getenv); got != tt.want {
+			t.Errorf("spawnDepth(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestChildDepth(t *testing.T) {
+	if got := childDepth(2, false); got != 3 {
+		t.Errorf("childDepth(2, false) = %d, want 3 for a command line invocation", got)
+	}
+	if got := childDepth(2, true); got != 2 {
+		t.Errorf("childDepth(2, true) = %d, want windows opened by a window to stay at its depth", got)
+	}
+}
+
+func TestCheckSpawnDepth(t *testing.T) {
+	if err := checkSpawnDepth(maxSpawnDepth - 1); err != nil {
+		t.Errorf("checkSpawnDepth(%d) = %v, want nil", maxSpawnDepth-1, err)
+	}
+	err := checkSpawnDepth(maxSpawnDepth)
+	if err == nil || !strings.Contains(err.Error(), depthEnv) {
+		t.Errorf("checkSpawnDepth(%d) = %v, want an error naming %s", maxSpawnDepth, err, depthEnv)
+	}
+}
+
+func TestChildEnvReplacesDepth(t *testing.T) {
+	env := childEnv([]string{"HOME=/home/ada", depthEnv + "=2", "PATH=/bin"}, 3)
+
+	want := []string{"HOME=/home/ada", "PATH=/bin", depthEnv + "=3"}
+	if !slices.Equal(env, want) {
+		t.Errorf("childEnv() = %v, want %v", env, want)
+	}
+}
+
+func TestGUICommandIncrementsDepth(t *testing.T) {
+	t.Setenv(depthEnv, "4")
+
+	cmd := guiCommand("/usr/bin/fenestro", []string{"--internal-gui"}, nil)
+
+	if !slices.Contains(cmd.Env, depthEnv+"=5") {
+		t.Errorf("child env should carry %s=5, got %v", depthEnv, cmd.Env)
+	}
+}
+
+func TestSpawnGUIBackgroundStopsAtMaxDepth(t *testing.T) {
+	useTempSocketDir(t)
+	t.Setenv(depthEnv, "8")
+
+	err := spawnGUIBackground(FileEntry{Name: "a.html", Path: "/tmp/a.html"}, "", false, "", DefaultConfig())
+	if err == nil || !strings.Contains(err.Error(), "nested 8 deep") {
+		t.Errorf("spawnGUIBackground() = %v, want the depth limit error", err)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"html"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ContentTypeDiff is unified diff text, rendered with per-line styling
+const ContentTypeDiff = "text/x-diff"
+
+// diffHunkHeader matches a unified diff hunk header, e.g. "@@ -1,3 +1,4 @@ func"
+var diffHunkHeader = regexp.MustCompile(`^@@ -\d+(,\d+)? \+\d+(,\d+)? @@`)
+
+// diffFileHeaders are the line prefixes that introduce a file in a diff
+var diffFileHeaders = []string{"diff ", "index ", "--- ", "+++ ", "new file mode", "deleted file mode", "similarity index", "rename from", "rename to", "old mode", "new mode", "Binary files"}
+
+// isDiffPath reports whether path has a diff or patch extension
+func isDiffPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".diff", ".patch":
+		return true
+	}
+	return false
+}
+
+// looksLikeDiff reports whether content is unified diff text. HTML documents
+// are never treated as diffs, even if they quote one.
+func looksLikeDiff(content string) bool {
+	if strings.HasPrefix(strings.TrimSpace(content), "<") {
+		return false
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if diffHunkHeader.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffToHTML renders a unified diff with a class per line (diff-file,
+// diff-hunk, diff-add, diff-del, diff-context) so chrome CSS can style it.
+// Content without any hunk falls back to plain preformatted text.
+func diffToHTML(content string) string {
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n"), "\n")
+
+	var out strings.Builder
+	out.WriteString(`<div class="fenestro-diff">`)
+	inHunk := false
+	hunks := 0
+	for _, line := range lines {
+		class := "diff-context"
+		switch {
+		case diffHunkHeader.MatchString(line):
+			class = "diff-hunk"
+			inHunk = true
+			hunks++
+		case inHunk && strings.HasPrefix(line, "+"):
+			class = "diff-add"
+		case inHunk && strings.HasPrefix(line, "-"):
+			class = "diff-del"
+		case isDiffFileHeader(line):
+			class = "diff-file"
+			inHunk = false
+		}
+		out.WriteString(`<div class="diff-line ` + class + `">` + html.EscapeString(line) + "</div>")
+	}
+	out.WriteString("</div>")
+
+	if hunks == 0 {
+		return plainToHTML(content)
+	}
+	return out.String()
+}
+
+// isDiffFileHeader reports whether line starts a file section in a diff
+func isDiffFileHeader(line string) bool {
+	for _, prefix := range diffFileHeaders {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1234567..89abcde 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,4 @@ package main
+ import "fmt"
+-func old() {}
++func new() {}
+ // <unchanged>
+`
+
+func TestLooksLikeDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{"unified diff", sampleDiff, true},
+		{"hunk without file headers", "@@ -3 +3 @@\n-a\n+b\n", true},
+		{"html quoting a diff", "<pre>\n@@ -1,2 +1,2 @@\n</pre>", false},
+		{"plain text", "just some text\n- a list item\n", false},
+		{"malformed hunk header", "@@ one two @@\n-a\n+b\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := looksLikeDiff(tt.content); got != tt.expected {
+				t.Errorf("looksLikeDiff() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDiffToHTMLLineClasses(t *testing.T) {
+	got := diffToHTML(sampleDiff)
+
+	expected := []string{
+		`<div class="diff-line diff-file">diff --git a/main.go b/main.go</div>`,
+		`<div class="diff-line diff-file">index 1234567..89abcde 100644</div>`,
+		`<div class="diff-line diff-file">--- a/main.go</div>`,
+		`<div class="diff-line diff-file">+++ b/main.go</div>`,
+		`<div class="diff-line diff-hunk">@@ -1,4 +1,4 @@ package main</div>`,
+		`<div class="diff-line diff-context"> import &#34;fmt&#34;</div>`,
+		`<div class="diff-line diff-del">-func old() {}</div>`,
+		`<div class="diff-line diff-add">+func new() {}</div>`,
+		`<div class="diff-line diff-context"> // &lt;unchanged&gt;</div>`,
+	}
+	if want := `<div class="fenestro-diff">` + strings.Join(expected, "") + "</div>"; got != want {
+		t.Errorf("diffToHTML() =\n%s\nexpected\n%s", got, want)
+	}
+}
+
+func TestDiffToHTMLMalformedFallsBack(t *testing.T) {
+	content := "--- a/file\n+++ b/file\nno hunks here <b>\n"
+	if got := diffToHTML(content); got != plainToHTML(content) {
+		t.Errorf("diffToHTML() = %q, expected plain <pre> fallback", got)
+	}
+}
+
+func TestApplyContentTypeDetectsDiff(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry FileEntry
+	}{
+		{"piped diff", FileEntry{Content: sampleDiff, ContentType: ContentTypeHTML}},
+		{"patch extension", FileEntry{Path: "/tmp/fix.patch", Content: sampleDiff, ContentType: ContentTypeHTML}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := tt.entry
+			applyContentType(&entry, "", true)
+			if !strings.Contains(entry.Content, `class="fenestro-diff"`) {
+				t.Errorf("Content = %q, expected rendered diff", entry.Content)
+			}
+			if entry.ContentType != ContentTypeHTML {
+				t.Errorf("ContentType = %q, expected %q after transform", entry.ContentType, ContentTypeHTML)
+			}
+		})
+	}
+
+	// HTML content is left alone
+	entry := FileEntry{Path: "/tmp/page.html", Content: "<p>@@ -1 +1 @@</p>", ContentType: ContentTypeHTML}
+	applyContentType(&entry, "", true)
+	if entry.Content != "<p>@@ -1 +1 @@</p>" {
+		t.Errorf("HTML content was transformed: %q", entry.Content)
+	}
+}
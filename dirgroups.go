@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// htmlExtensions lists the file extensions picked up by directory scans
+var htmlExtensions = map[string]bool{
+	".html":  true,
+	".htm":   true,
+	".xhtml": true,
+	".xht":   true,
+}
+
+// dirGroup is one window's worth of files from a --group-by-dir scan
+type dirGroup struct {
+	Name     string   // subdirectory name
+	WindowID string   // stable window ID derived from the subdirectory
+	Files    []string // absolute paths of the subdirectory's HTML files, sorted
+}
+
+// isHTMLFile reports whether path has an HTML-like extension
+func isHTMLFile(path string) bool {
+	return htmlExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// dirWindowID derives a window ID from a subdirectory path, so reopening the
+// same directory targets the same windows
+func dirWindowID(dir string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("fenestro:"+dir)).String()
+}
+
+// groupFilesByDir enumerates the immediate subdirectories of root and returns
+// one group per subdirectory containing HTML files, sorted by name.
+// Subdirectories without HTML files are skipped.
+func groupFilesByDir(root string) ([]dirGroup, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	entries, err := os.ReadDir(absRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var groups []dirGroup
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(absRoot, e.Name())
+		children, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory: %w", err)
+		}
+		var files []string
+		for _, c := range children {
+			if !c.IsDir() && isHTMLFile(c.Name()) {
+				files = append(files, filepath.Join(dir, c.Name()))
+			}
+		}
+		if len(files) == 0 {
+			continue
+		}
+		groups = append(groups, dirGroup{
+			Name:     e.Name(),
+			WindowID: dirWindowID(dir),
+			Files:    files,
+		})
+	}
+	return groups, nil
+}
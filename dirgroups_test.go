@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates the given files (relative paths) under root
+func writeTree(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		path := filepath.Join(root, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Could not create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("<p>"+f+"</p>"), 0644); err != nil {
+			t.Fatalf("Could not write file: %v", err)
+		}
+	}
+}
+
+func TestGroupFilesByDir(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root,
+		"index.html", // top-level files aren't grouped
+		"guide/b.html",
+		"guide/a.htm",
+		"guide/notes.txt",
+		"guide/nested/deep.html", // only immediate children are included
+		"api/ref.xhtml",
+		"images/logo.png",
+	)
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatalf("Could not create directory: %v", err)
+	}
+
+	groups, err := groupFilesByDir(root)
+	if err != nil {
+		t.Fatalf("groupFilesByDir() error = %v", err)
+	}
+
+	expected := map[string][]string{
+		"api":   {filepath.Join(root, "api", "ref.xhtml")},
+		"guide": {filepath.Join(root, "guide", "a.htm"), filepath.Join(root, "guide", "b.html")},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("got %d groups, expected %d: %+v", len(groups), len(expected), groups)
+	}
+	if groups[0].Name != "api" || groups[1].Name != "guide" {
+		t.Errorf("groups not sorted by name: %s, %s", groups[0].Name, groups[1].Name)
+	}
+	for _, g := range groups {
+		want := expected[g.Name]
+		if len(g.Files) != len(want) {
+			t.Errorf("group %s files = %v, expected %v", g.Name, g.Files, want)
+			continue
+		}
+		for i := range want {
+			if g.Files[i] != want[i] {
+				t.Errorf("group %s files = %v, expected %v", g.Name, g.Files, want)
+				break
+			}
+		}
+		if g.WindowID != dirWindowID(filepath.Join(root, g.Name)) {
+			t.Errorf("group %s WindowID = %s, expected one derived from its directory", g.Name, g.WindowID)
+		}
+	}
+}
+
+func TestGroupFilesByDirMissingRoot(t *testing.T) {
+	if _, err := groupFilesByDir(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("groupFilesByDir() should fail for a missing directory")
+	}
+}
+
+func TestDirWindowID(t *testing.T) {
+	a := dirWindowID("/docs/guide")
+	if a != dirWindowID("/docs/guide") {
+		t.Error("dirWindowID() should be stable for the same directory")
+	}
+	if a == dirWindowID("/docs/api") {
+		t.Error("dirWindowID() should differ between directories")
+	}
+}
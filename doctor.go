@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/BurntSushi/toml"
+)
+
+// EnvironmentInfo describes the environment fenestro is running in, for bug
+// reports. Wails v2 doesn't report the webview version; the frontend's
+// navigator.userAgent carries the WebKit version instead.
+type EnvironmentInfo struct {
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	Version    string `json:"version"`
+	GoVersion  string `json:"go_version"`
+	ConfigPath string `json:"config_path"`
+	SocketDir  string `json:"socket_dir"`
+}
+
+// environmentInfo collects the EnvironmentInfo for this process
+func environmentInfo() EnvironmentInfo {
+	return EnvironmentInfo{
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Version:    Version,
+		GoVersion:  runtime.Version(),
+		ConfigPath: getConfigPath(),
+		SocketDir:  getSocketDir(),
+	}
+}
+
+// GetEnvironmentInfo returns OS, architecture, version, and path details for
+// support diagnostics
+func (a *App) GetEnvironmentInfo() EnvironmentInfo {
+	return environmentInfo()
+}
+
+// doctorCheck is the result of one "fenestro doctor" check. err is nil if
+// the check passed; detail describes the outcome either way.
+type doctorCheck struct {
+	name   string
+	detail string
+	err    error
+}
+
+// runDoctorChecks checks the config file, the socket directory, and the
+// sockets in it
+func runDoctorChecks(info EnvironmentInfo) []doctorCheck {
+	return []doctorCheck{
+		checkConfigFile(info.ConfigPath),
+		checkSocketDirWritable(info.SocketDir),
+		checkStaleSockets(info.SocketDir),
+	}
+}
+
+// checkConfigFile reports whether the config file parses. A missing file is
+// fine: the defaults are used.
+func checkConfigFile(path string) doctorCheck {
+	check := doctorCheck{name: "config file"}
+	if path == "" {
+		check.detail = "no config directory, using defaults"
+		return check
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		check.detail = "not found, using defaults"
+		return check
+	}
+	var config Config
+	if _, err := toml.DecodeFile(path, &config); err != nil {
+		check.err = fmt.Errorf("%s doesn't parse: %w", path, err)
+		return check
+	}
+	check.detail = "parses"
+	return check
+}
+
+// checkSocketDirWritable reports whether windows can create their sockets.
+// A directory that doesn't exist yet is created by the first window.
+func checkSocketDirWritable(dir string) doctorCheck {
+	check := doctorCheck{name: "socket directory"}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		check.detail = "not created yet"
+		return check
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		check.err = fmt.Errorf("%s isn't writable: %w", dir, err)
+		return check
+	}
+	f.Close()
+	os.Remove(f.Name())
+	check.detail = "writable"
+	return check
+}
+
+// checkStaleSockets reports sockets left behind by windows that are no longer
+// running, without removing them
+func checkStaleSockets(dir string) doctorCheck {
+	check := doctorCheck{name: "stale sockets"}
+	stale := 0
+	for _, d := range []string{dir, filepath.Join(dir, windowsDir)} {
+		paths, err := staleSockets(d)
+		if err != nil {
+			check.err = err
+			return check
+		}
+		stale += len(paths)
+	}
+	if stale > 0 {
+		check.err = fmt.Errorf("%d stale socket(s); run \"fenestro gc\" to remove them", stale)
+		return check
+	}
+	check.detail = "none"
+	return check
+}
+
+// runDoctor implements "fenestro doctor" (and --doctor). It exits non-zero
+// if any check fails.
+func runDoctor(args []string, stdout, stderr io.Writer) int {
+	fs := newSubcommandFlags("doctor", stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	info := environmentInfo()
+	fmt.Fprintf(stdout, "fenestro %s (%s/%s, %s)\n", info.Version, info.OS, info.Arch, info.GoVersion)
+	fmt.Fprintf(stdout, "Config file: %s\n", info.ConfigPath)
+	fmt.Fprintf(stdout, "Socket dir:  %s\n\n", info.SocketDir)
+
+	code := 0
+	for _, check := range runDoctorChecks(info) {
+		if check.err != nil {
+			fmt.Fprintf(stdout, "FAIL  %s: %v\n", check.name, check.err)
+			code = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "ok    %s: %s\n", check.name, check.detail)
+	}
+	return code
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEnvironmentInfo(t *testing.T) {
+	configDir := useTempConfigDir(t)
+	useTempSocketDir(t)
+
+	info := NewApp(FileEntry{Name: "a.html"}, "").GetEnvironmentInfo()
+	if info.OS != runtime.GOOS || info.Arch != runtime.GOARCH {
+		t.Errorf("OS/Arch = %s/%s, want %s/%s", info.OS, info.Arch, runtime.GOOS, runtime.GOARCH)
+	}
+	if info.Version != Version {
+		t.Errorf("Version = %q, want %q", info.Version, Version)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("GoVersion = %q, want %q", info.GoVersion, runtime.Version())
+	}
+	if want := filepath.Join(configDir, "fenestro", "config.toml"); info.ConfigPath != want {
+		t.Errorf("ConfigPath = %q, want %q", info.ConfigPath, want)
+	}
+	if info.SocketDir != getSocketDir() {
+		t.Errorf("SocketDir = %q, want %q", info.SocketDir, getSocketDir())
+	}
+}
+
+func TestCheckConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	if check := checkConfigFile(filepath.Join(dir, "missing.toml")); check.err != nil {
+		t.Errorf("a missing config file should pass, got %v", check.err)
+	}
+
+	valid := filepath.Join(dir, "valid.toml")
+	os.WriteFile(valid, []byte("font_size = 18\n"), 0644)
+	if check := checkConfigFile(valid); check.err != nil {
+		t.Errorf("a valid config file should pass, got %v", check.err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.toml")
+	os.WriteFile(invalid, []byte("chrome_css = unquoted\n"), 0644)
+	if check := checkConfigFile(invalid); check.err == nil {
+		t.Error("an unparseable config file should fail")
+	}
+}
+
+func TestCheckSocketDirWritable(t *testing.T) {
+	dir := t.TempDir()
+
+	if check := checkSocketDirWritable(filepath.Join(dir, "missing")); check.err != nil {
+		t.Errorf("a socket dir that doesn't exist yet should pass, got %v", check.err)
+	}
+	if check := checkSocketDirWritable(dir); check.err != nil {
+		t.Errorf("a writable socket dir should pass, got %v", check.err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("the writability check should clean up after itself, found %d entries", len(entries))
+	}
+
+	if os.Getuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	readOnly := filepath.Join(dir, "ro")
+	os.Mkdir(readOnly, 0500)
+	if check := checkSocketDirWritable(readOnly); check.err == nil {
+		t.Error("a read-only socket dir should fail")
+	}
+}
+
+func TestCheckStaleSockets(t *testing.T) {
+	useTempSocketDir(t)
+	if err := ensureSocketDir(); err != nil {
+		t.Fatal(err)
+	}
+	startTestServer(t, NewApp(FileEntry{Name: "a.html"}, ""), getSidebarSocketPath())
+
+	if check := checkStaleSockets(getSocketDir()); check.err != nil {
+		t.Errorf("a live socket should not count as stale, got %v", check.err)
+	}
+
+	stale := getWindowSocketPath("0b6b5c1e-7b8f-4d6a-9f3e-2a1c4d5e6f70")
+	os.WriteFile(stale, nil, 0600)
+	check := checkStaleSockets(getSocketDir())
+	if check.err == nil || !strings.Contains(check.err.Error(), "1 stale socket") {
+		t.Errorf("checkStaleSockets() err = %v, want one stale socket reported", check.err)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Error("the doctor check should not remove stale sockets")
+	}
+}
+
+func TestRunDoctor(t *testing.T) {
+	useTempConfigDir(t)
+	useTempSocketDir(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := runDoctor(nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("runDoctor() = %d, want 0 for a clean environment; output:\n%s", code, stdout.String())
+	}
+	for _, want := range []string{"fenestro " + Version, getSocketDir(), "ok    config file", "ok    socket directory", "ok    stale sockets"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output should contain %q, got:\n%s", want, stdout.String())
+		}
+	}
+
+	ensureSocketDir()
+	os.WriteFile(filepath.Join(getSocketDir(), "fenestro.sock"), nil, 0600)
+	stdout.Reset()
+	if code := runDoctor(nil, &stdout, &stderr); code != 1 {
+		t.Errorf("runDoctor() = %d, want 1 with a stale socket", code)
+	}
+	if !strings.Contains(stdout.String(), "FAIL  stale sockets") {
+		t.Errorf("output should report the stale socket, got:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// manifestFilePattern names the temp files that carry a window's file set to
+// the window Duplicate opens
+const manifestFilePattern = "fenestro-session-*.json"
+
+// sessionManifest is a window's file set, in sidebar order, with the
+// selected file
+type sessionManifest struct {
+	Files        []FileEntry `json:"files"`
+	CurrentIndex int         `json:"current_index"`
+}
+
+// writeSessionManifest writes m to a new temp file and returns its path
+func writeSessionManifest(m sessionManifest) (string, error) {
+	f, err := os.CreateTemp("", manifestFilePattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create manifest file: %w", err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(m); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write manifest file: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// readSessionManifest reads and then deletes the manifest at path. An
+// out-of-range selection falls back to the first file.
+func readSessionManifest(path string) (sessionManifest, error) {
+	defer os.Remove(path)
+	var m sessionManifest
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	if len(m.Files) == 0 {
+		return m, fmt.Errorf("manifest %s has no files", path)
+	}
+	if m.CurrentIndex < 0 || m.CurrentIndex >= len(m.Files) {
+		m.CurrentIndex = 0
+	}
+	return m, nil
+}
+
+// sessionProcessArgs builds the arguments for a GUI subprocess seeded from
+// a manifest, with the window ID, instance and rendering flags of opts
+// (see copyOptions)
+func sessionProcessArgs(manifestPath string, opts guiOptions) []string {
+	args := []string{"--internal-gui", "--manifest", manifestPath, "--id", opts.WindowID}
+	if opts.Instance != "" {
+		args = append(args, "--instance", opts.Instance)
+	}
+	if opts.ContentType != "" {
+		args = append(args, "--content-type", opts.ContentType)
+	}
+	if opts.Safe {
+		args = append(args, "--safe")
+	}
+	if opts.PrintCSS {
+		args = append(args, "--print-css")
+	}
+	if opts.NoLocalFiles {
+		args = append(args, "--no-local-files")
+	}
+	if opts.MinSize != "" {
+		args = append(args, "--min-size", opts.MinSize)
+	}
+	return args
+}
+
+// Duplicate opens a new window, under a fresh window ID, holding a copy of
+// every file in this window with the same file selected. The new window
+// deletes the manifest once it has read it.
+func (a *App) Duplicate() error {
+	a.mu.RLock()
+	if len(a.files) == 0 {
+		a.mu.RUnlock()
+		return fmt.Errorf("no files to duplicate")
+	}
+	m := sessionManifest{Files: a.filesWithContent(), CurrentIndex: a.currentIndex}
+	opts := a.copyOptions()
+	a.mu.RUnlock()
+
+	manifestPath, err := writeSessionManifest(m)
+	if err != nil {
+		return err
+	}
+	if err := a.startProcess(sessionProcessArgs(manifestPath, opts)); err != nil {
+		os.Remove(manifestPath)
+		return err
+	}
+	return nil
+}
+
+// seedSession replaces the window's files with a duplicated file set before
+// the window starts
+func (a *App) seedSession(m sessionManifest) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.files = m.Files
+	a.currentIndex = m.CurrentIndex
+	current := a.files[a.currentIndex]
+	a.history = []HistoryEntry{{Name: current.Name, Path: current.Path}}
+	a.compactFiles()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDuplicateWritesManifest(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>", ContentType: ContentTypeHTML}, "")
+	app.AddFile(FileEntry{Name: "stdin", Content: "<p>piped</p>", ContentType: ContentTypeHTML})
+	app.AddFile(FileEntry{Name: "c.html", Path: "/tmp/c.html", Content: "<p>c</p>", ContentType: ContentTypeHTML, BasePath: "/srv/assets"})
+	app.SelectFile(1) // c.html; the sidebar is sorted by name
+	app.instance = "docs"
+	// Inactive files are stored compressed; the manifest must carry their content
+	app.config.CompressInactive = true
+	app.mu.Lock()
+	app.compactFiles()
+	app.mu.Unlock()
+
+	var started []string
+	app.startProcess = func(args []string) error {
+		started = args
+		return nil
+	}
+	if err := app.Duplicate(); err != nil {
+		t.Fatalf("Duplicate() error = %v", err)
+	}
+
+	if !hasArg(started, "--internal-gui") {
+		t.Errorf("args %v should start a GUI subprocess", started)
+	}
+	if _, err := uuid.Parse(argValue(started, "--id")); err != nil {
+		t.Errorf("--id = %q, want a fresh window ID", argValue(started, "--id"))
+	}
+	if got := argValue(started, "--instance"); got != "docs" {
+		t.Errorf("--instance = %q, want docs", got)
+	}
+
+	manifestPath := argValue(started, "--manifest")
+	if matched, _ := filepath.Match(manifestFilePattern, filepath.Base(manifestPath)); !matched {
+		t.Fatalf("manifest %q should match %s so stale ones are swept", manifestPath, manifestFilePattern)
+	}
+	m, err := readSessionManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("readSessionManifest() error = %v", err)
+	}
+	if _, err := os.Stat(manifestPath); !os.IsNotExist(err) {
+		t.Error("reading the manifest should delete it")
+	}
+
+	if m.CurrentIndex != 1 {
+		t.Errorf("CurrentIndex = %d, want 1", m.CurrentIndex)
+	}
+	want := []FileEntry{
+		{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>", ContentType: ContentTypeHTML},
+		{Name: "c.html", Path: "/tmp/c.html", Content: "<p>c</p>", ContentType: ContentTypeHTML, BasePath: "/srv/assets"},
+		{Name: "stdin", Content: "<p>piped</p>", ContentType: ContentTypeHTML},
+	}
+	if len(m.Files) != len(want) {
+		t.Fatalf("manifest has %d files, want %d", len(m.Files), len(want))
+	}
+	for i := range want {
+		got := m.Files[i]
+		if got.Name != want[i].Name || got.Path != want[i].Path || got.Content != want[i].Content ||
+			got.ContentType != want[i].ContentType || got.BasePath != want[i].BasePath {
+			t.Errorf("file %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestDuplicateSpawnErrorRemovesManifest(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "")
+	var manifestPath string
+	app.startProcess = func(args []string) error {
+		manifestPath = argValue(args, "--manifest")
+		return errors.New("spawn failed")
+	}
+
+	if err := app.Duplicate(); err == nil {
+		t.Fatal("Duplicate() should report the spawn error")
+	}
+	if _, err := os.Stat(manifestPath); !os.IsNotExist(err) {
+		t.Error("the manifest should be removed when the window can't start")
+	}
+}
+
+func TestSeedSession(t *testing.T) {
+	manifestPath, err := writeSessionManifest(sessionManifest{
+		Files: []FileEntry{
+			{Name: "b.html", Path: "/tmp/b.html", Content: "<p>b</p>"},
+			{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"},
+		},
+		CurrentIndex: 1,
+	})
+	if err != nil {
+		t.Fatalf("writeSessionManifest() error = %v", err)
+	}
+	m, err := readSessionManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("readSessionManifest() error = %v", err)
+	}
+
+	app := NewApp(m.Files[m.CurrentIndex], "")
+	app.seedSession(m)
+	files := app.GetFiles()
+	if len(files) != 2 || files[0].Name != "b.html" || files[1].Name != "a.html" {
+		t.Errorf("files = %+v, want the manifest's files in their original order", files)
+	}
+	if got := app.GetHTMLContent(); got != "<p>a</p>" {
+		t.Errorf("GetHTMLContent() = %q, want the manifest's selected file", got)
+	}
+}
+
+func TestReadSessionManifestErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"invalid.json": "not json",
+		"empty.json":   `{"files": [], "current_index": 0}`,
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0600)
+		if _, err := readSessionManifest(path); err == nil {
+			t.Errorf("readSessionManifest(%s) should fail", name)
+		}
+	}
+
+	path := filepath.Join(dir, "out-of-range.json")
+	os.WriteFile(path, []byte(`{"files": [{"name": "a.html"}], "current_index": 5}`), 0600)
+	m, err := readSessionManifest(path)
+	if err != nil {
+		t.Fatalf("readSessionManifest() error = %v", err)
+	}
+	if m.CurrentIndex != 0 {
+		t.Errorf("CurrentIndex = %d, want an out-of-range selection reset to 0", m.CurrentIndex)
+	}
+}
+
+func TestDuplicateKeepsRenderFlags(t *testing.T) {
+	app := NewApp(FileEntry{Name: "notes.txt", Path: "/tmp/notes.txt", Content: "<p>notes</p>"}, "")
+	app.safe = true
+	app.forcedContentType = ContentTypeMarkdown
+	app.printMedia = true
+	app.overrides = configOverrides{NoLocalFiles: true, MinSize: "640x480"}
+	var started []string
+	app.startProcess = func(args []string) error {
+		started = args
+		return nil
+	}
+	if err := app.Duplicate(); err != nil {
+		t.Fatalf("Duplicate() error = %v", err)
+	}
+	defer os.Remove(argValue(started, "--manifest"))
+
+	for _, flag := range []string{"--safe", "--no-local-files", "--print-css"} {
+		if !hasArg(started, flag) {
+			t.Errorf("args %v should include %s so the copy loads content the same way", started, flag)
+		}
+	}
+	if got := argValue(started, "--content-type"); got != ContentTypeMarkdown {
+		t.Errorf("--content-type = %q, want %s", got, ContentTypeMarkdown)
+	}
+	if got := argValue(started, "--min-size"); got != "640x480" {
+		t.Errorf("--min-size = %q, want 640x480", got)
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// scriptElement matches a <script> element with its body; an unclosed
+	// opening tag is matched on its own so it can't swallow the document
+	scriptElement = regexp.MustCompile(`(?is)<script\b[^>]*>.*?</script\s*>|<script\b[^>]*>`)
+	// openingTag matches an element's opening tag, where attributes live
+	openingTag = regexp.MustCompile(`<[A-Za-z][^>]*>`)
+	// tagAttr matches an attribute in an opening tag, capturing the space
+	// before it, its name, the = with its spacing, and its value
+	tagAttr = regexp.MustCompile(`(?i)(\s)([a-z][a-z0-9:._-]*)(\s*=\s*)("[^"]*"|'[^']*'|[^\s"'>]+)`)
+	// tagName matches the element name at the start of an opening tag
+	tagName = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9-]*)`)
+	// scriptOpenTag matches the opening tag of a <script> element
+	scriptOpenTag = regexp.MustCompile(`(?i)^<script\b[^>]*>`)
+	// cssURL matches a url(...) reference in CSS, capturing what's inside
+	cssURL = regexp.MustCompile(`(?i)url\(\s*("[^"]*"|'[^']*'|[^)"'\s]*)\s*\)`)
+	// cssImport matches an @import rule, capturing the stylesheet reference
+	// (quoted or in url()) and any media list
+	cssImport = regexp.MustCompile(`(?i)@import\s+(?:url\(\s*)?("[^"]*"|'[^']*'|[^)"'\s;]+)\s*\)?\s*([^;]*);`)
+	// urlScheme matches the scheme of an absolute URL such as https: or data:
+	urlScheme = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// maxImportDepth bounds how deeply @import rules are followed, so a cycle of
+// stylesheets importing each other ends
+const maxImportDepth = 8
+
+// htmlInliner replaces the local relative references in a document with the
+// files they point to, for --export-html
+type htmlInliner struct {
+	root     string   // directory inlined files must stay within ("" for piped content)
+	maxBytes int64    // largest file embedded as a data: URI (0 = no limit)
+	warnings []string // references left as they were, and why
+}
+
+// exportHTML returns content with its local assets inlined: stylesheets as
+// <style>, scripts as inline <script>, and images, fonts and other files
+// as data: URIs. Relative references resolve against base and must stay
+// within its serving root (see servingRoot). References that can't be
+// inlined are left as they were and described in the returned warnings.
+// Links to other pages and frames aren't assets and are left alone.
+func exportHTML(content, base string, config Config) (string, []string) {
+	in := &htmlInliner{maxBytes: config.ExportMaxInlineBytes}
+	if base != "" {
+		in.root = servingRootFor(base, config)
+	}
+
+	content = openingTag.ReplaceAllStringFunc(content, func(tag string) string {
+		return in.tag(tag, base)
+	})
+	content = styleElement.ReplaceAllStringFunc(content, func(element string) string {
+		parts := styleElement.FindStringSubmatch(element)
+		return parts[1] + styleText(in.css(parts[2], base, 0)) + parts[3]
+	})
+	content = linkTag.ReplaceAllStringFunc(content, func(tag string) string {
+		return in.stylesheet(tag, base)
+	})
+	content = scriptElement.ReplaceAllStringFunc(content, func(element string) string {
+		return in.script(element, base)
+	})
+	return content, in.warnings
+}
+
+// tag inlines the src, srcset, poster and style attributes of an opening
+// tag, and the href of icon links and SVG images. Scripts and stylesheets
+// are left to the other passes, and frames and links alone.
+func (in *htmlInliner) tag(tag, dir string) string {
+	m := tagName.FindStringSubmatch(tag)
+	if m == nil {
+		return tag
+	}
+	element := strings.ToLower(m[1])
+	switch element {
+	case "script", "style", "iframe", "frame", "a", "base", "form":
+		return tag
+	}
+	icon := element == "link" && hasToken(attrValue(tag, "rel"), "icon")
+	return tagAttr.ReplaceAllStringFunc(tag, func(attr string) string {
+		parts := tagAttr.FindStringSubmatch(attr)
+		name := strings.ToLower(parts[2])
+		value := html.UnescapeString(unquote(parts[4]))
+		var inlined string
+		switch {
+		case name == "src" || name == "poster" || (name == "data" && element == "object") ||
+			(name == "href" && icon) || ((name == "href" || name == "xlink:href") && element == "image"):
+			uri, ok := in.dataURI(value, dir)
+			if !ok {
+				return attr
+			}
+			inlined = uri
+		case name == "srcset" && !strings.Contains(value, "data:"):
+			inlined = in.srcset(value, dir)
+		case name == "style":
+			inlined = html.EscapeString(in.css(value, dir, 0))
+		default:
+			return attr
+		}
+		return parts[1] + parts[2] + parts[3] + `"` + inlined + `"`
+	})
+}
+
+// srcset inlines each image candidate of a srcset attribute
+func (in *htmlInliner) srcset(value, dir string) string {
+	candidates := strings.Split(value, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		if uri, ok := in.dataURI(fields[0], dir); ok {
+			fields[0] = uri
+		}
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// stylesheet replaces a <link rel="stylesheet"> to a local file with a
+// <style> element holding the file's CSS, keeping its media attribute
+func (in *htmlInliner) stylesheet(tag, dir string) string {
+	if !hasToken(attrValue(tag, "rel"), "stylesheet") {
+		return tag
+	}
+	path, ok := in.resolve(attrValue(tag, "href"), dir)
+	if !ok {
+		return tag
+	}
+	css, ok := in.read(path)
+	if !ok {
+		return tag
+	}
+	open := "<style>"
+	if media := attrValue(tag, "media"); media != "" {
+		open = `<style media="` + html.EscapeString(media) + `">`
+	}
+	return open + styleText(in.css(string(css), filepath.Dir(path), 0)) + "</style>"
+}
+
+// styleText escapes inlined CSS for a <style> element, where a literal
+// </style would end the element early. <\/ is the same text to CSS.
+func styleText(css string) string {
+	return strings.ReplaceAll(css, "</", `<\/`)
+}
+
+// script replaces the src of a <script> element that loads a local file
+// with the file's code
+func (in *htmlInliner) script(element, dir string) string {
+	open := scriptOpenTag.FindString(element)
+	if open == "" || len(open) == len(element) {
+		// An unclosed tag, left for the browser to make sense of
+		return element
+	}
+	path, ok := in.resolve(attrValue(open, "src"), dir)
+	if !ok {
+		return element
+	}
+	code, ok := in.read(path)
+	if !ok {
+		return element
+	}
+	open = tagAttr.ReplaceAllStringFunc(open, func(attr string) string {
+		if strings.EqualFold(tagAttr.FindStringSubmatch(attr)[2], "src") {
+			return ""
+		}
+		return attr
+	})
+	// A literal </script in the code would end the element early
+	return open + strings.ReplaceAll(string(code), "</script", `<\/script`) + "</script>"
+}
+
+// css inlines the @import rules and url() references of a stylesheet whose
+// relative URLs resolve against dir. depth counts the imports followed to
+// get here.
+func (in *htmlInliner) css(css, dir string, depth int) string {
+	css = cssImport.ReplaceAllStringFunc(css, func(rule string) string {
+		parts := cssImport.FindStringSubmatch(rule)
+		if depth >= maxImportDepth {
+			return rule
+		}
+		path, ok := in.resolve(unquote(parts[1]), dir)
+		if !ok {
+			return rule
+		}
+		imported, ok := in.read(path)
+		if !ok {
+			return rule
+		}
+		inlined := in.css(string(imported), filepath.Dir(path), depth+1)
+		if media := strings.TrimSpace(parts[2]); media != "" {
+			return "@media " + media + " {\n" + inlined + "\n}"
+		}
+		return inlined
+	})
+	return cssURL.ReplaceAllStringFunc(css, func(ref string) string {
+		uri, ok := in.dataURI(unquote(cssURL.FindStringSubmatch(ref)[1]), dir)
+		if !ok {
+			return ref
+		}
+		return `url("` + uri + `")`
+	})
+}
+
+// dataURI returns the local file ref points to as a data: URI, or false if
+// ref isn't local or the file can't be inlined
+func (in *htmlInliner) dataURI(ref, dir string) (string, bool) {
+	path, ok := in.resolve(ref, dir)
+	if !ok {
+		return "", false
+	}
+	if info, err := os.Stat(path); err == nil && in.maxBytes > 0 && info.Size() > in.maxBytes {
+		in.warn(path, fmt.Sprintf("larger than export_max_inline_bytes (%d bytes)", in.maxBytes))
+		return "", false
+	}
+	data, ok := in.read(path)
+	if !ok {
+		return "", false
+	}
+	mediaType := strings.ReplaceAll(mimeTypeForExt(filepath.Ext(path)), " ", "")
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), true
+}
+
+// resolve returns the file a local relative reference points to. Absolute
+// and root-relative URLs, data: URIs and fragments aren't local; a
+// reference outside the serving root is reported and refused.
+func (in *htmlInliner) resolve(ref, dir string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "/") || urlScheme.MatchString(ref) {
+		return "", false
+	}
+	if in.root == "" {
+		in.warn(ref, "piped content has no directory to resolve it against (use --base-path)")
+		return "", false
+	}
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+	if unescaped, err := url.PathUnescape(ref); err == nil {
+		ref = unescaped
+	}
+	path, err := filepath.Abs(filepath.Join(dir, filepath.FromSlash(ref)))
+	if err != nil {
+		return "", false
+	}
+	if !isWithinDir(path, in.root) {
+		in.warn(path, "outside the serving root "+in.root)
+		return "", false
+	}
+	return path, true
+}
+
+// read returns the content of a file being inlined, reporting any that
+// can't be read
+func (in *htmlInliner) read(path string) ([]byte, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		in.warn(path, err.Error())
+		return nil, false
+	}
+	return data, true
+}
+
+// warn records a reference that was left as it was
+func (in *htmlInliner) warn(path, reason string) {
+	in.warnings = append(in.warnings, fmt.Sprintf("%s: %s", path, reason))
+}
+
+// attrValue returns the unescaped value of the named attribute of an opening
+// tag, or "" if it has none
+func attrValue(tag, name string) string {
+	for _, parts := range tagAttr.FindAllStringSubmatch(tag, -1) {
+		if strings.EqualFold(parts[2], name) {
+			return html.UnescapeString(unquote(parts[4]))
+		}
+	}
+	return ""
+}
+
+// hasToken reports whether a space-separated attribute value such as rel
+// contains token
+func hasToken(value, token string) bool {
+	for _, field := range strings.Fields(value) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// unquote strips matching single or double quotes from an attribute or CSS
+// value
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
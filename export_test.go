@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// exportSite writes a small multi-file site and returns its directory
+func exportSite(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"css/site.css":      `@import "base.css" print; body { background: url(../img/bg.png); }`,
+		"css/base.css":      `h1 { font-family: Brand; } @font-face { src: url('../fonts/brand.woff2'); }`,
+		"js/app.js":         `document.title = "</script>";`,
+		"img/logo.png":      "\x89PNG logo",
+		"img/logo@2x.png":   "\x89PNG logo 2x",
+		"img/bg.png":        "\x89PNG bg",
+		"img/icon.svg":      `<svg xmlns="http://www.w3.org/2000/svg"></svg>`,
+		"fonts/brand.woff2": "wOF2",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	return dir
+}
+
+const exportPage = `<!DOCTYPE html>
+<html><head>
+<link rel="icon" href="img/icon.svg">
+<link rel="stylesheet" href="css/site.css" media="screen">
+<style>.hero { background-image: url("img/bg.png"); }</style>
+<script src="js/app.js" defer></script>
+</head><body>
+<img src="img/logo.png" srcset="img/logo.png 1x, img/logo@2x.png 2x" alt="logo">
+<div style="background: url(img/bg.png)"></div>
+<img src="https://example.com/remote.png">
+<a href="other.html">next</a>
+</body></html>`
+
+// localRef matches an asset attribute or CSS url() that still points at a
+// relative path
+var localRef = regexp.MustCompile(`(?i)(?:\s(?:src|srcset|poster)\s*=\s*["']?|url\(\s*["']?|@import\s+["'])(?:[a-z0-9_.-]+/|\.\./)*[a-z0-9_@.-]+\.(?:png|svg|css|js|woff2)`)
+
+func TestExportHTMLInlinesLocalAssets(t *testing.T) {
+	dir := exportSite(t)
+	out, warnings := exportHTML(exportPage, dir, DefaultConfig())
+
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if m := localRef.FindString(out); m != "" {
+		t.Errorf("export still references a local file (%q):\n%s", m, out)
+	}
+	for _, name := range []string{`href="img/icon.svg"`, `href="css/site.css"`, `src="js/app.js"`} {
+		if strings.Contains(out, name) {
+			t.Errorf("export still contains %s", name)
+		}
+	}
+
+	logo := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("\x89PNG logo"))
+	logo2x := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("\x89PNG logo 2x"))
+	for _, want := range []string{
+		`<img src="` + logo + `"`,
+		`<style media="screen">`,
+		`h1 { font-family: Brand; }`, // imported stylesheet
+		`@media print {`,             // with its media list kept
+		`;base64,d09GMg==")`,         // font resolved against css/
+		`<script defer>document.title = "<\/script>";</script>`,
+		`href="data:image/svg+xml;base64,`, // icon
+		logo2x + ` 2x"`,                    // srcset keeps its descriptors
+		`<img src="https://example.com/remote.png">`,
+		`<a href="other.html">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in export:\n%s", want, out)
+		}
+	}
+}
+
+func TestExportHTMLEscapesStyleEnd(t *testing.T) {
+	dir := t.TempDir()
+	css := `p::after { content: "</style><script>alert(1)</script>"; }`
+	if err := os.WriteFile(filepath.Join(dir, "end.css"), []byte(css), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	for _, page := range []string{
+		`<link rel="stylesheet" href="end.css">`,
+		`<style>@import "end.css";</style>`,
+	} {
+		out, warnings := exportHTML(page, dir, DefaultConfig())
+		if len(warnings) != 0 {
+			t.Errorf("unexpected warnings for %s: %v", page, warnings)
+		}
+		want := `<style>p::after { content: "<\/style><script>alert(1)<\/script>"; }</style>`
+		if out != want {
+			t.Errorf("exportHTML(%s) = %q, want %q", page, out, want)
+		}
+	}
+}
+
+func TestExportHTMLSizeLimit(t *testing.T) {
+	dir := exportSite(t)
+	config := DefaultConfig()
+	config.ExportMaxInlineBytes = 4
+
+	out, warnings := exportHTML(`<img src="img/logo.png">`, dir, config)
+
+	if out != `<img src="img/logo.png">` {
+		t.Errorf("a file over the limit should be left as a reference, got %q", out)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "export_max_inline_bytes") {
+		t.Errorf("warnings = %v, want one about the size limit", warnings)
+	}
+}
+
+func TestExportHTMLStaysWithinServingRoot(t *testing.T) {
+	site := exportSite(t)
+	dir := filepath.Join(site, "css")
+
+	out, warnings := exportHTML(`<img src="../img/logo.png"><img src="missing.png">`, dir, DefaultConfig())
+
+	if out != `<img src="../img/logo.png"><img src="missing.png">` {
+		t.Errorf("references outside the root or to missing files should be kept, got %q", out)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "outside the serving root") {
+		t.Errorf("warnings = %v, want the outside and missing references", warnings)
+	}
+
+	config := DefaultConfig()
+	config.ServeRoot = site
+	if out, _ := exportHTML(`<img src="../img/logo.png">`, dir, config); strings.Contains(out, "../img") {
+		t.Errorf("serve_root should allow the parent directory, got %q", out)
+	}
+}
+
+func TestExportHTMLPipedContent(t *testing.T) {
+	out, warnings := exportHTML(`<img src="logo.png"><img src="data:image/png;base64,AA==">`, "", DefaultConfig())
+
+	if !strings.Contains(out, `src="logo.png"`) {
+		t.Errorf("piped content has nothing to resolve against, got %q", out)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "--base-path") {
+		t.Errorf("warnings = %v, want one suggesting --base-path", warnings)
+	}
+}
+
+func TestRunExportHTML(t *testing.T) {
+	dir := exportSite(t)
+	page := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(page, []byte(exportPage), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	outPath := filepath.Join(t.TempDir(), "out.html")
+
+	if err := runExportHTML(FileEntry{Name: "index.html", Path: page, Content: exportPage, ContentType: ContentTypeHTML}, outPath, DefaultConfig()); err != nil {
+		t.Fatalf("runExportHTML() error = %v", err)
+	}
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("export wasn't written: %v", err)
+	}
+	if m := localRef.FindString(string(data)); m != "" {
+		t.Errorf("exported file still references a local file (%q)", m)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// fetchTimeout bounds how long --url waits for the server
+const fetchTimeout = 30 * time.Second
+
+// defaultUserAgent is sent with --url requests unless --user-agent is given
+var defaultUserAgent = "fenestro/" + Version
+
+// parseHeader splits a --header value of the form "Name: Value". The value
+// is left out of the error so tokens aren't echoed to the terminal.
+func parseHeader(h string) (string, string, error) {
+	name, value, ok := strings.Cut(h, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid header (expected \"Name: Value\")")
+	}
+	return name, strings.TrimSpace(value), nil
+}
+
+// newFetchRequest builds the GET request for url with the given --header
+// values and user agent applied. An empty userAgent uses the default.
+func newFetchRequest(url string, headers []string, userAgent string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range headers {
+		name, value, err := parseHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add(name, value)
+	}
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	return req, nil
+}
+
+// fetchURL downloads url and returns its body as a FileEntry. The entry has
+// no path, so it's handled like stdin content. Errors never include the
+// request headers, which may carry credentials.
+func fetchURL(url string, headers []string, userAgent string) (FileEntry, error) {
+	req, err := newFetchRequest(url, headers, userAgent)
+	if err != nil {
+		return FileEntry{}, err
+	}
+
+	client := &http.Client{Timeout: fetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return FileEntry{}, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return FileEntry{}, fmt.Errorf("reading %s: %w", url, err)
+	}
+
+	return FileEntry{
+		Name:        url,
+		Content:     string(body),
+		ContentType: contentTypeForResponse(resp),
+	}, nil
+}
+
+// contentTypeForResponse maps the response's Content-Type to one fenestro can
+// render, falling back to HTML
+func contentTypeForResponse(resp *http.Response) string {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !validContentType(mediaType) {
+		return ContentTypeHTML
+	}
+	return mediaType
+}
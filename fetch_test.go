@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantName  string
+		wantValue string
+		wantErr   bool
+	}{
+		{"Authorization: Bearer abc123", "Authorization", "Bearer abc123", false},
+		{"X-Custom:value", "X-Custom", "value", false},
+		{"  Accept :  text/html  ", "Accept", "text/html", false},
+		{"X-Time: 12:30", "X-Time", "12:30", false},
+		{"X-Empty:", "X-Empty", "", false},
+		{"no-colon", "", "", true},
+		{": value", "", "", true},
+		{"Bad Name: value", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			name, value, err := parseHeader(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHeader(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if name != tt.wantName || value != tt.wantValue {
+				t.Errorf("parseHeader(%q) = (%q, %q), want (%q, %q)", tt.input, name, value, tt.wantName, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestParseHeaderErrorOmitsValue(t *testing.T) {
+	_, _, err := parseHeader("Bearer secret-token")
+	if err == nil {
+		t.Fatal("expected an error for a header without a colon")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("error should not echo the header: %v", err)
+	}
+}
+
+// echoHeadersServer responds with the request's User-Agent and X-Test and
+// Authorization headers, one per line
+func echoHeadersServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, name := range []string{"User-Agent", "X-Test", "Authorization"} {
+			w.Write([]byte(name + "=" + strings.Join(r.Header.Values(name), ",") + "\n"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchURLAppliesHeaders(t *testing.T) {
+	server := echoHeadersServer(t)
+
+	entry, err := fetchURL(server.URL, []string{"X-Test: one", "X-Test: two", "Authorization: Bearer abc"}, "custom-agent/1.0")
+	if err != nil {
+		t.Fatalf("fetchURL() error = %v", err)
+	}
+
+	for _, want := range []string{"User-Agent=custom-agent/1.0", "X-Test=one,two", "Authorization=Bearer abc"} {
+		if !strings.Contains(entry.Content, want) {
+			t.Errorf("response %q should contain %q", entry.Content, want)
+		}
+	}
+	if entry.ContentType != ContentTypePlain {
+		t.Errorf("ContentType = %q, want %q from the response header", entry.ContentType, ContentTypePlain)
+	}
+	if entry.Name != server.URL || entry.Path != "" {
+		t.Errorf("entry name/path = %q/%q, want the URL and no path", entry.Name, entry.Path)
+	}
+}
+
+func TestFetchURLDefaultUserAgent(t *testing.T) {
+	server := echoHeadersServer(t)
+
+	entry, err := fetchURL(server.URL, nil, "")
+	if err != nil {
+		t.Fatalf("fetchURL() error = %v", err)
+	}
+	if !strings.Contains(entry.Content, "User-Agent="+defaultUserAgent+"\n") {
+		t.Errorf("response %q should report the default user agent", entry.Content)
+	}
+}
+
+func TestFetchURLInvalidHeader(t *testing.T) {
+	server := echoHeadersServer(t)
+
+	if _, err := fetchURL(server.URL, []string{"not a header"}, ""); err == nil {
+		t.Error("fetchURL() should reject a malformed header")
+	}
+}
+
+func TestFetchURLErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := fetchURL(server.URL, []string{"Authorization: Bearer secret-token"}, "")
+	if err == nil {
+		t.Fatal("fetchURL() should fail on a non-200 response")
+	}
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("error should not include request headers: %v", err)
+	}
+}
+
+func TestContentTypeForResponse(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"text/html; charset=utf-8", ContentTypeHTML},
+		{"application/json", ContentTypeJSON},
+		{"text/markdown", ContentTypeMarkdown},
+		{"image/png", ContentTypeHTML},
+		{"", ContentTypeHTML},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{"Content-Type": []string{tt.header}}}
+		if got := contentTypeForResponse(resp); got != tt.want {
+			t.Errorf("contentTypeForResponse(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
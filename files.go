@@ -1,8 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 // FileEntry represents a file in the sidebar
@@ -10,6 +14,43 @@ type FileEntry struct {
 	Name    string `json:"name"`
 	Path    string `json:"path"` // empty for stdin
 	Content string `json:"content"`
+	// Updated is true when the file arrived or changed since it was last viewed
+	Updated bool `json:"updated"`
+	// ContentType tells the frontend which parser to use (e.g. application/xhtml+xml)
+	ContentType string `json:"content_type"`
+	// SourceType is the type the content was loaded as when it has been
+	// rendered to HTML for display (e.g. text/markdown), and empty when
+	// ContentType is the content's own type
+	SourceType string `json:"source_type,omitempty"`
+	// BasePath overrides the directory used to resolve relative assets
+	// (empty = directory containing Path)
+	BasePath string `json:"base_path,omitempty"`
+	// compressed holds the gzipped content of an inactive file when
+	// compress_inactive is set, in which case Content is empty
+	compressed []byte
+	// viewSource shows the content's source instead of rendering it
+	// (see ToggleViewSource)
+	viewSource bool
+}
+
+// Content types for FileEntry.ContentType
+const (
+	ContentTypeHTML  = "text/html"
+	ContentTypeXHTML = "application/xhtml+xml"
+	ContentTypeXML   = "application/xml"
+)
+
+// contentTypeForPath derives a FileEntry content type from the file extension.
+// Stdin content (empty path) and unknown extensions are treated as HTML.
+func contentTypeForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xhtml", ".xht":
+		return ContentTypeXHTML
+	case ".xml":
+		return ContentTypeXML
+	default:
+		return ContentTypeHTML
+	}
 }
 
 // sortFilesByName sorts files alphabetically by name
@@ -19,6 +60,60 @@ func sortFilesByName(files []FileEntry) {
 	})
 }
 
+// resolveSelection returns the index of the --select file in files, which
+// are in sidebar order (sorted by name). sel is a file name or a 0-based
+// index; an unknown name or out-of-range index falls back to 0 with a
+// warning.
+func resolveSelection(files []FileEntry, sel string) (int, string) {
+	if sel == "" {
+		return 0, ""
+	}
+	for i, f := range files {
+		if f.Name == sel {
+			return i, ""
+		}
+	}
+	if i, err := strconv.Atoi(sel); err == nil {
+		if i >= 0 && i < len(files) {
+			return i, ""
+		}
+		return 0, fmt.Sprintf("--select %d is out of range (%d files), showing the first file", i, len(files))
+	}
+	return 0, fmt.Sprintf("--select %q matches no file, showing the first file", sel)
+}
+
+// selectedEntry returns the file --select picks from files, printing the
+// warning from resolveSelection if there is one
+func selectedEntry(files []FileEntry, sel string) FileEntry {
+	sorted := make([]FileEntry, len(files))
+	copy(sorted, files)
+	sortFilesByName(sorted)
+	index, warning := resolveSelection(sorted, sel)
+	if warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	return sorted[index]
+}
+
+// dedupeName returns name, or name with the lowest free " (N)" suffix
+// (starting at 2) if it's already in existing. A slot freed by a removed
+// entry is reused, so the result only depends on the names present.
+func dedupeName(name string, existing []string) string {
+	taken := make(map[string]bool, len(existing))
+	for _, n := range existing {
+		taken[n] = true
+	}
+	if !taken[name] {
+		return name
+	}
+	for n := 2; ; n++ {
+		candidate := name + " (" + strconv.Itoa(n) + ")"
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
 // isTerminal returns true if the given file is a terminal (not a pipe/redirect).
 func isTerminal(f *os.File) bool {
 	fi, err := f.Stat()
@@ -27,3 +122,38 @@ func isTerminal(f *os.File) bool {
 	}
 	return (fi.Mode() & os.ModeCharDevice) != 0
 }
+
+// splitPathLine splits an optional :line suffix from a file path (e.g.
+// "main.go:42" -> "main.go", 42), as printed by compilers. Paths without a
+// line are returned unchanged with line 0. A lone drive letter is never taken
+// as the path, so "C:12" stays a Windows drive-relative path.
+func splitPathLine(path string) (string, int) {
+	i := strings.LastIndex(path, ":")
+	if i <= 0 {
+		return path, 0
+	}
+	digits := path[i+1:]
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return path, 0
+	}
+	line, err := strconv.Atoi(digits)
+	if err != nil || line <= 0 {
+		return path, 0
+	}
+	prefix := path[:i]
+	if len(prefix) == 1 && (prefix[0]|0x20 >= 'a' && prefix[0]|0x20 <= 'z') {
+		return path, 0
+	}
+	return prefix, line
+}
+
+// splitPathAnchor splits an optional #anchor suffix from a file path
+// (e.g. "file.html#section2" -> "file.html", "section2"). Paths without an
+// anchor are returned unchanged with an empty anchor.
+func splitPathAnchor(path string) (string, string) {
+	i := strings.LastIndex(path, "#")
+	if i < 0 || i == len(path)-1 || strings.ContainsAny(path[i+1:], `/\`) {
+		return path, ""
+	}
+	return path[:i], path[i+1:]
+}
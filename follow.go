@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileFollower re-reads a file on a fixed interval and reports content
+// changes. This is a polling alternative to filesystem notifications for
+// setups where they don't work (e.g. network mounts).
+type FileFollower struct {
+	path     string
+	interval time.Duration
+	onChange func(content string)
+	readFile func(path string) ([]byte, error) // os.ReadFile, replaced in tests
+	lastHash [sha256.Size]byte
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewFileFollower creates a follower for path. initialContent is the content
+// already displayed, so the first poll only reports a change if the file differs.
+func NewFileFollower(path string, interval time.Duration, initialContent string, onChange func(content string)) *FileFollower {
+	return &FileFollower{
+		path:     path,
+		interval: interval,
+		onChange: onChange,
+		readFile: os.ReadFile,
+		lastHash: sha256.Sum256([]byte(initialContent)),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start begins polling in the background until Stop is called
+func (f *FileFollower) Start() {
+	go func() {
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-f.stop:
+				return
+			case <-ticker.C:
+				f.check()
+			}
+		}
+	}()
+}
+
+// Stop ends polling. Safe to call more than once.
+func (f *FileFollower) Stop() {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+	})
+}
+
+// check re-reads the file and calls onChange if its content hash differs from
+// the last seen content. Returns true if a change was reported. Read errors
+// (e.g. the file is briefly missing while being rewritten) are ignored.
+func (f *FileFollower) check() bool {
+	data, err := f.readFile(f.path)
+	if err != nil {
+		return false
+	}
+	hash := sha256.Sum256(data)
+	if hash == f.lastHash {
+		return false
+	}
+	f.lastHash = hash
+	f.onChange(string(data))
+	return true
+}
+
+// followFile re-reads path on interval and updates its entry when the file
+// changes (--follow), reapplying --content-type and --safe as Reload does.
+// Changes are reported against the file as it is now rather than the loaded
+// entry, whose content may have been converted to HTML. A path that's
+// already followed keeps its follower.
+func (a *App) followFile(path string, interval time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.followers[path]; ok {
+		return
+	}
+	initial, _ := os.ReadFile(path)
+	follower := NewFileFollower(path, interval, string(initial), func(content string) {
+		a.mu.RLock()
+		forced, safe, config := a.forcedContentType, a.safe, a.config
+		a.mu.RUnlock()
+		updated := FileEntry{Path: path, Content: content, ContentType: loadContentType(path)}
+		applyContentType(&updated, forced, config.NormalizeLineEndings)
+		sanitizeEntry(&updated, false, safe, config)
+		a.updateFileContent(path, updated.Content)
+	})
+	if a.followers == nil {
+		a.followers = make(map[string]*FileFollower)
+	}
+	a.followers[path] = follower
+	follower.Start()
+}
+
+// stopFollowing stops the followers started by followFile
+func (a *App) stopFollowing() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, follower := range a.followers {
+		follower.Stop()
+	}
+	a.followers = nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileFollowerCheck(t *testing.T) {
+	fileContent := "<html>v1</html>"
+	var changes []string
+
+	follower := NewFileFollower("/tmp/log.html", time.Second, "<html>v1</html>", func(content string) {
+		changes = append(changes, content)
+	})
+	follower.readFile = func(path string) ([]byte, error) {
+		return []byte(fileContent), nil
+	}
+
+	// Unchanged content should not emit an update
+	if follower.check() {
+		t.Error("check() reported a change for identical content")
+	}
+
+	fileContent = "<html>v2</html>"
+	if !follower.check() {
+		t.Error("check() did not report a change for new content")
+	}
+
+	// Same content again should not re-emit
+	if follower.check() {
+		t.Error("check() reported a change twice for the same content")
+	}
+
+	if len(changes) != 1 || changes[0] != "<html>v2</html>" {
+		t.Errorf("Expected a single change with v2 content, got %v", changes)
+	}
+}
+
+func TestFileFollowerCheckReadError(t *testing.T) {
+	called := false
+	follower := NewFileFollower("/tmp/missing.html", time.Second, "", func(content string) {
+		called = true
+	})
+	follower.readFile = func(path string) ([]byte, error) {
+		return nil, errors.New("file not found")
+	}
+
+	if follower.check() || called {
+		t.Error("check() should ignore read errors")
+	}
+}
+
+func TestFileFollowerPolling(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "fenestro-follow-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "log.html")
+	if err := os.WriteFile(path, []byte("<html>v1</html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var changes []string
+	follower := NewFileFollower(path, 10*time.Millisecond, "<html>v1</html>", func(content string) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, content)
+	})
+	follower.Start()
+	defer follower.Stop()
+
+	if err := os.WriteFile(path, []byte("<html>v2</html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(changes)
+		mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(changes) != 1 || changes[0] != "<html>v2</html>" {
+		t.Errorf("Expected one change with v2 content, got %v", changes)
+	}
+
+	// Stop twice should not panic
+	follower.Stop()
+}
+
+func TestUpdateFileContentKeepsSelection(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.log", Path: "/tmp/a.log", Content: "<pre>a</pre>"}, "")
+	app.AddFile(FileEntry{Name: "b.log", Path: "/tmp/b.log", Content: "<pre>b</pre>"})
+	rec := recordEvents(app)
+
+	if !app.updateFileContent("/tmp/b.log", "<pre>b2</pre>") {
+		t.Fatal("updateFileContent() = false for a file in the sidebar")
+	}
+	if got := app.GetCurrentIndex(); got != 0 {
+		t.Errorf("current index = %d, want 0: a followed file that isn't shown mustn't be selected", got)
+	}
+	if files := app.GetFiles(); !files[1].Updated {
+		t.Error("the changed file should be flagged as updated")
+	}
+	if len(rec.named("file-updated")) != 1 || len(rec.named("content-replaced")) != 0 {
+		t.Errorf("events = %+v, want one file-updated", rec.events)
+	}
+	if got := app.SelectFile(1); got != "<pre>b2</pre>" {
+		t.Errorf("SelectFile(1) = %q, want the updated content", got)
+	}
+
+	if !app.updateFileContent("/tmp/b.log", "<pre>b3</pre>") {
+		t.Fatal("updateFileContent() = false for the shown file")
+	}
+	if got := app.GetHTMLContent(); got != "<pre>b3</pre>" {
+		t.Errorf("content = %q, want the shown file re-rendered", got)
+	}
+	if len(rec.named("content-replaced")) != 1 {
+		t.Error("updating the shown file should emit content-replaced")
+	}
+
+	if app.updateFileContent("/tmp/missing.log", "x") {
+		t.Error("updateFileContent() = true for a path not in the sidebar")
+	}
+}
+
+func TestFollowForwardedToRunningSidebar(t *testing.T) {
+	useTempSocketDir(t)
+	oldFollow := follow
+	follow = 10 * time.Millisecond
+	t.Cleanup(func() { follow = oldFollow })
+
+	path := filepath.Join(t.TempDir(), "b.log")
+	if err := os.WriteFile(path, []byte("b1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	app := NewApp(FileEntry{Name: "a.log", Path: "/tmp/a.log", Content: "<pre>a</pre>"}, "")
+	app.forcedContentType = "text/plain"
+	rec := recordEvents(app)
+	t.Cleanup(app.stopFollowing)
+	startTestServer(t, app, getSidebarSocketPath())
+
+	entry := FileEntry{Name: "b.log", Path: path, Content: "b1"}
+	applyContentType(&entry, "text/plain", false)
+	if sent, err := TrySendToSidebarInstance(entry, false, 0, ""); !sent || err != nil {
+		t.Fatalf("TrySendToSidebarInstance() = %v, %v, want the running sidebar to take the file", sent, err)
+	}
+	following := func() bool {
+		app.mu.RLock()
+		defer app.mu.RUnlock()
+		return app.followers[path] != nil
+	}
+	deadline := time.Now().Add(time.Second)
+	for !following() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !following() {
+		t.Fatal("the sidebar should follow a file sent with --follow")
+	}
+	if err := os.WriteFile(path, []byte("b2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline = time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if len(rec.named("file-updated")) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := app.GetCurrentIndex(); got != 0 {
+		t.Errorf("current index = %d, want the follow update to keep the selection", got)
+	}
+	if got := app.SelectFile(1); !strings.Contains(got, "b2") {
+		t.Errorf("followed file content = %q, want the rewritten file", got)
+	}
+}
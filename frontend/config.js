@@ -16,6 +16,39 @@ export function applyFontSize(config, contentElement) {
     return false;
 }
 
+/**
+ * Give the content element the configured content class, replacing the one
+ * applied before, so chrome CSS can scope rules to the rendered document.
+ *
+ * @param {Object} config - The config object from the backend
+ * @param {HTMLElement} contentElement - The element holding the rendered document
+ * @returns {string} The class now on the element
+ */
+export function applyContentClass(config, contentElement) {
+    const className = (config && config.content_class) || 'fenestro-content';
+    const previous = contentElement.dataset.contentClass;
+    if (previous && previous !== className) {
+        contentElement.classList.remove(previous);
+    }
+    contentElement.classList.add(className);
+    contentElement.dataset.contentClass = className;
+    return className;
+}
+
+/**
+ * Record the theme in effect as a data-theme attribute, so chrome CSS can
+ * style light and dark themes with [data-theme="dark"] selectors.
+ *
+ * @param {string} theme - The effective theme from the backend ('light' or 'dark')
+ * @param {HTMLElement} root - The element to mark (default: the document element)
+ * @returns {string} The theme applied
+ */
+export function applyTheme(theme, root = document.documentElement) {
+    const applied = theme === 'dark' ? 'dark' : 'light';
+    root.dataset.theme = applied;
+    return applied;
+}
+
 /**
  * Inject custom chrome CSS into the document.
  *
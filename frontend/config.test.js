@@ -1,5 +1,5 @@
 import { describe, it, expect, beforeEach } from 'vitest';
-import { applyFontSize, injectChromeCSS } from './config.js';
+import { applyFontSize, applyContentClass, applyTheme, injectChromeCSS } from './config.js';
 
 describe('applyFontSize', () => {
     let contentElement;
@@ -81,6 +81,59 @@ describe('applyFontSize', () => {
     });
 });
 
+describe('applyContentClass', () => {
+    let contentElement;
+
+    beforeEach(() => {
+        contentElement = document.createElement('div');
+        contentElement.id = 'content';
+    });
+
+    it('applies the configured content class', () => {
+        const result = applyContentClass({ content_class: 'doc-body' }, contentElement);
+
+        expect(result).toBe('doc-body');
+        expect(contentElement.classList.contains('doc-body')).toBe(true);
+    });
+
+    it('falls back to fenestro-content when content_class is unset', () => {
+        const result = applyContentClass({}, contentElement);
+
+        expect(result).toBe('fenestro-content');
+        expect(contentElement.classList.contains('fenestro-content')).toBe(true);
+    });
+
+    it('replaces the previous class when the config changes', () => {
+        contentElement.classList.add('fixed-viewport');
+        applyContentClass({ content_class: 'old-theme' }, contentElement);
+
+        applyContentClass({ content_class: 'new-theme' }, contentElement);
+
+        expect(contentElement.classList.contains('old-theme')).toBe(false);
+        expect(contentElement.classList.contains('new-theme')).toBe(true);
+        expect(contentElement.classList.contains('fixed-viewport')).toBe(true);
+    });
+});
+
+describe('applyTheme', () => {
+    it('marks the root element with the theme', () => {
+        const root = document.createElement('html');
+
+        expect(applyTheme('dark', root)).toBe('dark');
+        expect(root.dataset.theme).toBe('dark');
+
+        expect(applyTheme('light', root)).toBe('light');
+        expect(root.dataset.theme).toBe('light');
+    });
+
+    it('falls back to light for an unknown theme', () => {
+        const root = document.createElement('html');
+
+        expect(applyTheme(undefined, root)).toBe('light');
+        expect(root.dataset.theme).toBe('light');
+    });
+});
+
 describe('injectChromeCSS', () => {
     beforeEach(() => {
         // Clean up any existing chrome CSS
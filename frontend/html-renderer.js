@@ -1,16 +1,26 @@
 // HTML rendering logic for Fenestro
 // Handles full HTML documents with <head> and <body> tags
 
+// Content types parsed with DOMParser's XML parser
+const XML_TYPES = ['application/xhtml+xml', 'application/xml', 'text/xml', 'image/svg+xml'];
+
 /**
  * Parse HTML and extract scripts, styles, and body content.
  * Uses DOMParser to properly handle full HTML documents.
  *
  * @param {string} html - The HTML string to parse
+ * @param {string} contentType - The content's type: 'text/html' (default), an XML type such as 'application/xhtml+xml', or a type the backend has already rendered to HTML such as 'text/markdown'
  * @returns {Object} Parsed content with scripts, styles, links, and bodyContent
  */
-export function parseHTML(html) {
+export function parseHTML(html, contentType = 'text/html') {
     const parser = new DOMParser();
-    const doc = parser.parseFromString(html, 'text/html');
+    // Content of any other type (Markdown, JSON, diffs...) arrives rendered as HTML
+    const parserType = XML_TYPES.includes(contentType) ? contentType : 'text/html';
+    let doc = parser.parseFromString(html, parserType);
+    // Fall back to the HTML parser if strict XML parsing fails
+    if (doc.getElementsByTagName('parsererror').length > 0 || !doc.body) {
+        doc = parser.parseFromString(html, 'text/html');
+    }
 
     // Collect all scripts in document order (from both head and body)
     const scripts = [];
@@ -240,9 +250,10 @@ export async function renderParsedHTML(parsed, contentContainer, targetDocument
  * @param {HTMLElement} contentContainer - Element to render body content into
  * @param {Document} targetDocument - Document to inject styles/scripts into (default: document)
  * @param {string} basePath - Optional base path for resolving relative URLs (file system path)
+ * @param {string} contentType - Optional content type selecting the parser (default: 'text/html')
  * @returns {Promise<void>} Resolves when rendering is complete
  */
-export async function renderHTML(html, contentContainer, targetDocument = document, basePath = '') {
-    const parsed = parseHTML(html);
+export async function renderHTML(html, contentContainer, targetDocument = document, basePath = '', contentType = 'text/html') {
+    const parsed = parseHTML(html, contentType);
     await renderParsedHTML(parsed, contentContainer, targetDocument, basePath);
 }
@@ -202,6 +202,30 @@ describe('parseHTML', () => {
             expect(result.bodyContent).toBeDefined();
         });
     });
+
+    describe('content type', () => {
+        it('parses XHTML with the XML parser', () => {
+            const html = '<html xmlns="http://www.w3.org/1999/xhtml"><head><title>t</title></head>' +
+                '<body><p>XHTML content</p></body></html>';
+            const result = parseHTML(html, 'application/xhtml+xml');
+
+            expect(result.bodyContent).toContain('XHTML content');
+        });
+
+        it('falls back to the HTML parser for malformed XHTML', () => {
+            const html = '<p>unclosed paragraph';
+            const result = parseHTML(html, 'application/xhtml+xml');
+
+            expect(result.bodyContent).toContain('unclosed paragraph');
+        });
+
+        it('parses content rendered from other types as HTML', () => {
+            const html = '<h1>Title</h1><p>from Markdown</p>';
+            const result = parseHTML(html, 'text/markdown');
+
+            expect(result.bodyContent).toContain('from Markdown');
+        });
+    });
 });
 
 describe('renderParsedHTML', () => {
@@ -1,7 +1,7 @@
 // Fenestro - Find in page and sidebar functionality
 
 import { renderHTML as renderHTMLContent } from './html-renderer.js';
-import { applyFontSize, injectChromeCSS } from './config.js';
+import { applyFontSize, applyContentClass, applyTheme, injectChromeCSS } from './config.js';
 
 (function() {
     'use strict';
@@ -30,20 +30,98 @@ import { applyFontSize, injectChromeCSS } from './config.js';
 
     // Render HTML content using the html-renderer module
     async function renderHTML(html, basePath = '') {
-        await renderHTMLContent(html, content, document, basePath);
+        const contentType = await window.go.main.App.GetCurrentContentType();
+        await renderHTMLContent(html, content, document, basePath, contentType);
+        notifyRendered();
+    }
+
+    // Tell the backend the content is on screen once layout has settled
+    // (two frames: one to apply the DOM changes, one to paint them)
+    function notifyRendered() {
+        requestAnimationFrame(() => requestAnimationFrame(() => {
+            window.go.main.App.NotifyRendered();
+        }));
+    }
+
+    // Get the base path for relative URLs, honoring the base_href_mode config
+    async function getBasePath() {
+        if (!await window.go.main.App.ShouldApplyBasePath()) {
+            return '';
+        }
+        return await window.go.main.App.GetCurrentBasePath();
     }
 
     // Load HTML content from backend
     async function loadContent() {
         try {
             const html = await window.go.main.App.GetHTMLContent();
-            const basePath = await window.go.main.App.GetCurrentBasePath();
+            const basePath = await getBasePath();
             await renderHTML(html, basePath);
+            await scrollToPendingAnchor();
+            revealLine(await window.go.main.App.ConsumePendingLine());
         } catch (err) {
             content.innerHTML = '<p style="color: red;">Error loading content: ' + err + '</p>';
         }
     }
 
+    // Scroll to an anchor requested by the backend (-p file.html#id or IPC scroll_to)
+    async function scrollToPendingAnchor() {
+        const anchor = await window.go.main.App.ConsumePendingAnchor();
+        if (anchor) {
+            const target = document.getElementById(anchor);
+            if (target) {
+                target.scrollIntoView({ block: 'start' });
+            }
+        }
+    }
+
+    // Scroll to and highlight a line of the first <pre> (--line, -p file:line or IPC line)
+    function revealLine(lineNumber) {
+        const pre = content.querySelector('pre');
+        if (!pre || lineNumber < 1) return;
+
+        // Find the text offsets where the line starts and ends
+        const walker = document.createTreeWalker(pre, NodeFilter.SHOW_TEXT);
+        const range = document.createRange();
+        let line = 1;
+        let started = lineNumber === 1;
+        if (started) range.setStart(pre, 0);
+        let node;
+        while ((node = walker.nextNode())) {
+            for (let i = 0; i < node.data.length; i++) {
+                if (node.data[i] !== '\n') continue;
+                if (started) {
+                    range.setEnd(node, i);
+                    return highlightLineRange(range);
+                }
+                line++;
+                if (line === lineNumber) {
+                    range.setStart(node, i + 1);
+                    started = true;
+                }
+            }
+        }
+        if (started) {
+            range.setEnd(pre, pre.childNodes.length);
+            highlightLineRange(range);
+        }
+    }
+
+    // Mark the revealed line and scroll it to the middle of the view
+    function highlightLineRange(range) {
+        const mark = document.createElement('mark');
+        mark.className = 'fenestro-line';
+        try {
+            range.surroundContents(mark);
+        } catch (err) {
+            // The line spans elements (e.g. highlighted code); just scroll to it
+            const rect = range.getBoundingClientRect();
+            content.scrollTop += rect.top - content.getBoundingClientRect().top - content.clientHeight / 2;
+            return;
+        }
+        mark.scrollIntoView({ block: 'center' });
+    }
+
     // Load files and update sidebar
     async function loadFiles() {
         try {
@@ -68,10 +146,12 @@ import { applyFontSize, injectChromeCSS } from './config.js';
         fileList.innerHTML = '';
         files.forEach((file, index) => {
             const item = document.createElement('div');
-            item.className = 'file-item' + (index === selectedIndex ? ' selected' : '');
+            item.className = 'file-item' + (index === selectedIndex ? ' selected' : '') +
+                (file.updated ? ' updated' : '');
             item.textContent = file.name;
             item.title = file.path || file.name;
-            item.addEventListener('click', () => selectFile(index));
+            // Select by path when there is one, so a re-sort can't change the target
+            item.addEventListener('click', () => file.path ? selectFileByPath(file.path) : selectFile(index));
             fileList.appendChild(item);
         });
     }
@@ -80,22 +160,51 @@ import { applyFontSize, injectChromeCSS } from './config.js';
     async function selectFile(index) {
         try {
             const html = await window.go.main.App.SelectFile(index);
-            const basePath = await window.go.main.App.GetCurrentBasePath();
+            const basePath = await getBasePath();
             await renderHTML(html, basePath);
             selectedIndex = index;
+            if (files[index]) files[index].updated = false;
             updateSidebar();
-            // Clear find highlights when switching files
-            clearHighlights();
-            findInput.value = '';
-            findCount.textContent = '';
-            matches = [];
-            currentMatchIndex = -1;
-            lastSearchTerm = '';
+            resetFind();
         } catch (err) {
             console.error('Error selecting file:', err);
         }
     }
 
+    // Select a file by path; the sidebar is updated by the file-selected event
+    async function selectFileByPath(path) {
+        try {
+            const html = await window.go.main.App.SetCurrentByPath(path);
+            const basePath = await getBasePath();
+            await renderHTML(html, basePath);
+            resetFind();
+        } catch (err) {
+            console.error('Error selecting file:', err);
+        }
+    }
+
+    // Clear find highlights when switching files
+    function resetFind() {
+        clearHighlights();
+        findInput.value = '';
+        findCount.textContent = '';
+        matches = [];
+        currentMatchIndex = -1;
+        lastSearchTerm = '';
+    }
+
+    // Jump to the next file that was updated since it was last viewed
+    async function selectNextUpdated() {
+        try {
+            await window.go.main.App.SelectNextUpdated();
+            const index = await window.go.main.App.GetCurrentIndex();
+            if (index === selectedIndex) return;
+            await selectFile(index);
+        } catch (err) {
+            console.error('Error selecting next updated file:', err);
+        }
+    }
+
     // Handle file-added event from backend
     function onFileAdded(data) {
         files = data.files;
@@ -111,6 +220,27 @@ import { applyFontSize, injectChromeCSS } from './config.js';
         loadContent();
     }
 
+    // Handle file-updated event from backend (a followed file that isn't shown changed)
+    function onFileUpdated(data) {
+        files = data.files;
+        updateSidebar();
+    }
+
+    // Handle file-selected event from backend (selection made by path)
+    function onFileSelected(data) {
+        files = data.files;
+        selectedIndex = data.currentIndex;
+        updateSidebar();
+    }
+
+    // Handle files-changed event from backend (whole file list replaced)
+    function onFilesChanged(data) {
+        files = data.files;
+        selectedIndex = data.currentIndex;
+        updateSidebar();
+        loadContent();
+    }
+
     // Show find bar
     function showFindBar() {
         findBar.classList.remove('hidden');
@@ -262,9 +392,12 @@ import { applyFontSize, injectChromeCSS } from './config.js';
         };
     }
 
-    // Zoom functions
+    // Zoom functions. Zoom scales the whole page and is remembered per window
+    // (WindowState.zoom); font_size only sets the default text size.
     function applyZoom() {
         content.style.zoom = zoomLevel;
+        window.go.main.App.SetZoom(zoomLevel);
+        saveWindowGeometry();
     }
 
     function zoomIn() {
@@ -335,6 +468,36 @@ import { applyFontSize, injectChromeCSS } from './config.js';
             // Cmd+0 for reset zoom
             e.preventDefault();
             resetZoom();
+        } else if ((e.metaKey || e.ctrlKey) && e.shiftKey && e.key.toLowerCase() === 'r') {
+            // Cmd+Shift+R to reload the config file
+            e.preventDefault();
+            window.go.main.App.ReloadConfig();
+        } else if ((e.metaKey || e.ctrlKey) && e.key === 'j') {
+            // Cmd+J to jump to the next updated file
+            e.preventDefault();
+            selectNextUpdated();
+        } else if ((e.metaKey || e.ctrlKey) && e.shiftKey && e.key.toLowerCase() === 'n') {
+            // Cmd+Shift+N to open the current file in its own window
+            e.preventDefault();
+            window.go.main.App.DuplicateToNewWindow().catch((err) => {
+                console.error('Error opening new window:', err);
+            });
+        } else if ((e.metaKey || e.ctrlKey) && e.shiftKey && e.key.toLowerCase() === 'd') {
+            // Cmd+Shift+D to open a copy of the whole sidebar in a new window
+            e.preventDefault();
+            window.go.main.App.Duplicate().catch((err) => {
+                console.error('Error duplicating window:', err);
+            });
+        } else if ((e.metaKey || e.ctrlKey) && !e.shiftKey && e.key === 'u') {
+            // Cmd+U to switch between the rendered content and its source
+            e.preventDefault();
+            window.go.main.App.ToggleViewSource();
+        } else if ((e.metaKey || e.ctrlKey) && !e.shiftKey && e.key === 'p') {
+            // Cmd+P to print the rendered content
+            e.preventDefault();
+            window.go.main.App.PrintCurrent().catch((err) => {
+                console.error('Error printing:', err);
+            });
         }
     });
 
@@ -343,6 +506,7 @@ import { applyFontSize, injectChromeCSS } from './config.js';
         try {
             const config = await window.go.main.App.GetConfig();
             applyFontSize(config, content);
+            applyContentClass(config, content);
 
             // Load and inject custom chrome CSS
             const chromeCSS = await window.go.main.App.GetChromeCSS();
@@ -352,6 +516,43 @@ import { applyFontSize, injectChromeCSS } from './config.js';
         }
     }
 
+    // Handle config-changed event from backend (config file reloaded)
+    async function onConfigChanged() {
+        content.style.fontSize = '';
+        document.getElementById('fenestro-chrome-css')?.remove();
+        await loadConfig();
+        await loadContent();
+    }
+
+    // Handle chrome-css-changed event from backend (CSS pushed at runtime)
+    function onChromeCSSChanged(chromeCSS) {
+        document.getElementById('fenestro-chrome-css')?.remove();
+        injectChromeCSS(chromeCSS);
+    }
+
+    // Show a warning badge listing non-fatal load problems in its tooltip
+    function showLoadErrors(errors) {
+        const badge = document.getElementById('load-errors');
+        if (!errors || errors.length === 0) {
+            badge.classList.add('hidden');
+            return;
+        }
+        badge.textContent = '\u26A0 ' + errors.length;
+        badge.title = errors.map(e => e.path ? e.path + ': ' + e.message : e.message).join('\n');
+        badge.classList.remove('hidden');
+    }
+
+    // Constrain the content to a fixed width for responsive checks (0 = full)
+    function applyViewport(width) {
+        if (width > 0) {
+            content.style.setProperty('--viewport-width', width + 'px');
+            content.classList.add('fixed-viewport');
+        } else {
+            content.style.removeProperty('--viewport-width');
+            content.classList.remove('fixed-viewport');
+        }
+    }
+
     // Window geometry saving
     // Debounced save to avoid excessive disk writes
     const saveWindowGeometry = debounce(async () => {
@@ -379,11 +580,26 @@ import { applyFontSize, injectChromeCSS } from './config.js';
     // Save geometry when window loses focus (user likely done moving/resizing)
     window.addEventListener('blur', saveWindowGeometry);
 
+    // Report focus so background windows can announce updates (notify_on_replace)
+    window.addEventListener('focus', () => window.go.main.App.SetWindowFocused(true));
+    window.addEventListener('blur', () => window.go.main.App.SetWindowFocused(false));
+
+    // Report system appearance changes so theme = "system" follows them live
+    const darkScheme = window.matchMedia('(prefers-color-scheme: dark)');
+    darkScheme.addEventListener('change', (e) => window.go.main.App.SetSystemAppearance(e.matches));
+
     // Initialize
     document.addEventListener('DOMContentLoaded', async () => {
         await loadConfig();
+        await window.go.main.App.SetSystemAppearance(darkScheme.matches);
+        applyTheme(await window.go.main.App.GetTheme());
+        applyViewport(await window.go.main.App.GetViewportWidth());
+        zoomLevel = await window.go.main.App.GetZoom();
+        content.style.zoom = zoomLevel;
         await loadContent();
         await loadFiles();
+        showLoadErrors(await window.go.main.App.GetLoadErrors());
+        window.go.main.App.SetWindowFocused(document.hasFocus());
         startGeometryTracking();
     });
 
@@ -391,5 +607,14 @@ import { applyFontSize, injectChromeCSS } from './config.js';
     if (window.runtime) {
         window.runtime.EventsOn('file-added', onFileAdded);
         window.runtime.EventsOn('content-replaced', onContentReplaced);
+        window.runtime.EventsOn('files-changed', onFilesChanged);
+        window.runtime.EventsOn('file-selected', onFileSelected);
+        window.runtime.EventsOn('file-updated', onFileUpdated);
+        window.runtime.EventsOn('config-changed', onConfigChanged);
+        window.runtime.EventsOn('chrome-css-changed', onChromeCSSChanged);
+        window.runtime.EventsOn('viewport-changed', applyViewport);
+        window.runtime.EventsOn('view-mode-changed', loadContent);
+        window.runtime.EventsOn('load-errors-changed', showLoadErrors);
+        window.runtime.EventsOn('theme-changed', applyTheme);
     }
 })();
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// headlessReason explains why no window can be opened on goos given the
+// environment read through getenv, or returns "" if a display looks
+// available. Only Linux is checked: there Wails needs an X11 or Wayland
+// display, and without one the window process fails deep in GTK. macOS
+// windows open through the login session's window server, which the
+// environment says nothing reliable about.
+func headlessReason(goos string, getenv func(string) string) string {
+	if goos != "linux" {
+		return ""
+	}
+	if getenv("DISPLAY") == "" && getenv("WAYLAND_DISPLAY") == "" {
+		return "DISPLAY and WAYLAND_DISPLAY are unset"
+	}
+	return ""
+}
+
+// checkDisplay returns an error if this environment can't open a window,
+// so fenestro can say so instead of waiting for a window that never starts
+func checkDisplay() error {
+	if reason := headlessReason(runtime.GOOS, os.Getenv); reason != "" {
+		return fmt.Errorf("no display available (%s); run fenestro from a desktop session or forward X11 with ssh -X", reason)
+	}
+	return nil
+}
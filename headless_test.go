@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestHeadlessReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		goos     string
+		env      map[string]string
+		headless bool
+	}{
+		{"linux without a display", "linux", nil, true},
+		{"linux with empty variables", "linux", map[string]string{"DISPLAY": "", "WAYLAND_DISPLAY": ""}, true},
+		{"linux over ssh without forwarding", "linux", map[string]string{"SSH_CONNECTION": "10.0.0.1 5000 10.0.0.2 22"}, true},
+		{"linux with X11", "linux", map[string]string{"DISPLAY": ":0"}, false},
+		{"linux with X11 forwarding", "linux", map[string]string{"DISPLAY": "localhost:10.0", "SSH_CONNECTION": "10.0.0.1 5000 10.0.0.2 22"}, false},
+		{"linux with Wayland", "linux", map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, false},
+		{"linux with both", "linux", map[string]string{"DISPLAY": ":0", "WAYLAND_DISPLAY": "wayland-0"}, false},
+		{"macOS without DISPLAY", "darwin", nil, false},
+		{"macOS over ssh", "darwin", map[string]string{"SSH_CONNECTION": "10.0.0.1 5000 10.0.0.2 22"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			reason := headlessReason(tt.goos, getenv)
+			if (reason != "") != tt.headless {
+				t.Errorf("headlessReason(%s, %v) = %q, want headless = %v", tt.goos, tt.env, reason, tt.headless)
+			}
+		})
+	}
+}
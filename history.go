@@ -0,0 +1,58 @@
+package main
+
+// maxHistory is the number of visits kept in a window's navigation history
+const maxHistory = 50
+
+// HistoryEntry is a file visited in this window, oldest first in GetHistory
+type HistoryEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"` // empty for stdin
+	// Index is the file's current position in the sidebar, or -1 if it has
+	// since been removed
+	Index int `json:"index"`
+}
+
+// recordVisit appends the file at index to the navigation history, skipping
+// repeat visits to the file already at the end. Must be called with a.mu held
+// for writing.
+func (a *App) recordVisit(index int) {
+	if index < 0 || index >= len(a.files) {
+		return
+	}
+	f := a.files[index]
+	if n := len(a.history); n > 0 && a.history[n-1].Path == f.Path && a.history[n-1].Name == f.Name {
+		return
+	}
+	a.history = append(a.history, HistoryEntry{Name: f.Name, Path: f.Path})
+	if len(a.history) > maxHistory {
+		a.history = a.history[len(a.history)-maxHistory:]
+	}
+}
+
+// GetHistory returns the files visited in this window, oldest first, with
+// each entry's current sidebar index
+func (a *App) GetHistory() []HistoryEntry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := make([]HistoryEntry, len(a.history))
+	copy(result, a.history)
+	for i := range result {
+		result[i].Index = a.indexOfFile(result[i].Name, result[i].Path)
+	}
+	return result
+}
+
+// indexOfFile returns the index of the file with the given path, or with the
+// given name for stdin content, or -1. Must be called with a.mu held.
+func (a *App) indexOfFile(name, path string) int {
+	for i, f := range a.files {
+		if path != "" && f.Path == path {
+			return i
+		}
+		if path == "" && f.Path == "" && f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,80 @@
+package main
+
+import "testing"
+
+func newHistoryApp() *App {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "")
+	app.AddFile(FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "<p>b</p>"})
+	app.AddFile(FileEntry{Name: "c.html", Path: "/tmp/c.html", Content: "<p>c</p>"})
+	return app
+}
+
+func historyPaths(history []HistoryEntry) []string {
+	paths := make([]string, len(history))
+	for i, h := range history {
+		paths[i] = h.Path
+	}
+	return paths
+}
+
+func TestGetHistoryRecordsNavigationInOrder(t *testing.T) {
+	app := newHistoryApp()
+
+	app.SelectFile(2)                   // c
+	app.SetCurrentByPath("/tmp/b.html") // b
+	app.SelectFile(1)                   // b again: not recorded twice
+	app.ReplaceFileContent("/tmp/a.html", "<p>a2</p>", "")
+
+	history := app.GetHistory()
+	want := []string{"/tmp/a.html", "/tmp/c.html", "/tmp/b.html", "/tmp/a.html"}
+	got := historyPaths(history)
+	if len(got) != len(want) {
+		t.Fatalf("history = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("history = %v, want %v", got, want)
+		}
+	}
+
+	for _, h := range history {
+		if h.Index < 0 || app.files[h.Index].Path != h.Path {
+			t.Errorf("entry %+v should carry the file's current sidebar index", h)
+		}
+	}
+}
+
+func TestGetHistoryIndexAfterRemoval(t *testing.T) {
+	app := newHistoryApp()
+	app.SelectFile(2)
+
+	app.SetFiles([]FileEntry{{Name: "a.html", Path: "/tmp/a.html"}})
+
+	history := app.GetHistory()
+	if last := history[len(history)-1]; last.Path != "/tmp/c.html" || last.Index != -1 {
+		t.Errorf("removed file entry = %+v, want index -1", last)
+	}
+}
+
+func TestGetHistoryReturnsCopy(t *testing.T) {
+	app := newHistoryApp()
+	app.SelectFile(1)
+
+	history := app.GetHistory()
+	history[0].Path = "/tmp/changed.html"
+
+	if got := app.GetHistory()[0].Path; got != "/tmp/a.html" {
+		t.Errorf("modifying the returned history changed the app's history: %q", got)
+	}
+}
+
+func TestGetHistoryIsCapped(t *testing.T) {
+	app := newHistoryApp()
+	for i := 0; i < maxHistory+10; i++ {
+		app.SelectFile(i % 3)
+	}
+
+	if got := len(app.GetHistory()); got != maxHistory {
+		t.Errorf("history length = %d, want %d", got, maxHistory)
+	}
+}
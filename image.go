@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/base64"
+	"html"
+)
+
+// imageContentTypes maps the extensions of image files, which are previewed
+// scaled to fit the window instead of being read as HTML
+var imageContentTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".bmp":  "image/bmp",
+	".ico":  "image/x-icon",
+	".svg":  "image/svg+xml",
+}
+
+func init() {
+	for ext, mediaType := range imageContentTypes {
+		extensionContentTypes[ext] = mediaType
+	}
+}
+
+// isImageType reports whether contentType is one of imageContentTypes. Image
+// types come only from the file extension; they can't be forced with
+// --content-type.
+func isImageType(contentType string) bool {
+	for _, mediaType := range imageContentTypes {
+		if contentType == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// imageFitCSS scales the previewed image down to fit the window, keeping its
+// aspect ratio, without enlarging small images
+const imageFitCSS = `body { margin: 0; }
+.fenestro-image { display: flex; align-items: center; justify-content: center; min-height: 100vh; }
+.fenestro-image img { max-width: 100vw; max-height: 100vh; object-fit: contain; }`
+
+// imageToHTML wraps image data of the given media type in a page that shows
+// it scaled to fit the window. The image is embedded as a data: URI, so the
+// preview works for piped images and with disable_local_files.
+func imageToHTML(mediaType, data string) string {
+	src := "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString([]byte(data))
+	return "<!DOCTYPE html>\n<html><head><style>\n" + imageFitCSS + "\n</style></head>\n" +
+		`<body><div class="fenestro-image"><img src="` + html.EscapeString(src) + `" alt=""></div></body></html>` + "\n"
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestLoadContentTypeImages(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/pics/shot.png", "image/png"},
+		{"/pics/Photo.JPG", "image/jpeg"},
+		{"/pics/photo.jpeg", "image/jpeg"},
+		{"/pics/anim.gif", "image/gif"},
+		{"/pics/logo.svg", "image/svg+xml"},
+		{"/pics/page.html", ContentTypeHTML},
+		{"/pics/png", ContentTypeHTML},
+	}
+	for _, tt := range tests {
+		if got := loadContentType(tt.path); got != tt.want {
+			t.Errorf("loadContentType(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+		if got := isImageType(loadContentType(tt.path)); got != strings.HasPrefix(tt.want, "image/") {
+			t.Errorf("isImageType(loadContentType(%q)) = %v", tt.path, got)
+		}
+	}
+}
+
+func TestImageToHTML(t *testing.T) {
+	data := "\x89PNG\r\n\x1a\n\x00binary"
+	html := imageToHTML("image/png", data)
+
+	src := "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte(data))
+	if !strings.Contains(html, `<img src="`+src+`"`) {
+		t.Errorf("Expected the image as a data URI, got:\n%s", html)
+	}
+	for _, want := range []string{"<head><style>", "max-width: 100vw", "max-height: 100vh", "object-fit: contain"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected %q in the wrapper, got:\n%s", want, html)
+		}
+	}
+}
+
+func TestApplyContentTypeImage(t *testing.T) {
+	// CRLF bytes in the image must survive line ending normalization
+	data := "\x89PNG\r\n\x1a\n"
+	entry := FileEntry{Path: "/pics/shot.png", Content: data, ContentType: loadContentType("/pics/shot.png")}
+	applyContentType(&entry, "", true)
+
+	if entry.ContentType != ContentTypeHTML {
+		t.Errorf("ContentType = %q, want %q", entry.ContentType, ContentTypeHTML)
+	}
+	if entry.Content != imageToHTML("image/png", data) {
+		t.Errorf("Expected the image preview page, got:\n%s", entry.Content)
+	}
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 const (
@@ -15,15 +23,137 @@ const (
 	sidebarSocketName = "fenestro.sock"
 	windowsDir        = "windows"
 	groupingTimeout   = 2 * time.Second
+	// connIdleTimeout closes connections that stop sending
+	connIdleTimeout = 30 * time.Second
+	// messageTimeout bounds reading the rest of a message once it has started
+	// arriving
+	messageTimeout = 5 * time.Second
 )
 
 // IPCCommand represents a command sent via IPC
 type IPCCommand struct {
-	Cmd     string    `json:"cmd"`     // "add-file" or "replace"
-	Entry   FileEntry `json:"entry"`   // for add-file
-	Path    string    `json:"path"`    // for replace
-	Content string    `json:"content"` // for replace
-	Name    string    `json:"name"`    // for replace
+	Cmd      string      `json:"cmd"`                 // "add-file", "replace", "set-files", "exists", "wait-render", "close", "rename-id", "screenshot", or "ping"
+	Entry    FileEntry   `json:"entry"`               // for add-file
+	Path     string      `json:"path"`                // for replace and exists
+	Content  string      `json:"content"`             // for replace
+	Name     string      `json:"name"`                // for replace
+	Files    []FileEntry `json:"files,omitempty"`     // for set-files
+	Token    string      `json:"token,omitempty"`     // shared secret (ipc_token config)
+	ScrollTo string      `json:"scroll_to,omitempty"` // for replace: anchor to scroll to
+	BasePath string      `json:"base_path,omitempty"` // for replace: asset base directory override
+	// ExpectedHash makes a replace conditional: it's applied only if the
+	// file's current content has this hash (hex SHA-256)
+	ExpectedHash string `json:"expected_hash,omitempty"`
+	// WaitRender delays the response until the frontend has rendered the
+	// resulting content
+	WaitRender bool `json:"wait_render,omitempty"`
+	// NewID is the window ID to move the window to, for rename-id
+	NewID string `json:"new_id,omitempty"`
+	// MatchName has a replace without a path target the entry with the same
+	// Name (a sidebar --pipe-name upsert) rather than the first entry
+	// without a path
+	MatchName bool `json:"match_name,omitempty"`
+	// Select names the file to show after set-files (default: keep the
+	// current selection if it's still present). On add-file, any value shows
+	// the added file.
+	Select string `json:"select,omitempty"`
+	// LoadErrors reports files the sender couldn't read, for the window to
+	// show alongside the ones that arrived (add-file and set-files)
+	LoadErrors []LoadError `json:"load_errors,omitempty"`
+	// Output is the PNG file to write, for screenshot
+	Output string `json:"output,omitempty"`
+	// Forget has close remove the window's saved state file as well
+	Forget bool `json:"forget,omitempty"`
+	// Line is the line to reveal in the content once it's shown (--line or
+	// -p file:line), for replace and add-file. On add-file it also shows
+	// the added file.
+	Line int `json:"line,omitempty"`
+	// Follow has the receiving window re-read the file on this interval
+	// (--follow), for replace and add-file of a file with a path
+	Follow time.Duration `json:"follow,omitempty"`
+}
+
+// IPCResponse is sent back to the sender after each command is processed
+type IPCResponse struct {
+	OK       bool      `json:"ok"`
+	Error    string    `json:"error,omitempty"`
+	WindowID string    `json:"window_id,omitempty"` // for ping: the server's window ID
+	Stats    *AppStats `json:"stats,omitempty"`     // for ping: the window's contents
+	// Conflict is set when a conditional replace was rejected because the
+	// content changed; CurrentHash then holds the content's hash. After a
+	// conditional replace is applied, CurrentHash is the new content's hash.
+	Conflict    bool   `json:"conflict,omitempty"`
+	CurrentHash string `json:"current_hash,omitempty"`
+	// For exists: whether the path is loaded and its sidebar index (-1 if
+	// not). Pointers so they're only sent in reply to exists.
+	Exists *bool `json:"exists,omitempty"`
+	Index  *int  `json:"index,omitempty"`
+}
+
+// IPCRequest is the envelope form of a command, {"id", "cmd", "params"},
+// for senders that issue many commands over one connection. Params holds the
+// IPCCommand fields other than cmd. The reply is an IPCEnvelopeResponse
+// carrying the same id. Messages without an id use the flat IPCCommand form.
+type IPCRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Cmd    string          `json:"cmd"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// IPCEnvelopeResponse answers an IPCRequest, echoing its id verbatim
+type IPCEnvelopeResponse struct {
+	ID       json.RawMessage `json:"id"`
+	OK       bool            `json:"ok"`
+	Error    string          `json:"error,omitempty"`
+	Conflict bool            `json:"conflict,omitempty"`
+	Result   *IPCResult      `json:"result,omitempty"`
+}
+
+// IPCResult holds the data a command returns, if any
+type IPCResult struct {
+	WindowID string    `json:"window_id,omitempty"` // for ping: the server's window ID
+	Stats    *AppStats `json:"stats,omitempty"`     // for ping: the window's contents
+	// For replace with expected_hash and exists: see IPCResponse
+	CurrentHash string `json:"current_hash,omitempty"`
+	Exists      *bool  `json:"exists,omitempty"`
+	Index       *int   `json:"index,omitempty"`
+}
+
+// decodeIPCMessage parses one message in either the envelope or the flat
+// format. It returns the command and, for envelopes, the request id (nil for
+// flat commands).
+func decodeIPCMessage(raw json.RawMessage) (IPCCommand, json.RawMessage, error) {
+	var req IPCRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return IPCCommand{}, nil, err
+	}
+	var cmd IPCCommand
+	if req.ID == nil {
+		err := json.Unmarshal(raw, &cmd)
+		return cmd, nil, err
+	}
+	if req.Params != nil {
+		if err := json.Unmarshal(req.Params, &cmd); err != nil {
+			return IPCCommand{}, req.ID, err
+		}
+	}
+	cmd.Cmd = req.Cmd
+	return cmd, req.ID, nil
+}
+
+// envelopeResponse wraps resp as the reply to the request with the given id
+func envelopeResponse(id json.RawMessage, resp IPCResponse) IPCEnvelopeResponse {
+	env := IPCEnvelopeResponse{ID: id, OK: resp.OK, Error: resp.Error, Conflict: resp.Conflict}
+	if resp.WindowID != "" || resp.Stats != nil || resp.CurrentHash != "" || resp.Exists != nil {
+		env.Result = &IPCResult{
+			WindowID:    resp.WindowID,
+			Stats:       resp.Stats,
+			CurrentHash: resp.CurrentHash,
+			Exists:      resp.Exists,
+			Index:       resp.Index,
+		}
+	}
+	return env
 }
 
 // IPCServer manages the Unix socket server for receiving commands
@@ -34,7 +164,34 @@ type IPCServer struct {
 	mu           sync.Mutex
 	closed       bool
 	timeoutTimer *time.Timer
-	useTimeout   bool // false for window ID mode (persistent)
+	useTimeout   bool          // false for window ID mode (persistent)
+	workers      int           // connection worker pool size (0 = goroutine per connection)
+	commandLog   *commandLog   // audit log of processed commands (nil = off)
+	maxMessage   int64         // largest accepted message in bytes (max_ipc_bytes)
+	done         chan struct{} // closed by Close to stop the workers
+}
+
+// IPCStatus reports whether a window can receive commands, for the frontend
+type IPCStatus struct {
+	Running bool   `json:"running"`
+	Address string `json:"address"` // socket path ("" if no server started)
+}
+
+// status reports whether s is still accepting connections, and where. A
+// sidebar server stops once its grouping timeout passes.
+func (s *IPCServer) status() IPCStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return IPCStatus{Running: !s.closed, Address: s.socketPath}
+}
+
+// GetIPCStatus reports whether this window's IPC server is accepting
+// commands (so more files can be sent to it) and its socket path
+func (a *App) GetIPCStatus() IPCStatus {
+	if a.ipcServer == nil {
+		return IPCStatus{}
+	}
+	return a.ipcServer.status()
 }
 
 // getSocketDir returns the socket directory path
@@ -46,9 +203,54 @@ func getSocketDir() string {
 	return filepath.Join(homeDir, socketDir)
 }
 
-// getSidebarSocketPath returns the path for the sidebar mode socket
+// getSidebarSocketPath returns the path for the sidebar mode socket of the
+// --instance in use
 func getSidebarSocketPath() string {
-	return filepath.Join(getSocketDir(), sidebarSocketName)
+	return getSidebarSocketPathForInstance(instance)
+}
+
+// getSidebarSocketPathForInstance returns the sidebar socket path for a named
+// instance (fenestro-<name>.sock), or the shared socket if name is empty
+func getSidebarSocketPathForInstance(name string) string {
+	return filepath.Join(getSocketDir(), sidebarSocketFile(name))
+}
+
+// sidebarSocketFile returns the socket file name for a sidebar instance
+func sidebarSocketFile(name string) string {
+	if name == "" {
+		return sidebarSocketName
+	}
+	return "fenestro-" + name + ".sock"
+}
+
+// instanceNamePattern limits instance names to characters that are safe in
+// socket and state file names
+var instanceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// checkInstanceName returns an error if name can't be used with --instance
+func checkInstanceName(name string) error {
+	if !instanceNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid instance name %q (use letters, digits, '.', '_', or '-')", name)
+	}
+	return nil
+}
+
+// cwdInstance returns the instance name group_by_cwd gives sidebar calls
+// made from dir: a hash, so the name is safe in socket and state file names
+// whatever the path contains
+func cwdInstance(dir string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(dir)))
+	return "cwd-" + hex.EncodeToString(sum[:6])
+}
+
+// sidebarInstance returns the instance a sidebar call uses: the --instance
+// name if given, otherwise with group_by_cwd one derived from the working
+// directory cwd, otherwise the shared window ("")
+func sidebarInstance(name string, config Config, cwd string) string {
+	if name != "" || !config.GroupByCwd || cwd == "" {
+		return name
+	}
+	return cwdInstance(cwd)
 }
 
 // getWindowSocketPath returns the path for a specific window ID socket
@@ -66,43 +268,232 @@ func ensureSocketDir() error {
 	return os.MkdirAll(windowsPath, 0700)
 }
 
-// TrySendToExisting tries to connect to an existing instance and send a command
-// Returns true if successful (caller should exit), false if no instance running
-func TrySendToExisting(socketPath string, cmd IPCCommand) bool {
+// TrySendToExisting tries to send a command to an existing instance and
+// waits for its response. It returns true if the instance accepted it
+// (caller should exit), and false with no error if no instance is running,
+// removing the socket if no server is listening on it. A live instance that
+// rejects the command or doesn't answer is reported as an error.
+func TrySendToExisting(socketPath string, cmd IPCCommand) (bool, error) {
+	resp, err := SendCommand(socketPath, cmd)
+	if err != nil {
+		switch checkSocketOwnership(socketPath) {
+		case socketStale:
+			os.Remove(socketPath)
+		case socketLive:
+			return false, fmt.Errorf("the window listening on %s didn't take %s: %w", socketPath, cmd.Cmd, err)
+		}
+		return false, nil
+	}
+	if !resp.OK {
+		return false, fmt.Errorf("window rejected %s: %s", cmd.Cmd, resp.Error)
+	}
+	return true, nil
+}
+
+// CleanStaleSockets removes sockets in dir that no server is listening on and
+// returns how many were removed. This is the explicit, bulk counterpart to
+// the cleanup TrySendToExisting does when a connection fails.
+func CleanStaleSockets(dir string) (int, error) {
+	paths, err := staleSockets(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, path := range paths {
+		if err := os.Remove(path); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// staleSockets returns the sockets in dir that no server is listening on
+func staleSockets(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read socket directory: %w", err)
+	}
+
+	var stale []string
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".sock" {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		conn, err := net.DialTimeout("unix", path, 500*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			continue
+		}
+		stale = append(stale, path)
+	}
+	return stale, nil
+}
+
+// SendCommand sends a command to the server at socketPath and waits for its response
+func SendCommand(socketPath string, cmd IPCCommand) (IPCResponse, error) {
+	return sendCommandTimeout(socketPath, cmd, 2*time.Second)
+}
+
+// sendCommandTimeout is SendCommand with a custom deadline for the whole
+// exchange, for commands the window may take a while to answer
+func sendCommandTimeout(socketPath string, cmd IPCCommand, timeout time.Duration) (IPCResponse, error) {
 	conn, err := net.DialTimeout("unix", socketPath, 500*time.Millisecond)
 	if err != nil {
-		// Connection failed - socket might be stale, clean it up
-		os.Remove(socketPath)
-		return false
+		return IPCResponse{}, fmt.Errorf("failed to connect: %w", err)
 	}
 	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
 
-	encoder := json.NewEncoder(conn)
-	if err := encoder.Encode(cmd); err != nil {
-		return false
+	if err := json.NewEncoder(conn).Encode(cmd); err != nil {
+		return IPCResponse{}, fmt.Errorf("failed to send command: %w", err)
 	}
 
-	return true
+	var resp IPCResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return IPCResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp, nil
 }
 
-// TrySendToSidebarInstance tries to send a file to an existing sidebar instance
-func TrySendToSidebarInstance(entry FileEntry) bool {
-	cmd := IPCCommand{
-		Cmd:   "add-file",
-		Entry: entry,
-	}
+// TrySendToSidebarInstance tries to send a file to an existing sidebar
+// instance. With upsert, a file already in the sidebar is replaced by path
+// instead of being added again. A line above 0 shows the file scrolled to
+// that line. With --follow, the sidebar follows the file from then on.
+func TrySendToSidebarInstance(entry FileEntry, upsert bool, line int, token string) (bool, error) {
+	cmd := sidebarCommand(entry, upsert, token)
+	cmd.Line = line
+	cmd.Follow = followInterval(entry)
 	return TrySendToExisting(getSidebarSocketPath(), cmd)
 }
 
-// TrySendToWindowInstance tries to send content to a specific window
-func TrySendToWindowInstance(windowID string, entry FileEntry) bool {
-	cmd := IPCCommand{
-		Cmd:     "replace",
-		Path:    entry.Path,
-		Content: entry.Content,
-		Name:    entry.Name,
+// followInterval returns the --follow interval a running window should
+// follow entry with, or 0 for piped content, which has no file to re-read
+func followInterval(entry FileEntry) time.Duration {
+	if entry.Path == "" {
+		return 0
+	}
+	return follow
+}
+
+// sidebarCommand builds the command TrySendToSidebarInstance sends: add-file,
+// or replace (which adds the file if its path isn't present) for upsert.
+// Piped content has no path to match, so it's only upserted by its
+// --pipe-name and otherwise added.
+func sidebarCommand(entry FileEntry, upsert bool, token string) IPCCommand {
+	if !upsert || (entry.Path == "" && pipeName == "") {
+		return IPCCommand{
+			Cmd:   "add-file",
+			Entry: entry,
+			Token: token,
+		}
+	}
+	return IPCCommand{
+		Cmd:       "replace",
+		Path:      entry.Path,
+		Content:   entry.Content,
+		Name:      entry.Name,
+		Token:     token,
+		BasePath:  entry.BasePath,
+		MatchName: true,
+	}
+}
+
+// TrySendToWindowInstance tries to send content to a specific window,
+// optionally scrolled to an anchor or line (0 = none). With add, the content
+// is added to the window's sidebar instead of replacing what it shows. With
+// --follow, the window follows the file from then on. The window must first
+// confirm its ID (see checkWindowSocket). It returns false with no
+// error when no window is listening, and an error when a live server at the
+// socket doesn't confirm it's windowID.
+func TrySendToWindowInstance(windowID string, entry FileEntry, scrollTo string, line int, add bool, token string) (bool, error) {
+	socketPath := getWindowSocketPath(windowID)
+	if ok, err := checkWindowSocket(socketPath, windowID, token); !ok {
+		return false, err
 	}
-	return TrySendToExisting(getWindowSocketPath(windowID), cmd)
+	cmd := windowCommand(entry, scrollTo, line, add, token)
+	cmd.Follow = followInterval(entry)
+	return TrySendToExisting(socketPath, cmd)
+}
+
+// checkWindowSocket reports whether the server at socketPath confirms it's
+// windowID. A socket no server is listening on is removed so a new window
+// can bind it; a live server that doesn't confirm (a wrong token, a ping
+// timing out while it's busy, another window's ID) keeps its socket and is
+// reported as an error.
+func checkWindowSocket(socketPath, windowID, token string) (bool, error) {
+	if verifyWindowIdentity(socketPath, windowID, token) {
+		return true, nil
+	}
+	switch checkSocketOwnership(socketPath) {
+	case socketStale:
+		os.Remove(socketPath)
+	case socketLive:
+		return false, fmt.Errorf("the window listening on %s didn't confirm it's %s", socketPath, windowID)
+	}
+	return false, nil
+}
+
+// windowCommand builds the command TrySendToWindowInstance sends: replace,
+// or add-file for add. An added file isn't selected, so there's no anchor
+// to scroll to, unless a line is given, which shows it at that line.
+func windowCommand(entry FileEntry, scrollTo string, line int, add bool, token string) IPCCommand {
+	if add {
+		return IPCCommand{
+			Cmd:   "add-file",
+			Entry: entry,
+			Token: token,
+			Line:  line,
+		}
+	}
+	return IPCCommand{
+		Cmd:      "replace",
+		Path:     entry.Path,
+		Content:  entry.Content,
+		Name:     entry.Name,
+		Token:    token,
+		ScrollTo: scrollTo,
+		BasePath: entry.BasePath,
+		Line:     line,
+	}
+}
+
+// verifyWindowIdentity pings the server at socketPath and reports whether it
+// identifies as windowID
+func verifyWindowIdentity(socketPath, windowID, token string) bool {
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "ping", Token: token})
+	return err == nil && resp.OK && resp.WindowID == windowID
+}
+
+// errSocketInUse is returned by NewIPCServer when another live server already
+// owns the socket path
+var errSocketInUse = errors.New("socket is in use by another instance")
+
+// socketOwnership describes what is found at a socket path before binding
+type socketOwnership int
+
+const (
+	socketAbsent socketOwnership = iota // nothing at the path
+	socketStale                         // a socket file no server is listening on
+	socketLive                          // a socket with a live server
+)
+
+// checkSocketOwnership reports whether a socket path is free, left behind by
+// a dead server, or owned by a live one
+func checkSocketOwnership(socketPath string) socketOwnership {
+	if _, err := os.Lstat(socketPath); err != nil {
+		return socketAbsent
+	}
+	conn, err := net.DialTimeout("unix", socketPath, 500*time.Millisecond)
+	if err != nil {
+		return socketStale
+	}
+	conn.Close()
+	return socketLive
 }
 
 // NewIPCServer creates a new IPC server
@@ -111,9 +502,18 @@ func NewIPCServer(app *App, socketPath string, useTimeout bool) (*IPCServer, err
 		return nil, fmt.Errorf("failed to create socket directory: %w", err)
 	}
 
-	// Remove existing socket file if it exists
-	os.Remove(socketPath)
+	// Never take over a socket from a live server; that would leave two
+	// windows with new files silently going to only one of them
+	switch checkSocketOwnership(socketPath) {
+	case socketLive:
+		return nil, fmt.Errorf("%w: %s", errSocketInUse, socketPath)
+	case socketStale:
+		fmt.Fprintf(os.Stderr, "fenestro: removed stale socket left by a previous instance: %s\n", socketPath)
+		os.Remove(socketPath)
+	}
 
+	// No backlog tuning is needed for bursts of senders: net.Listen already
+	// passes the kernel maximum (somaxconn) to listen(2) on Linux and macOS
 	listener, err := net.Listen("unix", socketPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create socket: %w", err)
@@ -124,6 +524,16 @@ func NewIPCServer(app *App, socketPath string, useTimeout bool) (*IPCServer, err
 		socketPath: socketPath,
 		app:        app,
 		useTimeout: useTimeout,
+		workers:    app.config.IPCWorkers,
+		commandLog: newCommandLog(app.config, app.windowID, app.instance),
+		maxMessage: app.config.MaxIPCBytes,
+		done:       make(chan struct{}),
+	}
+	if server.workers <= 0 {
+		server.workers = DefaultIPCWorkers
+	}
+	if server.maxMessage <= 0 {
+		server.maxMessage = DefaultMaxIPCBytes
 	}
 
 	// Start timeout timer if in sidebar mode
@@ -148,11 +558,40 @@ func (s *IPCServer) resetTimeout() {
 	})
 }
 
-// Start begins accepting connections
+// Start begins accepting connections. Commands are processed by a fixed
+// pool of workers, bounding concurrency under bursts of senders. A worker
+// is held for one command at a time: a connection waiting for its next
+// command is parked off the pool (see awaitCommand), so idle streaming
+// senders can't keep one-shot senders waiting. Commands on one connection
+// are processed in order, but there is no ordering guarantee between
+// different connections.
 func (s *IPCServer) Start() {
+	var ready chan *ipcConn
+	if s.workers > 0 {
+		ready = make(chan *ipcConn)
+		for i := 0; i < s.workers; i++ {
+			go func() {
+				for {
+					select {
+					case c := <-ready:
+						if s.serveCommand(c) {
+							go s.awaitCommand(c, ready)
+						}
+					case <-s.done:
+						return
+					}
+				}
+			}()
+		}
+	}
+
 	go func() {
 		for {
-			conn, err := s.listener.Accept()
+			// Read under the lock: rename-id swaps the listener
+			s.mu.Lock()
+			listener := s.listener
+			s.mu.Unlock()
+			conn, err := listener.Accept()
 			if err != nil {
 				s.mu.Lock()
 				closed := s.closed
@@ -162,33 +601,291 @@ func (s *IPCServer) Start() {
 				}
 				continue
 			}
-
-			go s.handleConnection(conn)
+			go s.awaitCommand(newIPCConn(conn), ready)
 		}
 	}()
 }
 
-// handleConnection processes a single IPC connection
-func (s *IPCServer) handleConnection(conn net.Conn) {
-	defer conn.Close()
+// ipcConn is an accepted connection with the decoder its messages are read
+// from, which may have buffered the start of the next one
+type ipcConn struct {
+	net.Conn
+	limited *io.LimitedReader
+	decoder *json.Decoder
+	encoder *json.Encoder
+}
+
+func newIPCConn(conn net.Conn) *ipcConn {
+	limited := &io.LimitedReader{R: conn}
+	return &ipcConn{
+		Conn:    conn,
+		limited: limited,
+		decoder: json.NewDecoder(limited),
+		encoder: json.NewEncoder(conn),
+	}
+}
 
-	// Reset timeout on each new file (sidebar mode only)
+// awaitCommand waits, without holding a worker, until the next message on c
+// starts arriving, the sender closes it, or it's idle for connIdleTimeout,
+// then hands c to a worker through ready. Without a pool (ready is nil) the
+// connection is served here instead, until it's closed.
+func (s *IPCServer) awaitCommand(c *ipcConn, ready chan<- *ipcConn) {
+	for {
+		c.SetReadDeadline(time.Now().Add(connIdleTimeout))
+		c.limited.N = s.maxMessage
+		// Errors are left for serveCommand, which gets them again on Decode
+		c.decoder.More()
+		if ready == nil {
+			if !s.serveCommand(c) {
+				return
+			}
+			continue
+		}
+		select {
+		case ready <- c:
+		case <-s.done:
+			c.Close()
+		}
+		return
+	}
+}
+
+// serveCommand reads, processes and answers one message from c, and reports
+// whether c is still open for more. One-shot senders send a single command
+// and close; streaming senders can keep the connection open and send many
+// commands, each answered in order. Each message may read at most
+// maxMessage bytes from the connection; a sender that goes over is answered
+// with an error and disconnected, since the rest of its stream can't be
+// parsed.
+func (s *IPCServer) serveCommand(c *ipcConn) bool {
+	// A sender that stalls partway through a message only holds the worker
+	// this long
+	c.SetReadDeadline(time.Now().Add(messageTimeout))
+	var raw json.RawMessage
+	if err := c.decoder.Decode(&raw); err != nil {
+		if c.limited.N <= 0 {
+			fmt.Fprintf(os.Stderr, "Warning: Rejected IPC message over max_ipc_bytes (%d bytes)\n", s.maxMessage)
+			c.encoder.Encode(IPCResponse{OK: false, Error: fmt.Sprintf("message exceeds max_ipc_bytes (%d bytes)", s.maxMessage)})
+		}
+		c.Close()
+		return false
+	}
+
+	// Reset timeout on each new command (sidebar mode only)
 	if s.useTimeout {
 		s.resetTimeout()
 	}
 
-	decoder := json.NewDecoder(conn)
-	var cmd IPCCommand
-	if err := decoder.Decode(&cmd); err != nil {
+	// One-shot senders may already have closed the connection; that's fine
+	cmd, id, err := decodeIPCMessage(raw)
+	var resp IPCResponse
+	if err != nil {
+		resp = IPCResponse{OK: false, Error: "invalid command: " + err.Error()}
+	} else {
+		resp = s.processCommand(cmd)
+		s.logCommand(cmd, resp)
+	}
+	if id != nil {
+		c.encoder.Encode(envelopeResponse(id, resp))
+	} else {
+		c.encoder.Encode(resp)
+	}
+
+	// Quit only once the sender has its answer
+	if cmd.Cmd == "close" && resp.OK {
+		s.app.Quit()
+		c.Close()
+		return false
+	}
+	return true
+}
+
+// logCommand records cmd in the command log if logging is on. Liveness pings
+// are skipped so identity checks don't flood the log.
+func (s *IPCServer) logCommand(cmd IPCCommand, resp IPCResponse) {
+	if s.commandLog == nil || cmd.Cmd == "ping" {
 		return
 	}
+	if err := s.commandLog.Record(cmd, resp); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not write IPC command log: %v\n", err)
+	}
+}
+
+// processCommand validates and applies a single command
+func (s *IPCServer) processCommand(cmd IPCCommand) IPCResponse {
+	if !s.validToken(cmd.Token) {
+		return IPCResponse{OK: false, Error: "invalid token"}
+	}
+
+	if err := s.checkAllowed(cmd); err != nil {
+		if cmd.Cmd == "add-file" || cmd.Cmd == "set-files" {
+			s.app.recordLoadError(LoadError{Message: err.Error()})
+		}
+		return IPCResponse{OK: false, Error: err.Error()}
+	}
+	for _, loadErr := range cmd.LoadErrors {
+		s.app.recordLoadError(loadErr)
+	}
 
+	var currentHash string
 	switch cmd.Cmd {
 	case "add-file":
-		s.app.AddFile(cmd.Entry)
+		if cmd.Line > 0 {
+			s.app.SetPendingLine(cmd.Line)
+		}
+		s.app.addFile(cmd.Entry, cmd.Select != "" || cmd.Line > 0)
+		if cmd.Follow > 0 && cmd.Entry.Path != "" {
+			s.app.followFile(cmd.Entry.Path, cmd.Follow)
+		}
 	case "replace":
-		s.app.ReplaceFileContent(cmd.Path, cmd.Content, cmd.Name)
+		if cmd.ScrollTo != "" {
+			s.app.SetPendingAnchor(cmd.ScrollTo)
+		}
+		if cmd.Line > 0 {
+			s.app.SetPendingLine(cmd.Line)
+		}
+		err := s.app.replaceEntryIfMatch(FileEntry{
+			Path:     cmd.Path,
+			Content:  cmd.Content,
+			Name:     cmd.Name,
+			BasePath: cmd.BasePath,
+		}, cmd.ExpectedHash, cmd.MatchName)
+		var conflict *conflictError
+		if errors.As(err, &conflict) {
+			if cmd.ScrollTo != "" {
+				s.app.SetPendingAnchor("")
+			}
+			if cmd.Line > 0 {
+				s.app.SetPendingLine(0)
+			}
+			return IPCResponse{OK: false, Error: err.Error(), Conflict: true, CurrentHash: conflict.currentHash}
+		}
+		if cmd.ExpectedHash != "" {
+			currentHash = contentHash(cmd.Content)
+		}
+		if cmd.Follow > 0 && cmd.Path != "" {
+			s.app.followFile(cmd.Path, cmd.Follow)
+		}
+	case "set-files":
+		s.app.setFiles(cmd.Files, cmd.Select)
+	case "exists":
+		exists, index := s.app.HasFile(cmd.Path)
+		return IPCResponse{OK: true, Exists: &exists, Index: &index}
+	case "wait-render":
+		cmd.WaitRender = true
+	case "close":
+		if s.app.ctx == nil {
+			return IPCResponse{OK: false, Error: errNoWindow.Error()}
+		}
+		if cmd.Forget {
+			if err := s.app.forgetWindowState(); err != nil {
+				return IPCResponse{OK: false, Error: err.Error()}
+			}
+		}
+	case "rename-id":
+		if err := s.renameWindow(cmd.NewID); err != nil {
+			return IPCResponse{OK: false, Error: err.Error()}
+		}
+		return IPCResponse{OK: true, WindowID: cmd.NewID}
+	case "screenshot":
+		if err := s.app.captureScreenshot(cmd.Output); err != nil {
+			return IPCResponse{OK: false, Error: err.Error()}
+		}
+	case "ping":
+		stats := s.app.GetStats()
+		return IPCResponse{OK: true, WindowID: s.app.GetWindowID(), Stats: &stats}
+	default:
+		return IPCResponse{OK: false, Error: "unknown command: " + cmd.Cmd}
+	}
+
+	if cmd.WaitRender {
+		if err := s.app.WaitRendered(renderTimeout); err != nil {
+			return IPCResponse{OK: false, Error: err.Error()}
+		}
 	}
+	return IPCResponse{OK: true, CurrentHash: currentHash}
+}
+
+// renameWindow moves a window ID mode window to newID: it listens on the new
+// ID's socket, stops accepting connections on the old one, and removes it.
+// Connections already open, including the one that asked for the rename,
+// are unaffected.
+func (s *IPCServer) renameWindow(newID string) error {
+	if _, err := uuid.Parse(newID); err != nil {
+		return fmt.Errorf("invalid window ID format (expected UUID): %s", newID)
+	}
+	if s.app.GetWindowID() == "" {
+		return fmt.Errorf("only windows opened with --id can be renamed")
+	}
+	newPath := getWindowSocketPath(newID)
+	switch checkSocketOwnership(newPath) {
+	case socketLive:
+		return fmt.Errorf("%w: %s", errSocketInUse, newPath)
+	case socketStale:
+		os.Remove(newPath)
+	}
+	listener, err := net.Listen("unix", newPath)
+	if err != nil {
+		return fmt.Errorf("failed to create socket: %w", err)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		os.Remove(newPath)
+		return fmt.Errorf("server is closed")
+	}
+	oldListener, oldPath := s.listener, s.socketPath
+	s.listener, s.socketPath = listener, newPath
+	// The log follows the window to a file named for its new ID
+	var logErr error
+	if s.commandLog != nil {
+		logErr = s.commandLog.moveTo(getCommandLogPath(newID, ""))
+	}
+	s.mu.Unlock()
+
+	oldListener.Close()
+	os.Remove(oldPath)
+	if logErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", logErr)
+	}
+
+	s.app.mu.Lock()
+	s.app.windowID = newID
+	s.app.mu.Unlock()
+	return nil
+}
+
+// checkAllowed verifies every file path carried by a command against the
+// allowed_extensions setting. exists only asks about a path without opening
+// it, so like ping it's never refused.
+func (s *IPCServer) checkAllowed(cmd IPCCommand) error {
+	if cmd.Cmd == "exists" {
+		return nil
+	}
+	config := s.app.GetConfig()
+	paths := []string{cmd.Entry.Path, cmd.Path}
+	for _, f := range cmd.Files {
+		paths = append(paths, f.Path)
+	}
+	for _, path := range paths {
+		if err := config.checkExtensionAllowed(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validToken checks a command's token against the ipc_token in the app's
+// current config, so a reloaded config takes effect. Once a token is
+// configured, every command must carry it.
+func (s *IPCServer) validToken(token string) bool {
+	expected := s.app.GetConfig().IPCToken
+	if expected == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
 }
 
 // Close shuts down the IPC server and removes the socket file
@@ -208,6 +905,9 @@ func (s *IPCServer) Close() {
 	if s.listener != nil {
 		s.listener.Close()
 	}
+	if s.done != nil {
+		close(s.done)
+	}
 
 	// Clean up socket file
 	os.Remove(s.socketPath)
@@ -215,7 +915,7 @@ func (s *IPCServer) Close() {
 
 // StartSidebarServer starts an IPC server for sidebar mode with timeout
 func StartSidebarServer(app *App) (*IPCServer, error) {
-	server, err := NewIPCServer(app, getSidebarSocketPath(), true)
+	server, err := NewIPCServer(app, getSidebarSocketPathForInstance(app.instance), true)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
 	"os"
 	"path/filepath"
@@ -31,6 +33,63 @@ func TestGetSidebarSocketPath(t *testing.T) {
 	}
 }
 
+func TestGetSidebarSocketPathForInstance(t *testing.T) {
+	if got := getSidebarSocketPathForInstance(""); got != filepath.Join(getSocketDir(), sidebarSocketName) {
+		t.Errorf("getSidebarSocketPathForInstance(\"\") = %q, want the shared socket", got)
+	}
+
+	docs := getSidebarSocketPathForInstance("docs")
+	diff := getSidebarSocketPathForInstance("diff")
+	if docs == diff {
+		t.Errorf("different instances should use different sockets, both got %q", docs)
+	}
+	if docs != filepath.Join(getSocketDir(), "fenestro-docs.sock") {
+		t.Errorf("getSidebarSocketPathForInstance(\"docs\") = %q, want fenestro-docs.sock in the socket dir", docs)
+	}
+}
+
+func TestSidebarInstanceGroupByCwd(t *testing.T) {
+	socketFor := func(config Config, cwd string) string {
+		return getSidebarSocketPathForInstance(sidebarInstance("", config, cwd))
+	}
+
+	disabled := DefaultConfig()
+	if a, b := socketFor(disabled, "/src/one"), socketFor(disabled, "/src/two"); a != b || a != getSidebarSocketPathForInstance("") {
+		t.Errorf("without group_by_cwd both directories should share the sidebar socket, got %q and %q", a, b)
+	}
+
+	enabled := DefaultConfig()
+	enabled.GroupByCwd = true
+	one, two := socketFor(enabled, "/src/one"), socketFor(enabled, "/src/two")
+	if one == two {
+		t.Errorf("with group_by_cwd different directories should use different sockets, both got %q", one)
+	}
+	if again := socketFor(enabled, "/src/one/"); again != one {
+		t.Errorf("the same directory should always get the same socket, got %q and %q", one, again)
+	}
+	if err := checkInstanceName(cwdInstance("/path with spaces/../odd")); err != nil {
+		t.Errorf("cwdInstance() should be a valid instance name: %v", err)
+	}
+
+	// An explicit --instance wins
+	if got := sidebarInstance("docs", enabled, "/src/one"); got != "docs" {
+		t.Errorf("sidebarInstance() = %q, want the --instance name", got)
+	}
+}
+
+func TestCheckInstanceName(t *testing.T) {
+	for _, name := range []string{"docs", "diff-tool", "my_tool.2", "A1"} {
+		if err := checkInstanceName(name); err != nil {
+			t.Errorf("checkInstanceName(%q) error = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"", "../escape", "a/b", ".hidden", "has space"} {
+		if err := checkInstanceName(name); err == nil {
+			t.Errorf("checkInstanceName(%q) should fail", name)
+		}
+	}
+}
+
 func TestGetWindowSocketPath(t *testing.T) {
 	windowID := "test-uuid-1234"
 	path := getWindowSocketPath(windowID)
@@ -98,10 +157,51 @@ func TestTrySendToExistingNoSocket(t *testing.T) {
 	os.Remove(socketPath) // Ensure it doesn't exist
 
 	cmd := IPCCommand{Cmd: "test"}
-	result := TrySendToExisting(socketPath, cmd)
+	result, err := TrySendToExisting(socketPath, cmd)
+
+	if result || err != nil {
+		t.Errorf("TrySendToExisting() = %v, %v, want false with no error when socket doesn't exist", result, err)
+	}
+}
+
+func TestTrySendToExistingStaleSocket(t *testing.T) {
+	useTempSocketDir(t)
+	socketPath := getSidebarSocketPath()
+	if err := ensureSocketDir(); err != nil {
+		t.Fatalf("ensureSocketDir() failed: %v", err)
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Could not create socket: %v", err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
 
-	if result {
-		t.Error("TrySendToExisting() should return false when socket doesn't exist")
+	if sent, err := TrySendToExisting(socketPath, IPCCommand{Cmd: "ping"}); sent || err != nil {
+		t.Fatalf("TrySendToExisting() = %v, %v, want false with no error for a stale socket", sent, err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("stale socket should be removed, stat error = %v", err)
+	}
+}
+
+func TestTrySendToExistingRejected(t *testing.T) {
+	useTempSocketDir(t)
+	socketPath := getSidebarSocketPath()
+	app := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, "")
+	app.config.IPCToken = "s3cret"
+	startTestServer(t, app, socketPath)
+
+	cmd := IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "b.html", Content: "<p>b</p>"}, Token: "wrong"}
+	sent, err := TrySendToExisting(socketPath, cmd)
+	if sent || err == nil || !strings.Contains(err.Error(), "token") {
+		t.Fatalf("TrySendToExisting() = %v, %v, want the window's rejection as an error", sent, err)
+	}
+	if _, err := os.Stat(socketPath); err != nil {
+		t.Errorf("the live window's socket was removed: %v", err)
+	}
+	if len(app.GetFiles()) != 1 {
+		t.Errorf("the rejected file was added: %+v", app.GetFiles())
 	}
 }
 
@@ -226,7 +326,7 @@ func TestIPCServerReplace(t *testing.T) {
 
 	// Verify content was replaced
 	content := app.GetHTMLContent()
-	if content != "<html>replaced</html>" {
+	if content != `<html><head><meta charset="utf-8"></head>replaced</html>` {
 		t.Errorf("Content not replaced: got %q", content)
 	}
 }
@@ -256,9 +356,142 @@ func TestTrySendToSidebarInstance(t *testing.T) {
 	socketPath := getSidebarSocketPath()
 	os.Remove(socketPath)
 
-	result := TrySendToSidebarInstance(entry)
-	if result {
-		t.Error("TrySendToSidebarInstance() should return false when no server is running")
+	result, err := TrySendToSidebarInstance(entry, false, 0, "")
+	if result || err != nil {
+		t.Errorf("TrySendToSidebarInstance() = %v, %v, want false with no error when no server is running", result, err)
+	}
+}
+
+func TestSidebarReplaceOrAddUpserts(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial.html", Path: "/tmp/initial.html", Content: "<p>initial</p>"}, "")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-upsert.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, true)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	// The first send adds the file, repeats replace it in place
+	for _, content := range []string{"<p>v1</p>", "<p>v2</p>", "<p>v3</p>"} {
+		entry := FileEntry{Name: "report.html", Path: "/tmp/report.html", Content: content}
+		resp, err := SendCommand(socketPath, sidebarCommand(entry, true, ""))
+		if err != nil || !resp.OK {
+			t.Fatalf("SendCommand() = %+v, %v", resp, err)
+		}
+	}
+
+	files := app.GetFiles()
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files after repeated upserts, got %d", len(files))
+	}
+	for _, f := range files {
+		if f.Path == "/tmp/report.html" && f.Content != "<p>v3</p>" {
+			t.Errorf("upserted content = %q, want the last send", f.Content)
+		}
+	}
+	if app.GetCurrentFilePath() != "/tmp/report.html" {
+		t.Errorf("current file = %q, want the upserted file selected", app.GetCurrentFilePath())
+	}
+}
+
+func TestSidebarPipeNameReplacesByName(t *testing.T) {
+	oldPipeName := pipeName
+	pipeName = "foo"
+	t.Cleanup(func() { pipeName = oldPipeName })
+	app := NewApp(FileEntry{Name: "initial.html", Path: "/tmp/initial.html", Content: "<p>initial</p>"}, "")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-pipe-name.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, true)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	// Repeated --pipe-name foo sends, plus one from a different pipe
+	sends := []FileEntry{
+		{Name: "foo", Content: "<p>foo 1</p>"},
+		{Name: "foo", Content: "<p>foo 2</p>"},
+		{Name: "bar", Content: "<p>bar</p>"},
+		{Name: "foo", Content: "<p>foo 3</p>"},
+	}
+	for _, entry := range sends {
+		resp, err := SendCommand(socketPath, sidebarCommand(entry, true, ""))
+		if err != nil || !resp.OK {
+			t.Fatalf("SendCommand() = %+v, %v", resp, err)
+		}
+	}
+
+	files := app.GetFiles()
+	if len(files) != 3 {
+		t.Fatalf("Expected initial, bar, and foo, got %d files: %+v", len(files), files)
+	}
+	for _, f := range files {
+		if f.Name == "foo" && f.Content != "<p>foo 3</p>" {
+			t.Errorf("foo content = %q, want the latest send", f.Content)
+		}
+		if f.Name == "bar" && f.Content != "<p>bar</p>" {
+			t.Errorf("bar content = %q, should be untouched by foo sends", f.Content)
+		}
+	}
+	if app.GetCurrentFileName() != "foo" {
+		t.Errorf("current file = %q, want foo selected", app.GetCurrentFileName())
+	}
+}
+
+func TestWindowReplaceOfPipedContent(t *testing.T) {
+	useTempSocketDir(t)
+	windowID := "piped-replace-window"
+	app := NewApp(FileEntry{Name: "stdin", Content: "<p>first</p>"}, windowID)
+	app.AddFile(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"})
+	socketPath := getWindowSocketPath(windowID)
+	startTestServer(t, app, socketPath)
+
+	// An --id pipe replaces the window's piped entry whatever it's named,
+	// even with --pipe-name
+	for _, name := range []string{"stdin", "status"} {
+		entry := FileEntry{Name: name, Content: "<p>" + name + "</p>"}
+		if err := sendCommandOK(socketPath, windowCommand(entry, "", 0, false, "")); err != nil {
+			t.Fatalf("replace as %s failed: %v", name, err)
+		}
+	}
+
+	files := app.GetFiles()
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want the piped entry replaced in place: %+v", len(files), files)
+	}
+	if got := app.GetCurrentFileName(); got != "status" || app.GetHTMLContent() != "<p>status</p>" {
+		t.Errorf("current file = %q with %q, want the piped entry renamed to status", got, app.GetHTMLContent())
+	}
+}
+
+func TestSidebarCommand(t *testing.T) {
+	entry := FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>", BasePath: "/tmp/assets"}
+
+	if cmd := sidebarCommand(entry, false, ""); cmd.Cmd != "add-file" || cmd.Entry.Path != entry.Path {
+		t.Errorf("sidebarCommand(upsert=false) = %+v, want add-file carrying the entry", cmd)
+	}
+	cmd := sidebarCommand(entry, true, "")
+	if cmd.Cmd != "replace" || cmd.Path != entry.Path || cmd.Content != entry.Content || cmd.BasePath != entry.BasePath {
+		t.Errorf("sidebarCommand(upsert=true) = %+v, want replace carrying the entry's fields", cmd)
+	}
+
+	// Piped content has no path to upsert by unless it has a --pipe-name
+	piped := FileEntry{Name: "stdin", Content: "<p>piped</p>"}
+	if cmd := sidebarCommand(piped, true, ""); cmd.Cmd != "add-file" || cmd.Entry.Content != piped.Content {
+		t.Errorf("sidebarCommand(piped, upsert=true) = %+v, want add-file", cmd)
+	}
+	oldPipeName := pipeName
+	pipeName = "status"
+	t.Cleanup(func() { pipeName = oldPipeName })
+	if cmd := sidebarCommand(FileEntry{Name: "status", Content: "<p>piped</p>"}, true, ""); cmd.Cmd != "replace" || !cmd.MatchName {
+		t.Errorf("sidebarCommand(--pipe-name, upsert=true) = %+v, want replace matched by name", cmd)
 	}
 }
 
@@ -270,9 +503,9 @@ func TestTrySendToWindowInstance(t *testing.T) {
 	socketPath := getWindowSocketPath(windowID)
 	os.Remove(socketPath)
 
-	result := TrySendToWindowInstance(windowID, entry)
-	if result {
-		t.Error("TrySendToWindowInstance() should return false when no server is running")
+	result, err := TrySendToWindowInstance(windowID, entry, "", 0, false, "")
+	if result || err != nil {
+		t.Errorf("TrySendToWindowInstance() = %v, %v, want false with no error when no server is running", result, err)
 	}
 }
 
@@ -475,3 +708,1282 @@ func TestSidebarTimeoutReset(t *testing.T) {
 		server.Close()
 	}
 }
+
+func TestIPCServerSetFiles(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html>initial</html>"}, "")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-setfiles.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	cmd := IPCCommand{
+		Cmd: "set-files",
+		Files: []FileEntry{
+			{Name: "one", Path: "/tmp/one.html", Content: "<html>1</html>"},
+			{Name: "two", Path: "/tmp/two.html", Content: "<html>2</html>"},
+		},
+		Select: "two",
+	}
+	if sent, err := TrySendToExisting(socketPath, cmd); !sent || err != nil {
+		t.Fatalf("Failed to send set-files command: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	files := app.GetFiles()
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files after set-files, got %d", len(files))
+	}
+	if files[0].Name != "one" || files[1].Name != "two" {
+		t.Errorf("Unexpected files after set-files: %+v", files)
+	}
+	if got := app.GetCurrentIndex(); got != 1 {
+		t.Errorf("Expected set-files to select two (index 1), got %d", got)
+	}
+}
+
+func TestIPCServerResponse(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html>initial</html>"}, "")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-response.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	resp, err := SendCommand(socketPath, IPCCommand{
+		Cmd:   "add-file",
+		Entry: FileEntry{Name: "new", Path: "/tmp/new.html", Content: "<html>new</html>"},
+	})
+	if err != nil {
+		t.Fatalf("SendCommand() failed: %v", err)
+	}
+	if !resp.OK {
+		t.Errorf("Expected OK response, got %+v", resp)
+	}
+
+	resp, err = SendCommand(socketPath, IPCCommand{Cmd: "bogus"})
+	if err != nil {
+		t.Fatalf("SendCommand() failed: %v", err)
+	}
+	if resp.OK || resp.Error == "" {
+		t.Errorf("Expected error response for unknown command, got %+v", resp)
+	}
+}
+
+func TestIPCServerToken(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html>initial</html>"}, "")
+	app.config.IPCToken = "s3cret"
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-token.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		expectOK bool
+	}{
+		{"matching token accepted", "s3cret", true},
+		{"mismatched token rejected", "wrong", false},
+		{"absent token rejected", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(app.GetFiles())
+			resp, err := SendCommand(socketPath, IPCCommand{
+				Cmd:   "add-file",
+				Entry: FileEntry{Name: tt.name, Content: "<html></html>"},
+				Token: tt.token,
+			})
+			if err != nil {
+				t.Fatalf("SendCommand() failed: %v", err)
+			}
+			if resp.OK != tt.expectOK {
+				t.Errorf("Expected OK=%v, got %+v", tt.expectOK, resp)
+			}
+
+			added := len(app.GetFiles()) - before
+			if tt.expectOK && added != 1 {
+				t.Errorf("Expected file to be added, got %d new files", added)
+			}
+			if !tt.expectOK && added != 0 {
+				t.Errorf("Rejected command should not add files, got %d new files", added)
+			}
+		})
+	}
+}
+
+func TestIPCServerNoTokenConfigured(t *testing.T) {
+	server := &IPCServer{app: NewApp(FileEntry{Name: "initial"}, "")}
+	if !server.validToken("anything") || !server.validToken("") {
+		t.Error("Any token should be accepted when ipc_token is not configured")
+	}
+}
+
+func TestIPCServerTokenFollowsReloadedConfig(t *testing.T) {
+	configDir := filepath.Join(useTempConfigDir(t), "fenestro")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Could not create config dir: %v", err)
+	}
+	app := NewApp(FileEntry{Name: "initial"}, "")
+	server := &IPCServer{app: app}
+	if !server.validToken("") {
+		t.Fatal("commands should be accepted before a token is configured")
+	}
+
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(`ipc_token = "s3cret"`), 0644); err != nil {
+		t.Fatalf("Could not write config: %v", err)
+	}
+	app.ReloadConfig()
+	if server.validToken("") || server.validToken("old") {
+		t.Error("after reloading, commands without the new token should be rejected")
+	}
+	if !server.validToken("s3cret") {
+		t.Error("after reloading, the new token should be accepted")
+	}
+}
+
+func TestIPCServerReplaceScrollTo(t *testing.T) {
+	app := NewApp(FileEntry{Name: "test", Path: "/tmp/test.html", Content: "<html>original</html>"}, "")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-scrollto.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	resp, err := SendCommand(socketPath, IPCCommand{
+		Cmd:      "replace",
+		Path:     "/tmp/test.html",
+		Content:  "<html>replaced</html>",
+		ScrollTo: "section2",
+	})
+	if err != nil || !resp.OK {
+		t.Fatalf("SendCommand() failed: %v %+v", err, resp)
+	}
+
+	if got := app.ConsumePendingAnchor(); got != "section2" {
+		t.Errorf("Expected pending anchor %q, got %q", "section2", got)
+	}
+}
+
+func TestIPCServerLine(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.log", Path: "/tmp/a.log", Content: "<pre>a</pre>"}, "")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-line.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	if err := sendCommandOK(socketPath, windowCommand(FileEntry{Name: "a.log", Path: "/tmp/a.log", Content: "<pre>a\nb</pre>"}, "", 2, false, "")); err != nil {
+		t.Fatalf("replace failed: %v", err)
+	}
+	if got := app.ConsumePendingLine(); got != 2 {
+		t.Errorf("pending line after replace = %d, want 2", got)
+	}
+
+	// An add with a line shows the added file so the line can be revealed
+	if err := sendCommandOK(socketPath, windowCommand(FileEntry{Name: "b.log", Path: "/tmp/b.log", Content: "<pre>b</pre>"}, "", 7, true, "")); err != nil {
+		t.Fatalf("add-file failed: %v", err)
+	}
+	if got := app.GetCurrentIndex(); got != 1 {
+		t.Errorf("current index after add-file with a line = %d, want 1 (the added file)", got)
+	}
+	if got := app.ConsumePendingLine(); got != 7 {
+		t.Errorf("pending line after add-file = %d, want 7", got)
+	}
+
+	// A rejected conditional replace leaves no line behind
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "replace", Path: "/tmp/a.log", Content: "<pre>c</pre>", Line: 3, ExpectedHash: contentHash("stale")})
+	if err != nil || resp.OK {
+		t.Fatalf("conditional replace = %+v, %v, want a conflict", resp, err)
+	}
+	if got := app.ConsumePendingLine(); got != 0 {
+		t.Errorf("pending line after a conflict = %d, want 0", got)
+	}
+}
+
+func TestTrySendToSidebarInstanceLine(t *testing.T) {
+	useTempSocketDir(t)
+	app := NewApp(FileEntry{Name: "a.log", Path: "/tmp/a.log", Content: "<pre>a</pre>"}, "")
+	startTestServer(t, app, getSidebarSocketPath())
+
+	if sent, err := TrySendToSidebarInstance(FileEntry{Name: "b.log", Path: "/tmp/b.log", Content: "<pre>b</pre>"}, false, 5, ""); !sent || err != nil {
+		t.Fatalf("TrySendToSidebarInstance() = %v, %v, want the running sidebar to take the file", sent, err)
+	}
+
+	if got := app.GetCurrentIndex(); got != 1 {
+		t.Errorf("current index = %d, want 1 (the file sent with a line)", got)
+	}
+	if got := app.ConsumePendingLine(); got != 5 {
+		t.Errorf("pending line = %d, want 5", got)
+	}
+}
+
+func TestIPCServerAllowedExtensions(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html>initial</html>"}, "")
+	app.config.AllowedExtensions = []string{".html", ".htm", ".md"}
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-allowed-ext.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		cmd      IPCCommand
+		expectOK bool
+	}{
+		{"allowed add-file", IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "a", Path: "/tmp/a.html"}}, true},
+		{"denied add-file", IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "b", Path: "/tmp/b.exe"}}, false},
+		{"allowed replace", IPCCommand{Cmd: "replace", Path: "/tmp/c.md", Name: "c"}, true},
+		{"denied replace", IPCCommand{Cmd: "replace", Path: "/tmp/d.json", Name: "d"}, false},
+		{"stdin add-file allowed", IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "stdin"}}, true},
+		{"denied file in set-files", IPCCommand{Cmd: "set-files", Files: []FileEntry{
+			{Name: "ok", Path: "/tmp/ok.html"},
+			{Name: "bad", Path: "/tmp/bad.sh"},
+		}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(app.GetFiles())
+			resp, err := SendCommand(socketPath, tt.cmd)
+			if err != nil {
+				t.Fatalf("SendCommand() failed: %v", err)
+			}
+			if resp.OK != tt.expectOK {
+				t.Errorf("Expected OK=%v, got %+v", tt.expectOK, resp)
+			}
+			if !tt.expectOK {
+				if resp.Error == "" {
+					t.Error("Rejected command should include an error message")
+				}
+				if got := len(app.GetFiles()); got != before {
+					t.Errorf("Rejected command changed file count from %d to %d", before, got)
+				}
+			}
+		})
+	}
+}
+
+// TestIPCServerStreamingConnection sends many commands over one connection
+func TestIPCServerStreamingConnection(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html>initial</html>"}, "")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-streaming.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	conn, err := net.DialTimeout("unix", socketPath, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to connect to socket: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(conn)
+	commandCount := 20
+	for i := 0; i < commandCount; i++ {
+		cmd := IPCCommand{
+			Cmd: "add-file",
+			Entry: FileEntry{
+				Name:    "stream-" + string(rune('A'+i)),
+				Path:    "/tmp/stream-" + string(rune('A'+i)) + ".html",
+				Content: "<html>streamed</html>",
+			},
+		}
+		if err := encoder.Encode(cmd); err != nil {
+			t.Fatalf("Failed to send command %d: %v", i, err)
+		}
+
+		var resp IPCResponse
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("Failed to read response %d: %v", i, err)
+		}
+		if !resp.OK {
+			t.Errorf("Command %d failed: %+v", i, resp)
+		}
+	}
+
+	files := app.GetFiles()
+	if len(files) != 1+commandCount {
+		t.Errorf("Expected %d files, got %d", 1+commandCount, len(files))
+	}
+}
+
+func TestDecodeIPCMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantCmd string
+		wantID  string
+		wantErr bool
+	}{
+		{"flat", `{"cmd":"replace","path":"/tmp/a.html","content":"<p>a</p>"}`, "replace", "", false},
+		{"envelope with number id", `{"id":7,"cmd":"replace","params":{"path":"/tmp/a.html","content":"<p>a</p>"}}`, "replace", "7", false},
+		{"envelope with string id", `{"id":"req-1","cmd":"ping"}`, "ping", `"req-1"`, false},
+		{"envelope with bad params", `{"id":1,"cmd":"replace","params":{"path":5}}`, "", "1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, id, err := decodeIPCMessage(json.RawMessage(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeIPCMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(id) != tt.wantID {
+				t.Errorf("id = %s, want %s", id, tt.wantID)
+			}
+			if tt.wantErr {
+				return
+			}
+			if cmd.Cmd != tt.wantCmd {
+				t.Errorf("cmd = %q, want %q", cmd.Cmd, tt.wantCmd)
+			}
+			if tt.wantCmd == "replace" && (cmd.Path != "/tmp/a.html" || cmd.Content != "<p>a</p>") {
+				t.Errorf("params not decoded: %+v", cmd)
+			}
+		})
+	}
+}
+
+func TestEnvelopeResponseRoundTrip(t *testing.T) {
+	env := envelopeResponse(json.RawMessage(`"req-1"`), IPCResponse{OK: true, WindowID: "abc"})
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"id":"req-1","ok":true,"result":{"window_id":"abc"}}`
+	if string(data) != want {
+		t.Errorf("envelope = %s, want %s", data, want)
+	}
+
+	var decoded IPCEnvelopeResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if string(decoded.ID) != `"req-1"` || !decoded.OK || decoded.Result == nil || decoded.Result.WindowID != "abc" {
+		t.Errorf("decoded envelope = %+v", decoded)
+	}
+
+	// Commands without a result omit it
+	data, _ = json.Marshal(envelopeResponse(json.RawMessage(`2`), IPCResponse{OK: false, Error: "boom"}))
+	if string(data) != `{"id":2,"ok":false,"error":"boom"}` {
+		t.Errorf("error envelope = %s", data)
+	}
+}
+
+// TestIPCServerEnvelopeCorrelation mixes envelope and flat commands on one
+// connection and matches each reply to its request by id
+func TestIPCServerEnvelopeCorrelation(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html>initial</html>"}, "envelope-window")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-envelope.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	conn, err := net.DialTimeout("unix", socketPath, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to connect to socket: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	requests := []string{
+		`{"id":"a","cmd":"replace","params":{"path":"/tmp/a.html","name":"a.html","content":"<p>a</p>"}}`,
+		`{"id":2,"cmd":"ping"}`,
+		`{"id":"bad","cmd":"nope"}`,
+		`{"id":"b","cmd":"add-file","params":{"entry":{"name":"b.html","path":"/tmp/b.html","content":"<p>b</p>"}}}`,
+	}
+	for _, req := range requests {
+		if _, err := conn.Write([]byte(req + "\n")); err != nil {
+			t.Fatalf("Failed to send %s: %v", req, err)
+		}
+	}
+
+	decoder := json.NewDecoder(conn)
+	replies := make(map[string]IPCEnvelopeResponse)
+	for range requests {
+		var resp IPCEnvelopeResponse
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		replies[string(resp.ID)] = resp
+	}
+
+	if r := replies[`"a"`]; !r.OK {
+		t.Errorf("replace reply = %+v, want OK", r)
+	}
+	if r := replies[`2`]; !r.OK || r.Result == nil || r.Result.WindowID != "envelope-window" {
+		t.Errorf("ping reply = %+v, want OK with the window ID", r)
+	}
+	if r := replies[`"bad"`]; r.OK || !strings.Contains(r.Error, "unknown command") {
+		t.Errorf("unknown command reply = %+v, want an error", r)
+	}
+	if r := replies[`"b"`]; !r.OK {
+		t.Errorf("add-file reply = %+v, want OK", r)
+	}
+
+	// The flat format still works on the same connection
+	if err := json.NewEncoder(conn).Encode(IPCCommand{Cmd: "ping"}); err != nil {
+		t.Fatalf("Failed to send flat command: %v", err)
+	}
+	var flat IPCResponse
+	if err := decoder.Decode(&flat); err != nil {
+		t.Fatalf("Failed to read flat response: %v", err)
+	}
+	if !flat.OK || flat.WindowID != "envelope-window" {
+		t.Errorf("flat ping reply = %+v", flat)
+	}
+
+	if len(app.GetFiles()) != 3 {
+		t.Errorf("Expected 3 files, got %d", len(app.GetFiles()))
+	}
+}
+
+// TestIPCServerSimultaneousDials checks that a burst of senders dialing at
+// once, like a diff tool fanning out CLI invocations, all connect
+func TestIPCServerSimultaneousDials(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial"}, "burst-window")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-burst.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	const dialers = 200
+	start := make(chan struct{})
+	errs := make(chan error, dialers)
+	var wg sync.WaitGroup
+	for i := 0; i < dialers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			conn, err := net.DialTimeout("unix", socketPath, 2*time.Second)
+			if err != nil {
+				errs <- err
+				return
+			}
+			conn.Close()
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	failed := 0
+	for err := range errs {
+		if failed == 0 {
+			t.Logf("first dial error: %v", err)
+		}
+		failed++
+	}
+	if failed > 0 {
+		t.Errorf("%d of %d simultaneous dials failed", failed, dialers)
+	}
+}
+
+func TestNewIPCServerWorkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  int
+		expected int
+	}{
+		{"configured", 3, 3},
+		{"unset uses default", 0, DefaultIPCWorkers},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApp(FileEntry{Name: "initial"}, "")
+			app.config.IPCWorkers = tt.workers
+
+			socketPath := filepath.Join(os.TempDir(), "fenestro-test-workers.sock")
+			server, err := NewIPCServer(app, socketPath, false)
+			if err != nil {
+				t.Fatalf("NewIPCServer() failed: %v", err)
+			}
+			defer server.Close()
+
+			if server.workers != tt.expected {
+				t.Errorf("workers = %d, expected %d", server.workers, tt.expected)
+			}
+		})
+	}
+}
+
+// TestWorkerPoolStress floods a small worker pool with concurrent connections
+// and checks every command is applied exactly once
+func TestWorkerPoolStress(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html>initial</html>"}, "")
+	app.config.IPCWorkers = 2
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-pool.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	fileCount := 200
+	var wg sync.WaitGroup
+	errs := make(chan error, fileCount)
+	for i := 0; i < fileCount; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			resp, err := SendCommand(socketPath, IPCCommand{
+				Cmd: "add-file",
+				Entry: FileEntry{
+					Name:    fmt.Sprintf("pool-%03d.html", idx),
+					Path:    fmt.Sprintf("/tmp/pool-%03d.html", idx),
+					Content: "<html>pooled</html>",
+				},
+			})
+			if err != nil {
+				errs <- err
+			} else if !resp.OK {
+				errs <- fmt.Errorf("command %d failed: %s", idx, resp.Error)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	seen := make(map[string]int)
+	for _, f := range app.GetFiles() {
+		seen[f.Path]++
+	}
+	for i := 0; i < fileCount; i++ {
+		path := fmt.Sprintf("/tmp/pool-%03d.html", i)
+		if seen[path] != 1 {
+			t.Errorf("%s added %d times, expected once", path, seen[path])
+		}
+	}
+}
+
+// TestWorkerPoolIdleConnections checks that connections waiting for their
+// next command don't hold workers: with more idle senders than workers, a
+// new sender is still answered right away
+func TestWorkerPoolIdleConnections(t *testing.T) {
+	useTempSocketDir(t)
+	app := NewApp(FileEntry{Name: "initial"}, "")
+	app.config.IPCWorkers = 2
+	socketPath := getSidebarSocketPath()
+	startTestServer(t, app, socketPath)
+
+	for i := 0; i < app.config.IPCWorkers+1; i++ {
+		conn, err := net.Dial("unix", socketPath)
+		if err != nil {
+			t.Fatalf("Dial() failed: %v", err)
+		}
+		defer conn.Close()
+		// Half of them stream: one command answered, then nothing more
+		if i%2 == 0 {
+			continue
+		}
+		if err := json.NewEncoder(conn).Encode(IPCCommand{Cmd: "ping"}); err != nil {
+			t.Fatalf("Encode() failed: %v", err)
+		}
+		var resp IPCResponse
+		if err := json.NewDecoder(conn).Decode(&resp); err != nil || !resp.OK {
+			t.Fatalf("streaming ping = %+v, %v", resp, err)
+		}
+	}
+
+	start := time.Now()
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "ping"})
+	if err != nil || !resp.OK {
+		t.Fatalf("SendCommand() = %+v, %v, want idle connections to leave a worker free", resp, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ping took %v behind idle connections", elapsed)
+	}
+}
+
+// BenchmarkIPCConnections compares the worker pool against a goroutine per
+// connection, with many senders connecting concurrently
+func BenchmarkIPCConnections(b *testing.B) {
+	for _, bm := range []struct {
+		name    string
+		workers int
+	}{
+		{"pool", DefaultIPCWorkers},
+		{"unbounded", 0},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			app := NewApp(FileEntry{Name: "bench.html", Path: "/tmp/bench.html"}, "")
+
+			socketPath := filepath.Join(os.TempDir(), "fenestro-bench-"+bm.name+".sock")
+			server, err := NewIPCServer(app, socketPath, false)
+			if err != nil {
+				b.Fatalf("NewIPCServer() failed: %v", err)
+			}
+			server.workers = bm.workers
+			server.Start()
+			defer server.Close()
+
+			// Replace the same file so the list doesn't grow across iterations
+			cmd := IPCCommand{Cmd: "replace", Path: "/tmp/bench.html", Content: "<html>bench</html>"}
+
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := SendCommand(socketPath, cmd); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestIPCServerPing(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial"}, "ping-window-id")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-ping.sock")
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "ping"})
+	if err != nil {
+		t.Fatalf("SendCommand() failed: %v", err)
+	}
+	if !resp.OK || resp.WindowID != "ping-window-id" {
+		t.Errorf("ping response = %+v, expected OK with window ID ping-window-id", resp)
+	}
+}
+
+func TestTrySendToWindowInstanceIdentity(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverID     string
+		expectSent   bool
+		expectSocket bool
+	}{
+		{"matching identity", "identity-test-window", true, true},
+		// A live server keeps its socket even when it isn't the window asked for
+		{"mismatched identity", "some-other-window", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windowID := "identity-test-window"
+			socketPath := getWindowSocketPath(windowID)
+
+			app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>old</p>"}, tt.serverID)
+			server, err := NewIPCServer(app, socketPath, false)
+			if err != nil {
+				t.Fatalf("NewIPCServer() failed: %v", err)
+			}
+			server.Start()
+			defer server.Close()
+
+			entry := FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>new</p>"}
+			got, err := TrySendToWindowInstance(windowID, entry, "", 0, false, "")
+			if got != tt.expectSent {
+				t.Fatalf("TrySendToWindowInstance() = %v, expected %v", got, tt.expectSent)
+			}
+			if wantErr := !tt.expectSent; (err != nil) != wantErr {
+				t.Errorf("TrySendToWindowInstance() error = %v, want error %v", err, wantErr)
+			}
+
+			wantContent := "<p>old</p>"
+			if tt.expectSent {
+				wantContent = "<p>new</p>"
+			}
+			if got := app.GetHTMLContent(); got != wantContent {
+				t.Errorf("content = %q, expected %q", got, wantContent)
+			}
+
+			_, err = os.Stat(socketPath)
+			if exists := err == nil; exists != tt.expectSocket {
+				t.Errorf("socket exists = %v, expected %v", exists, tt.expectSocket)
+			}
+		})
+	}
+}
+
+func TestTrySendToWindowInstanceRejectedPing(t *testing.T) {
+	useTempSocketDir(t)
+
+	windowID := "rejected-ping-window"
+	socketPath := getWindowSocketPath(windowID)
+	app := NewApp(FileEntry{Name: "a.html", Content: "<p>old</p>"}, windowID)
+	app.config.IPCToken = "s3cret"
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	sent, err := TrySendToWindowInstance(windowID, FileEntry{Name: "a.html", Content: "<p>new</p>"}, "", 0, false, "wrong")
+	if sent || err == nil {
+		t.Fatalf("TrySendToWindowInstance() = %v, %v, want an error when the window rejects the ping", sent, err)
+	}
+	if _, err := os.Stat(socketPath); err != nil {
+		t.Errorf("the live window's socket was removed: %v", err)
+	}
+	if got := app.GetHTMLContent(); got != "<p>old</p>" {
+		t.Errorf("content = %q, want it unchanged", got)
+	}
+}
+
+func TestCheckWindowSocketRemovesStale(t *testing.T) {
+	useTempSocketDir(t)
+	windowID := "stale-window"
+	socketPath := getWindowSocketPath(windowID)
+	if err := ensureSocketDir(); err != nil {
+		t.Fatalf("ensureSocketDir() failed: %v", err)
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Could not create socket: %v", err)
+	}
+	// Closing a unix listener unlinks its file; keep it to leave a stale socket
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	if found, err := checkWindowSocket(socketPath, windowID, ""); found || err != nil {
+		t.Fatalf("checkWindowSocket() = %v, %v, want false with no error for a stale socket", found, err)
+	}
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("stale socket should be removed, stat error = %v", err)
+	}
+}
+
+func TestCleanStaleSockets(t *testing.T) {
+	// Keep the path short; Unix socket paths are length-limited
+	dir, err := os.MkdirTemp("", "fenestro-gc")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	live := filepath.Join(dir, "live.sock")
+	listener, err := net.Listen("unix", live)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	// Dead sockets: the listener is gone but the socket file remains
+	var dead []string
+	for _, name := range []string{"dead1.sock", "dead2.sock"} {
+		path := filepath.Join(dir, name)
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		l.(*net.UnixListener).SetUnlinkOnClose(false)
+		l.Close()
+		dead = append(dead, path)
+	}
+
+	other := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(other, []byte("not a socket"), 0644); err != nil {
+		t.Fatalf("Could not write file: %v", err)
+	}
+
+	removed, err := CleanStaleSockets(dir)
+	if err != nil {
+		t.Fatalf("CleanStaleSockets() error = %v", err)
+	}
+	if removed != len(dead) {
+		t.Errorf("removed = %d, expected %d", removed, len(dead))
+	}
+	for _, path := range dead {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Dead socket %s should have been removed", path)
+		}
+	}
+	for _, path := range []string{live, other} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should not have been removed: %v", path, err)
+		}
+	}
+}
+
+func TestCleanStaleSocketsMissingDir(t *testing.T) {
+	removed, err := CleanStaleSockets(filepath.Join(os.TempDir(), "fenestro-gc-missing"))
+	if err != nil || removed != 0 {
+		t.Errorf("CleanStaleSockets() = %d, %v; expected 0, nil for a missing directory", removed, err)
+	}
+}
+
+func TestCheckSocketOwnership(t *testing.T) {
+	dir, err := os.MkdirTemp("", "fenestro-own")
+	if err != nil {
+		t.Fatalf("Could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	absent := filepath.Join(dir, "absent.sock")
+	if got := checkSocketOwnership(absent); got != socketAbsent {
+		t.Errorf("checkSocketOwnership(absent) = %v, expected socketAbsent", got)
+	}
+
+	live := filepath.Join(dir, "live.sock")
+	listener, err := net.Listen("unix", live)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	if got := checkSocketOwnership(live); got != socketLive {
+		t.Errorf("checkSocketOwnership(live) = %v, expected socketLive", got)
+	}
+
+	stale := filepath.Join(dir, "stale.sock")
+	l, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	if got := checkSocketOwnership(stale); got != socketStale {
+		t.Errorf("checkSocketOwnership(stale) = %v, expected socketStale", got)
+	}
+}
+
+func TestNewIPCServerReplacesStaleSocket(t *testing.T) {
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-stale-owner.sock")
+	os.Remove(socketPath)
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	app := NewApp(FileEntry{Name: "initial"}, "")
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() should take over a stale socket, got error: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	if got := checkSocketOwnership(socketPath); got != socketLive {
+		t.Errorf("socket should be live after binding, got %v", got)
+	}
+}
+
+func TestNewIPCServerRefusesLiveSocket(t *testing.T) {
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-live-owner.sock")
+	os.Remove(socketPath)
+
+	owner, err := NewIPCServer(NewApp(FileEntry{Name: "owner"}, ""), socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	owner.Start()
+	defer owner.Close()
+
+	_, err = NewIPCServer(NewApp(FileEntry{Name: "second"}, ""), socketPath, false)
+	if !errors.Is(err, errSocketInUse) {
+		t.Fatalf("NewIPCServer() error = %v, expected errSocketInUse", err)
+	}
+
+	// The original owner keeps working
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "ping"})
+	if err != nil || !resp.OK {
+		t.Errorf("owner should still respond, got %+v, %v", resp, err)
+	}
+}
+
+func TestIPCServerRejectsOversizedMessage(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html>initial</html>"}, "")
+	app.config.MaxIPCBytes = 1024
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-oversized.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	// The server stops reading at the cap, so the write may not complete
+	big := IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "big", Content: strings.Repeat("x", 64*1024)}}
+	go json.NewEncoder(conn).Encode(big)
+
+	var resp IPCResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		t.Fatalf("expected an error response, got %v", err)
+	}
+	if resp.OK || !strings.Contains(resp.Error, "max_ipc_bytes") {
+		t.Errorf("response = %+v, want a max_ipc_bytes error", resp)
+	}
+	if len(app.GetFiles()) != 1 {
+		t.Error("oversized message should not be applied")
+	}
+
+	// The server is still alive for well-behaved senders
+	resp, err = SendCommand(socketPath, IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "small", Content: "<p>ok</p>"}})
+	if err != nil {
+		t.Fatalf("SendCommand() after oversized message failed: %v", err)
+	}
+	if !resp.OK {
+		t.Errorf("valid command after oversized message failed: %s", resp.Error)
+	}
+	if len(app.GetFiles()) != 2 {
+		t.Errorf("expected 2 files, got %d", len(app.GetFiles()))
+	}
+}
+
+func TestIPCServerMessageCapIsPerMessage(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<html>initial</html>"}, "")
+	app.config.MaxIPCBytes = 1024
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-permessage.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	// Ten ~500 byte commands add up to more than the cap on one connection
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(conn)
+	for i := 0; i < 10; i++ {
+		cmd := IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: fmt.Sprintf("file-%d", i), Content: strings.Repeat("x", 500)}}
+		if err := encoder.Encode(cmd); err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		var resp IPCResponse
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("command %d: %v", i, err)
+		}
+		if !resp.OK {
+			t.Fatalf("command %d failed: %s", i, resp.Error)
+		}
+	}
+}
+
+func TestWindowCommand(t *testing.T) {
+	entry := FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "<p>b</p>", BasePath: "/srv"}
+
+	replace := windowCommand(entry, "intro", 12, false, "")
+	if replace.Cmd != "replace" || replace.Path != entry.Path || replace.ScrollTo != "intro" || replace.BasePath != "/srv" || replace.Line != 12 {
+		t.Errorf("windowCommand() = %+v, want a replace carrying the entry, anchor and line", replace)
+	}
+
+	add := windowCommand(entry, "intro", 12, true, "")
+	if add.Cmd != "add-file" || add.Entry.Path != entry.Path || add.Entry.BasePath != "/srv" || add.Line != 12 {
+		t.Errorf("windowCommand(add) = %+v, want an add-file carrying the entry and line", add)
+	}
+}
+
+func TestTrySendToWindowInstanceAdd(t *testing.T) {
+	windowID := "add-test-window"
+	socketPath := getWindowSocketPath(windowID)
+
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, windowID)
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	for _, name := range []string{"b.html", "c.html"} {
+		entry := FileEntry{Name: name, Path: "/tmp/" + name, Content: "<p>" + name + "</p>"}
+		if sent, err := TrySendToWindowInstance(windowID, entry, "", 0, true, ""); !sent || err != nil {
+			t.Fatalf("TrySendToWindowInstance(%s, add) = %v, %v", name, sent, err)
+		}
+	}
+
+	files := app.GetFiles()
+	if len(files) != 3 {
+		t.Fatalf("window has %d files, want 3 after two adds", len(files))
+	}
+	if got := app.GetHTMLContent(); got != "<p>a</p>" {
+		t.Errorf("content = %q, want the original file still shown", got)
+	}
+}
+
+func TestIPCServerExists(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "")
+	app.AddFile(FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "<p>b</p>"})
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-exists.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	tests := []struct {
+		path       string
+		wantExists bool
+		wantIndex  int
+	}{
+		{"/tmp/a.html", true, 0},
+		{"/tmp/b.html", true, 1},
+		{"/tmp/c.html", false, -1},
+	}
+
+	for _, tt := range tests {
+		resp, err := SendCommand(socketPath, IPCCommand{Cmd: "exists", Path: tt.path})
+		if err != nil {
+			t.Fatalf("SendCommand() failed: %v", err)
+		}
+		if !resp.OK || resp.Exists == nil || resp.Index == nil {
+			t.Fatalf("exists %s = %+v, want OK with exists and index", tt.path, resp)
+		}
+		if *resp.Exists != tt.wantExists || *resp.Index != tt.wantIndex {
+			t.Errorf("exists %s = (%v, %d), want (%v, %d)", tt.path, *resp.Exists, *resp.Index, tt.wantExists, tt.wantIndex)
+		}
+	}
+
+	if len(app.GetFiles()) != 2 {
+		t.Error("exists should not change the file list")
+	}
+}
+
+func TestIPCServerExistsIgnoresAllowedExtensions(t *testing.T) {
+	useTempSocketDir(t)
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "")
+	app.config.AllowedExtensions = []string{".md"}
+	socketPath := getSidebarSocketPath()
+	startTestServer(t, app, socketPath)
+
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "exists", Path: "/tmp/a.html"})
+	if err != nil {
+		t.Fatalf("SendCommand() failed: %v", err)
+	}
+	if !resp.OK || resp.Exists == nil || !*resp.Exists {
+		t.Errorf("exists /tmp/a.html = %+v, want it found even though allowed_extensions omits .html", resp)
+	}
+	if errs := app.GetLoadErrors(); len(errs) != 0 {
+		t.Errorf("exists recorded load errors: %+v", errs)
+	}
+}
+
+func TestExistsResponseJSON(t *testing.T) {
+	// Index 0 and exists=false must still be sent
+	exists, index := false, 0
+	data, _ := json.Marshal(IPCResponse{OK: true, Exists: &exists, Index: &index})
+	if want := `{"ok":true,"exists":false,"index":0}`; string(data) != want {
+		t.Errorf("exists response = %s, want %s", data, want)
+	}
+
+	// Other responses leave the fields out
+	data, _ = json.Marshal(IPCResponse{OK: true})
+	if want := `{"ok":true}`; string(data) != want {
+		t.Errorf("plain response = %s, want %s", data, want)
+	}
+
+	env := envelopeResponse([]byte("1"), IPCResponse{OK: true, Exists: &exists, Index: &index})
+	if env.Result == nil || env.Result.Exists == nil || *env.Result.Index != 0 {
+		t.Errorf("envelope = %+v, want exists and index in the result", env)
+	}
+}
+
+func TestIPCServerRenameID(t *testing.T) {
+	useTempSocketDir(t)
+	oldID := "123e4567-e89b-12d3-a456-426614174000"
+	newID := "9b2f6c1e-4d3a-4c5b-8e7f-0a1b2c3d4e5f"
+	app := NewApp(FileEntry{Name: "report.html", Content: "<p>r</p>"}, oldID)
+	server, err := NewIPCServer(app, getWindowSocketPath(oldID), false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	resp, err := SendCommand(getWindowSocketPath(oldID), IPCCommand{Cmd: "rename-id", NewID: newID})
+	if err != nil || !resp.OK {
+		t.Fatalf("rename-id = %+v, %v", resp, err)
+	}
+	if resp.WindowID != newID {
+		t.Errorf("response WindowID = %q, want %q", resp.WindowID, newID)
+	}
+
+	if !verifyWindowIdentity(getWindowSocketPath(newID), newID, "") {
+		t.Error("window should answer at the new ID")
+	}
+	if _, err := os.Stat(getWindowSocketPath(oldID)); !os.IsNotExist(err) {
+		t.Error("the old socket should be removed")
+	}
+	if _, err := SendCommand(getWindowSocketPath(oldID), IPCCommand{Cmd: "ping"}); err == nil {
+		t.Error("window should be unreachable at the old ID")
+	}
+	if got := app.GetWindowID(); got != newID {
+		t.Errorf("GetWindowID() = %q, want %q", got, newID)
+	}
+
+	// Files still arrive at the new address, and closing cleans it up
+	if err := sendCommandOK(getWindowSocketPath(newID), IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "b.html", Content: "<p>b</p>"}}); err != nil {
+		t.Fatalf("add-file after rename failed: %v", err)
+	}
+	if len(app.GetFiles()) != 2 {
+		t.Errorf("Expected 2 files after add-file, got %d", len(app.GetFiles()))
+	}
+	server.Close()
+	if _, err := os.Stat(getWindowSocketPath(newID)); !os.IsNotExist(err) {
+		t.Error("closing the server should remove the new socket")
+	}
+}
+
+func TestIPCServerRenameIDRejected(t *testing.T) {
+	useTempSocketDir(t)
+	oldID := "123e4567-e89b-12d3-a456-426614174000"
+	takenID := "9b2f6c1e-4d3a-4c5b-8e7f-0a1b2c3d4e5f"
+	app := NewApp(FileEntry{Name: "report.html", Content: "<p>r</p>"}, oldID)
+	startTestServer(t, app, getWindowSocketPath(oldID))
+	startTestServer(t, NewApp(FileEntry{Name: "other.html", Content: "<p>o</p>"}, takenID), getWindowSocketPath(takenID))
+	sidebar := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, "")
+	startTestServer(t, sidebar, getSidebarSocketPathForInstance(""))
+
+	tests := []struct {
+		name   string
+		socket string
+		newID  string
+	}{
+		{"invalid UUID", getWindowSocketPath(oldID), "not-a-uuid"},
+		{"ID in use", getWindowSocketPath(oldID), takenID},
+		{"sidebar window", getSidebarSocketPathForInstance(""), "5f0c8a2e-1b3d-4e6f-9a7b-c8d9e0f1a2b3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := SendCommand(tt.socket, IPCCommand{Cmd: "rename-id", NewID: tt.newID})
+			if err != nil {
+				t.Fatalf("SendCommand() failed: %v", err)
+			}
+			if resp.OK || resp.Error == "" {
+				t.Errorf("rename-id to %q = %+v, want an error", tt.newID, resp)
+			}
+		})
+	}
+
+	if got := app.GetWindowID(); got != oldID {
+		t.Errorf("GetWindowID() = %q, rejected renames should keep %q", got, oldID)
+	}
+	if !verifyWindowIdentity(getWindowSocketPath(oldID), oldID, "") {
+		t.Error("window should still answer at its old ID")
+	}
+}
+
+func TestGetIPCStatus(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, "")
+	if status := app.GetIPCStatus(); status.Running || status.Address != "" {
+		t.Errorf("GetIPCStatus() without a server = %+v, want not running", status)
+	}
+
+	useTempSocketDir(t)
+	socketPath := getWindowSocketPath("5f0c8a2e-1b3d-4e6f-9a7b-c8d9e0f1a2b3")
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	app.ipcServer = server
+
+	if status := app.GetIPCStatus(); !status.Running || status.Address != socketPath {
+		t.Errorf("GetIPCStatus() = %+v, want running on %s", status, socketPath)
+	}
+
+	server.Close()
+	if status := app.GetIPCStatus(); status.Running {
+		t.Errorf("GetIPCStatus() after Close = %+v, want not running", status)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LoadError describes a non-fatal problem loading content, such as a file
+// in a batch that couldn't be read or a missing chrome_css file
+type LoadError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	// fromConfig marks problems with files named in the config, which are
+	// cleared when it's reloaded (see clearConfigLoadErrors)
+	fromConfig bool
+}
+
+// recordLoadError adds a problem to the window's load errors and sends the
+// updated list to the frontend. A problem already recorded isn't repeated.
+func (a *App) recordLoadError(loadErr LoadError) {
+	a.mu.Lock()
+	for _, existing := range a.loadErrors {
+		if existing == loadErr {
+			a.mu.Unlock()
+			return
+		}
+	}
+	a.loadErrors = append(a.loadErrors, loadErr)
+	errs := append([]LoadError(nil), a.loadErrors...)
+	a.mu.Unlock()
+
+	a.emitEvent("load-errors-changed", errs)
+}
+
+// clearConfigLoadErrors drops the load errors recorded for files named in
+// the config, so a reloaded config that fixes them clears them from the
+// window. The reloaded config records any that are still broken again.
+func (a *App) clearConfigLoadErrors() {
+	a.mu.Lock()
+	kept := a.loadErrors[:0]
+	for _, loadErr := range a.loadErrors {
+		if !loadErr.fromConfig {
+			kept = append(kept, loadErr)
+		}
+	}
+	changed := len(kept) != len(a.loadErrors)
+	a.loadErrors = kept
+	errs := append([]LoadError{}, a.loadErrors...)
+	a.mu.Unlock()
+
+	if changed {
+		a.emitEvent("load-errors-changed", errs)
+	}
+}
+
+// GetLoadErrors returns the non-fatal load problems seen by this window, in
+// the order they happened
+func (a *App) GetLoadErrors() []LoadError {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]LoadError{}, a.loadErrors...)
+}
+
+// loadSnippets reads the header_html and footer_html snippets, recording
+// any that can't be read
+func (a *App) loadSnippets(config Config) (header, footer string) {
+	load := func(path string) string {
+		snippet, err := loadSnippet(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Skipping snippet %s: %v\n", path, err)
+			a.recordLoadError(LoadError{Path: path, Message: err.Error(), fromConfig: true})
+		}
+		return snippet
+	}
+	return load(config.HeaderHTML), load(config.FooterHTML)
+}
+
+// readBatch reads the files at paths for sending to a window together.
+// Each file is converted by its content type, as a file opened on its own
+// is. Files with a disallowed extension are skipped; files that can't be read
+// are left out and reported as load errors rather than failing the batch.
+func readBatch(paths []string, safe bool, config Config) ([]FileEntry, []LoadError) {
+	var files []FileEntry
+	var loadErrs []LoadError
+	for _, path := range paths {
+		if !config.IsExtensionAllowed(path) {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			loadErrs = append(loadErrs, LoadError{Path: path, Message: err.Error()})
+			continue
+		}
+		entry := FileEntry{
+			Name:        filepath.Base(path),
+			Path:        path,
+			Content:     string(content),
+			ContentType: loadContentType(path),
+		}
+		applyContentType(&entry, "", config.NormalizeLineEndings)
+		sanitizeEntry(&entry, false, safe, config)
+		files = append(files, entry)
+	}
+	return files, loadErrs
+}
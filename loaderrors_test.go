@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadBatchSkipsUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "a.html")
+	missing := filepath.Join(dir, "missing.html")
+	if err := os.WriteFile(good, []byte("<p>a</p>"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	files, loadErrs := readBatch([]string{good, missing}, false, DefaultConfig())
+
+	if len(files) != 1 || files[0].Path != good {
+		t.Fatalf("files = %+v, want only %s", files, good)
+	}
+	if len(loadErrs) != 1 || loadErrs[0].Path != missing || loadErrs[0].Message == "" {
+		t.Errorf("loadErrs = %+v, want one error for %s", loadErrs, missing)
+	}
+}
+
+func TestReadBatchConvertsContent(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	if err := os.WriteFile(notes, []byte("# Notes\r\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	config := DefaultConfig()
+	config.NormalizeLineEndings = true
+
+	files, _ := readBatch([]string{notes}, false, config)
+
+	if len(files) != 1 || !strings.Contains(files[0].Content, "<h1") {
+		t.Fatalf("files = %+v, want the Markdown rendered as HTML", files)
+	}
+	if strings.Contains(files[0].Content, "\r") {
+		t.Errorf("content = %q, want line endings normalized", files[0].Content)
+	}
+}
+
+func TestMissingBatchFileRecordsLoadError(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.html")
+	files, loadErrs := readBatch([]string{missing}, false, DefaultConfig())
+	files = append(files, FileEntry{Name: "ok.html", Path: "/tmp/ok.html", Content: "<p>ok</p>"})
+
+	app := NewApp(FileEntry{Name: "initial", Content: "<p>initial</p>"}, "")
+	rec := recordEvents(app)
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-loaderrors.sock")
+	os.Remove(socketPath)
+	startTestServer(t, app, socketPath)
+
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "set-files", Files: files, LoadErrors: loadErrs})
+	if err != nil || !resp.OK {
+		t.Fatalf("SendCommand() = %+v, %v", resp, err)
+	}
+
+	got := app.GetLoadErrors()
+	if len(got) != 1 || got[0].Path != missing {
+		t.Fatalf("GetLoadErrors() = %+v, want the missing batch file", got)
+	}
+	if len(app.GetFiles()) != 1 {
+		t.Errorf("the readable file should still be loaded, got %+v", app.GetFiles())
+	}
+	if len(rec.named("load-errors-changed")) != 1 {
+		t.Error("recording a load error should notify the frontend")
+	}
+}
+
+func TestRejectedIPCFileRecordsLoadError(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<p>initial</p>"}, "")
+	app.config.AllowedExtensions = []string{".html"}
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-loaderrors-denied.sock")
+	os.Remove(socketPath)
+	startTestServer(t, app, socketPath)
+
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "add-file", Entry: FileEntry{Name: "b", Path: "/tmp/b.exe"}})
+	if err != nil || resp.OK {
+		t.Fatalf("SendCommand() = %+v, %v, want the file rejected", resp, err)
+	}
+	if got := app.GetLoadErrors(); len(got) != 1 || got[0].Message != resp.Error {
+		t.Errorf("GetLoadErrors() = %+v, want the rejection %q", got, resp.Error)
+	}
+}
+
+func TestMissingChromeCSSRecordedOnce(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, "")
+	app.config.ChromeCSS = filepath.Join(t.TempDir(), "chrome.css")
+
+	for i := 0; i < 3; i++ {
+		if css := app.GetChromeCSS(); css != "" {
+			t.Fatalf("GetChromeCSS() = %q, want empty for a missing file", css)
+		}
+	}
+
+	got := app.GetLoadErrors()
+	if len(got) != 1 || got[0].Path != app.config.ChromeCSS {
+		t.Errorf("GetLoadErrors() = %+v, want one error for the chrome CSS file", got)
+	}
+}
+
+func TestReloadConfigClearsConfigLoadErrors(t *testing.T) {
+	configDir := filepath.Join(useTempConfigDir(t), "fenestro")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	configPath := filepath.Join(configDir, "config.toml")
+	missing := filepath.Join(t.TempDir(), "chrome.css")
+	if err := os.WriteFile(configPath, []byte(fmt.Sprintf("chrome_css = %q\n", missing)), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	app := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, "")
+	app.ReloadConfig()
+	app.GetChromeCSS()
+	app.recordLoadError(LoadError{Path: "/tmp/batch.html", Message: "no such file"})
+	if got := app.GetLoadErrors(); len(got) != 2 {
+		t.Fatalf("GetLoadErrors() = %+v, want the chrome CSS and batch errors", got)
+	}
+
+	if err := os.WriteFile(configPath, []byte("font_size = 16\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	rec := recordEvents(app)
+	app.ReloadConfig()
+
+	got := app.GetLoadErrors()
+	if len(got) != 1 || got[0].Path != "/tmp/batch.html" {
+		t.Errorf("GetLoadErrors() after fixing the config = %+v, want only the batch error", got)
+	}
+	if len(rec.named("load-errors-changed")) != 1 {
+		t.Error("clearing the config's load errors should notify the frontend")
+	}
+}
+
+func TestGetLoadErrorsEmpty(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, "")
+	if got := app.GetLoadErrors(); got == nil || len(got) != 0 {
+		t.Errorf("GetLoadErrors() = %#v, want an empty list", got)
+	}
+}
@@ -3,11 +3,15 @@ package main
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	goruntime "runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -25,53 +29,322 @@ var assets embed.FS
 const Version = "2.0.0"
 
 var (
-	filePath    string
-	displayName string
-	windowID    string
-	showVersion bool
-	internalGUI bool // Hidden flag: run as GUI subprocess
-	tempFile    bool // Hidden flag: delete file after reading (for stdin content)
+	filePath      string
+	displayName   string
+	windowTitle   string
+	windowID      string
+	showVersion   bool
+	follow        time.Duration
+	basePath      string
+	groupByDir    bool
+	contentType   string
+	noLocalFiles  bool
+	logFile       string
+	minSize       string
+	gcSockets     bool
+	forceDiff     bool
+	geometry      string
+	forceGeometry bool
+	sourceURL     string
+	headers       []string
+	userAgent     string
+	instance      string
+	replaceOrAdd  bool
+	showStats     bool
+	doctorChecks  bool
+	closeWindows  bool
+	safeMode      bool
+	reloadSignal  bool
+	printCSS      bool
+	selectFile    string
+	noReuse       bool
+	pipeName      string
+	printConfig   bool
+	printJSON     bool
+	splitDelim    string
+	waitRender    bool
+	addToWindow   bool
+	lineNumber    int
+	noActivate    bool
+	viewport      int
+	screenshot    string
+	exportPath    string
+	shotWidth     int
+	shotHeight    int
+	internalGUI   bool   // Hidden flag: run as GUI subprocess
+	tempFile      bool   // Hidden flag: delete file after reading (for stdin content)
+	manifestPath  string // Hidden flag: seed the window from a Duplicate manifest
+
+	// seed is the file set read from --manifest, if given
+	seed *sessionManifest
 )
 
 func init() {
 	flag.StringVarP(&filePath, "path", "p", "", "Path to HTML file to display")
 	flag.StringVarP(&displayName, "name", "n", "", "Display name for the window title")
+	flag.StringVar(&windowTitle, "title", "", "Window title, independent of the sidebar name (default: the display name)")
 	flag.StringVar(&windowID, "id", "", "Window ID: use 'new' to generate ID, or provide existing UUID to target that window")
 	flag.BoolVarP(&showVersion, "version", "v", false, "Show version")
+	flag.StringVar(&basePath, "base-path", "", "Directory to resolve relative assets against (default: the file's directory)")
+	flag.DurationVar(&follow, "follow", 0, "Re-read the file on this interval (e.g. 2s) and update the window when it changes")
+	flag.StringVar(&contentType, "content-type", "", "Render content as text/html, text/markdown, text/plain, application/json, or text/x-diff regardless of file extension")
+	flag.BoolVar(&safeMode, "safe", false, "Strip scripts, inline event handlers, and javascript: URLs from the content before rendering")
+	flag.BoolVar(&noLocalFiles, "no-local-files", false, "Never serve local files referenced by the content (images, stylesheets, scripts)")
+	flag.StringVar(&logFile, "log-file", "", "Write the window process's output to this file instead of the terminal")
+	flag.StringVar(&minSize, "min-size", "", "Minimum window size as WxH (e.g. 320x200), overriding min_width/min_height")
+	flag.BoolVar(&gcSockets, "gc", false, "Remove sockets left behind by windows that are no longer running, then exit")
+	flag.BoolVar(&forceDiff, "diff", false, "Render content as a unified diff (same as --content-type text/x-diff)")
+	flag.StringVar(&geometry, "geometry", "", "Initial window size and position as WxH or WxH+X+Y (e.g. 1200x800+100+50), used instead of config defaults")
+	flag.BoolVar(&forceGeometry, "force-geometry", false, "Use --geometry even if a saved window size and position exist")
+	flag.StringVar(&instance, "instance", "", "Use a separate sidebar window and saved geometry for this name, so independent tools don't share a window")
+	flag.BoolVar(&replaceOrAdd, "replace-or-add", false, "In sidebar mode, replace a file already in the sidebar (matched by path) instead of adding it again")
+	flag.BoolVar(&printConfig, "print-config", false, "Print the configuration in effect (config file plus flag overrides) as TOML, then exit")
+	flag.BoolVar(&printJSON, "json", false, "With --print-config, print JSON instead of TOML")
+	flag.StringVar(&splitDelim, "split", "", "Split piped content on this delimiter (e.g. '\\f') into separate sidebar entries")
+	flag.StringVar(&exportPath, "export-html", "", "Write the rendered content with its local assets inlined to this file as a single HTML document, then exit")
+	flag.StringVar(&screenshot, "screenshot", "", "Render the content in a new window, save it as this PNG file, close the window, and exit")
+	flag.IntVar(&shotWidth, "width", 0, "With --screenshot, the window width in pixels (default: the configured window width)")
+	flag.IntVar(&shotHeight, "height", 0, "With --screenshot, the window height in pixels (default: the configured window height)")
+	flag.IntVar(&viewport, "viewport", 0, "Constrain the content to this width in pixels (0 = full window)")
+	flag.IntVar(&lineNumber, "line", 0, "Scroll to and highlight this line of a text or source file (same as -p file:line)")
+	flag.BoolVar(&addToWindow, "add", false, "With --id, add the file to the window's sidebar instead of replacing its content")
+	flag.StringVar(&selectFile, "select", "", "File to show first when opening several (--split, --group-by-dir): a name or 0-based index")
+	flag.BoolVar(&printCSS, "print-css", false, "Apply the content's print stylesheets instead of its screen ones")
+	flag.BoolVar(&reloadSignal, "reload-on-signal", false, "Re-read the file from disk when the window process receives SIGUSR1")
+	flag.BoolVar(&noActivate, "no-activate", false, "Open the window in the background, without taking focus from the active app (macOS)")
+	flag.BoolVar(&waitRender, "wait-render", false, "Exit only once the window has rendered the content")
+	flag.StringVar(&pipeName, "pipe-name", "", "Name piped content; later pipes with the same name replace it instead of adding another entry")
+	flag.BoolVar(&noReuse, "no-reuse", false, "With --id, open a new window (printing its ID) instead of replacing content in a live window with that ID")
+	flag.BoolVar(&closeWindows, "close-all", false, "Close every running window and remove stale sockets, then exit")
+	flag.BoolVar(&doctorChecks, "doctor", false, "Print environment details and check the config file and sockets, then exit")
+	flag.BoolVar(&showStats, "stats", false, "Print the file count, selected file, and content size of the sidebar window (or the --id window), then exit")
+	flag.BoolVar(&groupByDir, "group-by-dir", false, "Open one window per subdirectory of the given directory, containing that subdirectory's HTML files")
+	flag.StringVar(&sourceURL, "url", "", "Fetch and display the page at this URL")
+	flag.StringArrayVar(&headers, "header", nil, "Request header for --url as \"Name: Value\" (repeatable)")
+	flag.StringVar(&userAgent, "user-agent", "", "User-Agent for --url (default \"fenestro/<version>\")")
 	flag.BoolVar(&internalGUI, "internal-gui", false, "Internal: run as GUI subprocess")
 	flag.BoolVar(&tempFile, "temp-file", false, "Internal: delete file after reading")
+	flag.StringVar(&manifestPath, "manifest", "", "Internal: open the files in this manifest, then delete it")
 	flag.CommandLine.MarkHidden("internal-gui")
 	flag.CommandLine.MarkHidden("temp-file")
+	flag.CommandLine.MarkHidden("manifest")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usageText(flag.CommandLine))
+	}
+}
+
+// usageText builds the help text from the registered flags so it stays in
+// sync as flags are added. Hidden internal flags are omitted by pflag.
+func usageText(flags *flag.FlagSet) string {
+	var b strings.Builder
+	b.WriteString("Usage: fenestro [-p] path [-n name] [--id [window-id]]\n")
+	b.WriteString("       fenestro --url https://example.com [--header 'Name: Value']\n")
+	b.WriteString("       fenestro <directory> --group-by-dir\n")
+	b.WriteString("       echo '<html>...</html>' | fenestro\n")
+	b.WriteString("       fenestro <command> [options]\n")
+	b.WriteString("\n")
+	b.WriteString("Commands:\n")
+	b.WriteString(subcommandUsage())
+	b.WriteString("\n")
+	b.WriteString("Options:\n")
+	b.WriteString(flags.FlagUsages())
+	b.WriteString("\n")
+	b.WriteString("Modes:\n")
+	b.WriteString("  Sidebar mode (default):\n")
+	b.WriteString("    Files opened within 2 seconds are grouped in the same window.\n")
+	b.WriteString("\n")
+	b.WriteString("  Window ID mode (--id):\n")
+	b.WriteString("    fenestro -p file.html --id new    # Create window, print UUID\n")
+	b.WriteString("    fenestro -p file.html --id <uuid> # Replace content in window\n")
+	b.WriteString("\n")
+	b.WriteString("  Group by directory (--group-by-dir):\n")
+	b.WriteString("    One window per subdirectory; reopening the directory updates the same windows.\n")
+	return b.String()
 }
 
 func main() {
-	flag.Parse()
+	args := os.Args[1:]
+	if code, ok := runSubcommand(args, os.Stdout, os.Stderr); ok {
+		os.Exit(code)
+	}
+	// "fenestro open ..." is the default behavior spelled out
+	if len(args) > 0 && args[0] == "open" {
+		args = args[1:]
+	}
+	flag.CommandLine.Parse(args)
 
 	if showVersion {
 		fmt.Printf("fenestro %s\n", Version)
 		os.Exit(0)
 	}
 
+	// Loaded once for the whole invocation, so problems with the config file
+	// are reported once
+	config := LoadConfig()
+
+	if instance != "" {
+		if err := checkInstanceName(instance); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// The GUI is handed its instance with --instance, so only the command
+	// line derives one from the working directory
+	if !internalGUI {
+		cwd, _ := os.Getwd()
+		instance = sidebarInstance(instance, config, cwd)
+	}
+
+	if gcSockets {
+		os.Exit(runGC(nil, os.Stdout, os.Stderr))
+	}
+
+	if closeWindows {
+		os.Exit(closeAll(getSocketDir(), config.IPCToken, os.Stdout, os.Stderr))
+	}
+
+	if doctorChecks {
+		os.Exit(runDoctor(nil, os.Stdout, os.Stderr))
+	}
+
+	if printConfig {
+		effective, err := config.applyOverrides(configOverrides{
+			MinSize:      minSize,
+			Geometry:     geometry,
+			NoLocalFiles: noLocalFiles,
+		})
+		if err == nil {
+			err = writeConfig(os.Stdout, effective.withEffectiveDefaults(), printJSON)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if showStats {
+		socketPath, err := targetSocketPath(windowID)
+		if err == nil {
+			err = printStats(os.Stdout, socketPath, config.IPCToken)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if groupByDir {
+		if flag.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, "Error: --group-by-dir requires a directory argument")
+			os.Exit(1)
+		}
+		if err := openDirGroups(flag.Arg(0), config); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// fenestro file.html is the same as fenestro -p file.html
+	var pathWarning string
+	filePath, pathWarning = resolvePathArg(filePath, flag.Args())
+	if pathWarning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", pathWarning)
+	}
+
+	// A .webloc or .url shortcut opens the page it points to, as with --url
+	if filePath != "" && sourceURL == "" && !internalGUI && isShortcutPath(filePath) {
+		target, err := readShortcut(filePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading shortcut: %v\n", err)
+			os.Exit(1)
+		}
+		if displayName == "" {
+			displayName = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+		}
+		sourceURL, filePath = target, ""
+	}
+
+	if addToWindow && windowID == "" {
+		fmt.Fprintln(os.Stderr, "Error: --add requires --id (sidebar mode always adds)")
+		os.Exit(1)
+	}
+
+	// Piped content with a --pipe-name is identified by that name, so sending
+	// it to the sidebar again replaces the earlier content. An --id window
+	// already replaces its piped content, so there the name only labels it.
+	if pipeName != "" && !internalGUI {
+		if displayName != "" {
+			fmt.Fprintln(os.Stderr, "Error: --pipe-name and --name can't be combined")
+			os.Exit(1)
+		}
+		if filePath != "" || sourceURL != "" {
+			fmt.Fprintln(os.Stderr, "Error: --pipe-name only applies to content piped on stdin")
+			os.Exit(1)
+		}
+		displayName = pipeName
+		if windowID == "" {
+			replaceOrAdd = true
+		}
+	}
+
 	// Determine content source and create FileEntry
 	var entry FileEntry
 	var fromStdin bool
+	var anchor string
 
-	if filePath != "" {
+	if manifestPath != "" && internalGUI {
+		// A window opened by Duplicate; its files arrive already rendered
+		m, err := readSessionManifest(manifestPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		seed = &m
+		entry = m.Files[m.CurrentIndex]
+	} else if sourceURL != "" {
+		// Fetched content has no path, so it's passed to the window like stdin
+		fetched, err := fetchURL(sourceURL, headers, userAgent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching URL: %v\n", err)
+			os.Exit(1)
+		}
+		entry = fetched
+		if displayName != "" {
+			entry.Name = displayName
+		}
+		fromStdin = true
+	} else if filePath != "" {
+		// Support file.html#section to open scrolled to an anchor and
+		// file.txt:42 to open at a line, unless the suffix is really part of
+		// an existing file's name. An explicit --line wins over the suffix.
+		if _, err := os.Stat(filePath); err != nil {
+			filePath, lineNumber = resolveLine(filePath, lineNumber)
+			filePath, anchor = splitPathAnchor(filePath)
+		}
 		// Load from file path
 		absPath, err := filepath.Abs(filePath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error resolving path: %v\n", err)
 			os.Exit(1)
 		}
+		// Temp files carry stdin content, which isn't subject to the extension allowlist
+		if err := config.checkExtensionAllowed(absPath); err != nil && !tempFile {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 		content, err := os.ReadFile(absPath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
 			os.Exit(1)
 		}
 		entry = FileEntry{
-			Name:    displayName,
-			Path:    absPath,
-			Content: string(content),
+			Name:        displayName,
+			Path:        absPath,
+			Content:     string(content),
+			ContentType: loadContentType(absPath),
 		}
 		if entry.Name == "" {
 			entry.Name = filepath.Base(filePath)
@@ -88,9 +361,10 @@ func main() {
 			os.Exit(1)
 		}
 		entry = FileEntry{
-			Name:    displayName,
-			Path:    "", // stdin has no path
-			Content: string(content),
+			Name:        displayName,
+			Path:        "", // stdin has no path
+			Content:     string(content),
+			ContentType: ContentTypeHTML,
 		}
 		if entry.Name == "" {
 			entry.Name = "stdin"
@@ -98,36 +372,151 @@ func main() {
 		fromStdin = true
 	} else {
 		// No input provided
-		fmt.Println("Usage: fenestro [-p path] [-n name] [-id [window-id]]")
-		fmt.Println("       echo '<html>...</html>' | fenestro")
-		fmt.Println()
-		fmt.Println("Options:")
-		fmt.Println("  -p, --path    Path to HTML file to display")
-		fmt.Println("  -n, --name    Display name for the window title")
-		fmt.Println("  -id           Window ID mode ('new' = generate ID, '<uuid>' = target window)")
-		fmt.Println("  -v, --version Show version")
-		fmt.Println()
-		fmt.Println("Sidebar mode (default):")
-		fmt.Println("  Files opened within 2 seconds are grouped in the same window.")
-		fmt.Println()
-		fmt.Println("Window ID mode (-id):")
-		fmt.Println("  fenestro -p file.html -id new    # Create window, print UUID")
-		fmt.Println("  fenestro -p file.html -id <uuid> # Replace content in window")
+		fmt.Print(usageText(flag.CommandLine))
+		os.Exit(0)
+	}
+
+	// Render according to --content-type if given, ignoring the file extension
+	if forceDiff {
+		contentType = ContentTypeDiff
+	}
+	if contentType != "" {
+		if err := checkContentType(contentType); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// --split turns one piped stream into several sidebar entries
+	var documents []FileEntry
+	if splitDelim != "" && fromStdin && !internalGUI {
+		documents = splitEntries(entry, unescapeDelimiter(splitDelim))
+		if len(documents) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: no documents found in piped content")
+			os.Exit(1)
+		}
+		entry = documents[0]
+	}
+	// Duplicated files were rendered by the window they came from; the
+	// flags it passed on apply to what the copy loads from then on
+	if seed == nil {
+		applyContentType(&entry, contentType, config.NormalizeLineEndings)
+		sanitizeEntry(&entry, fromStdin, safeMode, config)
+	}
+	for i := range documents {
+		applyContentType(&documents[i], contentType, config.NormalizeLineEndings)
+		sanitizeEntry(&documents[i], fromStdin, safeMode, config)
+	}
+
+	if logFile != "" {
+		absLog, err := filepath.Abs(logFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving log file: %v\n", err)
+			os.Exit(1)
+		}
+		logFile = absLog
+	}
+
+	// Resolve relative assets against an explicit directory if requested
+	if basePath != "" {
+		absBase, err := filepath.Abs(basePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving base path: %v\n", err)
+			os.Exit(1)
+		}
+		entry.BasePath = absBase
+		for i := range documents {
+			documents[i].BasePath = absBase
+		}
+	}
+
+	// Exporting writes a file instead of opening a window
+	if exportPath != "" && !internalGUI {
+		if err := runExportHTML(entry, exportPath, config); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --export-html: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Remember files opened from the command line (the GUI subprocess and
+	// stdin content are skipped so each open is recorded once)
+	if !internalGUI {
+		if err := rememberFile(entry.Path, config); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not update recent files: %v\n", err)
+		}
+	}
+
+	if geometry != "" {
+		if _, err := ParseGeometry(geometry); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --geometry: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if minSize != "" {
+		if _, _, err := parseSize(minSize); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --min-size: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if lineNumber < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --line must be a positive line number")
+		os.Exit(1)
+	}
+
+	if noActivate {
+		if err := checkNoActivate(goruntime.GOOS); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Without --viewport, the window keeps the width it last used
+	if !flag.CommandLine.Changed("viewport") {
+		viewport = -1
+	} else if viewport < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --viewport must be 0 or a positive width")
+		os.Exit(1)
+	}
+
+	if err := checkScreenshotArgs(screenshot, shotWidth, shotHeight, windowID, geometry); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if screenshot != "" && !internalGUI {
+		if err := runScreenshot(entry, fromStdin, anchor, config); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --screenshot: %v\n", err)
+			os.Exit(1)
+		}
 		os.Exit(0)
 	}
 
 	// Check if we're using window ID mode
 	isWindowIDMode := windowID != ""
 
-	// Handle window ID "new" - generate UUID before any IPC or spawning
-	if isWindowIDMode && windowID == "new" {
-		windowID = uuid.New().String()
-		fmt.Println(windowID)
+	// Handle window ID "new" (or a live ID with --no-reuse) - generate UUID
+	// before any IPC or spawning
+	if isWindowIDMode {
+		var generated bool
+		alive := func(id string) bool { return windowAlive(id, config.IPCToken) }
+		windowID, generated = resolveWindowID(windowID, noReuse && !internalGUI, alive)
+		if generated {
+			fmt.Println(windowID)
+		}
+	}
+
+	if len(documents) > 0 {
+		if err := openDocuments(documents, windowID, config); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		exitDelivered(windowID, config.IPCToken)
 	}
 
 	// If this is the GUI subprocess, run the GUI directly
 	if internalGUI {
-		runGUI(entry, windowID, isWindowIDMode)
+		runGUI(entry, windowID, isWindowIDMode, anchor, windowTitle, config)
 		return
 	}
 
@@ -140,82 +529,318 @@ func main() {
 				os.Exit(1)
 			}
 			// Try to send to existing window
-			if TrySendToWindowInstance(windowID, entry) {
-				os.Exit(0)
+			sent, err := TrySendToWindowInstance(windowID, entry, anchor, lineNumber, addToWindow, config.IPCToken)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if sent {
+				exitDelivered(windowID, config.IPCToken)
 			}
 		}
 	} else {
 		// Sidebar mode - try to send to existing instance
-		if TrySendToSidebarInstance(entry) {
-			os.Exit(0)
+		sent, err := TrySendToSidebarInstance(entry, replaceOrAdd, lineNumber, config.IPCToken)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if sent {
+			exitDelivered(windowID, config.IPCToken)
 		}
 	}
 
-	// No existing instance - spawn GUI in background and exit
-	if err := spawnGUIBackground(entry, windowID, fromStdin); err != nil {
+	// No existing instance - spawn GUI in background and exit, first clearing
+	// out temp files earlier windows never got to read
+	sweepTempFiles(os.TempDir(), tempFileMaxAge)
+	if err := spawnGUIBackground(entry, windowID, fromStdin, anchor, config); err != nil {
 		fmt.Fprintf(os.Stderr, "Error spawning GUI: %v\n", err)
 		os.Exit(1)
 	}
+	exitDelivered(windowID, config.IPCToken)
+}
+
+// exitDelivered exits once content has been handed to a window, first
+// waiting for the window to render it when --wait-render is set
+func exitDelivered(windowID, token string) {
+	if waitRender {
+		socketPath := getSidebarSocketPath()
+		if windowID != "" {
+			socketPath = getWindowSocketPath(windowID)
+		}
+		if err := waitForRender(socketPath, token); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --wait-render: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	os.Exit(0)
 }
 
-// spawnGUIBackground spawns the GUI as a background process and waits for the socket to be ready
-func spawnGUIBackground(entry FileEntry, windowID string, fromStdin bool) error {
-	exe, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("failed to get executable path: %w", err)
+// runExportHTML writes entry as it would be rendered, with its local assets
+// inlined (see exportHTML), to path without opening a window. References
+// that couldn't be inlined are reported as warnings.
+func runExportHTML(entry FileEntry, path string, config Config) error {
+	app := newAppWithConfig(entry, "", config)
+	app.printMedia = printCSS
+	content, warnings := exportHTML(app.GetHTMLContent(), app.GetCurrentBasePath(), app.GetConfig())
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: Not inlined: %s\n", warning)
 	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
 
-	args := []string{"--internal-gui"}
+// runScreenshot opens entry in a window of its own, sized by --width and
+// --height, and saves it to the --screenshot file once it has rendered
+func runScreenshot(entry FileEntry, fromStdin bool, anchor string, config Config) error {
+	path, err := filepath.Abs(screenshot)
+	if err != nil {
+		return err
+	}
+	size, err := screenshotGeometry(shotWidth, shotHeight, config)
+	if err != nil {
+		return err
+	}
+	if size != "" {
+		// Saved window sizes don't apply to the capture
+		geometry, forceGeometry = size, true
+	}
+	id := uuid.New().String()
+	sweepTempFiles(os.TempDir(), tempFileMaxAge)
+	if err := spawnGUIBackground(entry, id, fromStdin, anchor, config); err != nil {
+		return err
+	}
+	return takeScreenshot(getWindowSocketPath(id), path, config.IPCToken)
+}
 
-	// Handle content: if from stdin, write to temp file; otherwise use original path
-	if fromStdin {
-		tmpFile, err := os.CreateTemp("", "fenestro-*.html")
-		if err != nil {
-			return fmt.Errorf("failed to create temp file: %w", err)
+// openDocuments shows the entries split from piped content (--split). In
+// sidebar mode they're added to the sidebar window; a window ID window has
+// its files replaced by them. A window is spawned with the --select entry
+// (by default the first in sidebar order) if none is running.
+func openDocuments(documents []FileEntry, windowID string, config Config) error {
+	selected := selectedEntry(documents, selectFile)
+	// The spawned window names its entry from -n, so pass the split name
+	displayName = selected.Name
+	if windowID != "" {
+		if _, err := uuid.Parse(windowID); err != nil {
+			return fmt.Errorf("invalid window ID format (expected UUID): %s", windowID)
 		}
-		if _, err := tmpFile.WriteString(entry.Content); err != nil {
-			tmpFile.Close()
-			os.Remove(tmpFile.Name())
-			return fmt.Errorf("failed to write temp file: %w", err)
+		if !windowAlive(windowID, config.IPCToken) {
+			sweepTempFiles(os.TempDir(), tempFileMaxAge)
+			if err := spawnGUIBackground(selected, windowID, true, "", config); err != nil {
+				return fmt.Errorf("failed to open window: %w", err)
+			}
 		}
-		tmpFile.Close()
-		args = append(args, "-p", tmpFile.Name(), "--temp-file")
+		return sendCommandOK(getWindowSocketPath(windowID), IPCCommand{Cmd: "set-files", Files: documents, Select: selected.Name, Token: config.IPCToken})
+	}
+
+	return sendDocumentsToSidebar(documents, selected, config)
+}
+
+// sendDocumentsToSidebar sends documents to the sidebar instance, spawning
+// it showing selected if none is running. A running sidebar gets selected
+// last, flagged to be shown, so it ends up selected there too.
+func sendDocumentsToSidebar(documents []FileEntry, selected FileEntry, config Config) error {
+	socketPath := getSidebarSocketPath()
+	var cmds []IPCCommand
+	for _, doc := range documents {
+		if doc.Name != selected.Name {
+			cmds = append(cmds, sidebarCommand(doc, replaceOrAdd, config.IPCToken))
+		}
+	}
+	if checkSocketOwnership(socketPath) == socketLive {
+		last := sidebarCommand(selected, replaceOrAdd, config.IPCToken)
+		last.Select = selected.Name
+		last.Line = lineNumber
+		cmds = append(cmds, last)
 	} else {
-		args = append(args, "-p", entry.Path)
+		sweepTempFiles(os.TempDir(), tempFileMaxAge)
+		if err := spawnGUIBackground(selected, "", true, "", config); err != nil {
+			return fmt.Errorf("failed to open window: %w", err)
+		}
+	}
+	for _, cmd := range cmds {
+		if err := sendCommandOK(socketPath, cmd); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Pass display name if it was explicitly set
-	if displayName != "" {
-		args = append(args, "-n", displayName)
+// sendCommandOK sends cmd and turns a rejected command into an error
+func sendCommandOK(socketPath string, cmd IPCCommand) error {
+	resp, err := SendCommand(socketPath, cmd)
+	if err != nil {
+		return err
+	}
+	if !resp.OK {
+		return fmt.Errorf("window rejected %s: %s", cmd.Cmd, resp.Error)
 	}
+	return nil
+}
 
-	// Pass window ID if set
-	if windowID != "" {
-		args = append(args, "-id", windowID)
+// resolveLine splits a :line suffix from a -p path. A --line flag
+// (flagLine > 0) takes precedence over the suffix.
+func resolveLine(path string, flagLine int) (string, int) {
+	path, line := splitPathLine(path)
+	if flagLine > 0 {
+		return path, flagLine
 	}
+	return path, line
+}
 
-	// Spawn the child process detached
-	cmd := exec.Command(exe, args...)
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setsid: true, // Create new session so child survives parent exit
+// resolveWindowID decides which window a window ID mode invocation targets.
+// "new" always gets a fresh ID; with noReuse, so does an ID whose window is
+// alive, leaving that window untouched. It returns the ID and whether it was
+// generated.
+func resolveWindowID(id string, noReuse bool, alive func(string) bool) (string, bool) {
+	if id == "new" || (noReuse && alive(id)) {
+		return uuid.New().String(), true
 	}
-	// Don't inherit stdin (child reads from file), but keep stderr for errors
-	cmd.Stderr = os.Stderr
+	return id, false
+}
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start GUI process: %w", err)
+// windowAlive reports whether a window with the given ID is running
+func windowAlive(id, token string) bool {
+	return verifyWindowIdentity(getWindowSocketPath(id), id, token)
+}
+
+// resolvePathArg returns the file to open given the -p value and the
+// positional arguments. -p takes precedence; otherwise the first positional
+// argument is used. The warning is non-empty if any arguments were ignored.
+func resolvePathArg(flagPath string, args []string) (string, string) {
+	if flagPath != "" {
+		if len(args) > 0 {
+			return flagPath, fmt.Sprintf("using -p %s and ignoring %s", flagPath, strings.Join(args, " "))
+		}
+		return flagPath, ""
+	}
+	if len(args) == 0 {
+		return "", ""
+	}
+	if len(args) > 1 {
+		return args[0], fmt.Sprintf("only one file can be opened at a time, ignoring %s", strings.Join(args[1:], " "))
+	}
+	return args[0], ""
+}
+
+// openDirGroups opens one window per subdirectory of root, each showing that
+// subdirectory's HTML files. Windows that are already open are updated in place.
+func openDirGroups(root string, config Config) error {
+	groups, err := groupFilesByDir(root)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		return fmt.Errorf("no HTML files found in subdirectories of %s", root)
+	}
+
+	for _, group := range groups {
+		files, loadErrs := readBatch(group.Files, safeMode, config)
+		for _, loadErr := range loadErrs {
+			fmt.Fprintf(os.Stderr, "Warning: Skipping %s: %s\n", loadErr.Path, loadErr.Message)
+		}
+		if len(files) == 0 {
+			continue
+		}
+		selected := selectedEntry(files, selectFile)
+
+		cmd := IPCCommand{Cmd: "set-files", Files: files, Select: selected.Name, Token: config.IPCToken, LoadErrors: loadErrs}
+		socketPath := getWindowSocketPath(group.WindowID)
+		found, err := checkWindowSocket(socketPath, group.WindowID, config.IPCToken)
+		if err != nil {
+			return fmt.Errorf("failed to open window for %s: %w", group.Name, err)
+		}
+		if !found {
+			// No window for this subdirectory yet - spawn one, then send the rest
+			if err := spawnGUIBackground(selected, group.WindowID, false, "", config); err != nil {
+				return fmt.Errorf("failed to open window for %s: %w", group.Name, err)
+			}
+		}
+		if resp, err := SendCommand(socketPath, cmd); err != nil {
+			return fmt.Errorf("failed to send files for %s: %w", group.Name, err)
+		} else if !resp.OK {
+			return fmt.Errorf("failed to send files for %s: %s", group.Name, resp.Error)
+		}
+	}
+	return nil
+}
+
+// spawnGUIBackground spawns the GUI as a background process and waits for the socket to be ready
+func spawnGUIBackground(entry FileEntry, windowID string, fromStdin bool, anchor string, config Config) error {
+	if err := checkSpawnDepth(currentSpawnDepth()); err != nil {
+		return err
+	}
+
+	// Fail fast over SSH and in containers rather than timing out below
+	if err := checkDisplay(); err != nil {
+		return err
+	}
+
+	// Pass display name only if it was explicitly set
+	args, err := guiProcessArgs(entry, guiOptions{
+		Name:          displayName,
+		Title:         windowTitle,
+		WindowID:      windowID,
+		FromStdin:     fromStdin,
+		Anchor:        anchor,
+		Follow:        follow,
+		ContentType:   contentType,
+		NoLocalFiles:  noLocalFiles,
+		LogFile:       logFile,
+		MinSize:       minSize,
+		Geometry:      geometry,
+		ForceGeometry: forceGeometry,
+		Instance:      instance,
+		Viewport:      viewportArg(viewport),
+		Line:          lineNumber,
+		NoActivate:    noActivate,
+		Safe:          safeMode,
+		ReloadSignal:  reloadSignal,
+		PrintCSS:      printCSS,
+	})
+	if err != nil {
+		return err
 	}
 
-	// Wait for socket to be created (guarantees subsequent invocations can connect)
 	var socketPath string
 	if windowID != "" {
 		socketPath = getWindowSocketPath(windowID)
 	} else {
 		socketPath = getSidebarSocketPath()
 	}
+	return launchGUI(guiProcessStarter(config.LogMaxSize), args, socketPath, guiStartTimeout)
+}
+
+// viewportArg formats --viewport for a GUI subprocess, or "" if the flag
+// wasn't given (px < 0)
+func viewportArg(px int) string {
+	if px < 0 {
+		return ""
+	}
+	return strconv.Itoa(px)
+}
+
+// guiStartTimeout is how long to wait for a new window to create its socket
+const guiStartTimeout = 5 * time.Second
 
-	deadline := time.Now().Add(5 * time.Second)
+// launchGUI starts a GUI subprocess and waits for it to create socketPath,
+// which guarantees subsequent invocations can connect. A --temp-file is
+// normally deleted by the child once read, so it's removed here if the child
+// fails to start or never comes up.
+func launchGUI(start processStarter, args []string, socketPath string, timeout time.Duration) error {
+	tmpPath := tempFileArg(args)
+
+	if err := start(args); err != nil {
+		if tmpPath != "" {
+			os.Remove(tmpPath)
+		}
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
 		if _, err := os.Stat(socketPath); err == nil {
 			return nil // Socket exists, child is ready
@@ -223,17 +848,347 @@ func spawnGUIBackground(entry FileEntry, windowID string, fromStdin bool) error
 		time.Sleep(10 * time.Millisecond)
 	}
 
+	if tmpPath != "" {
+		os.Remove(tmpPath)
+	}
 	return fmt.Errorf("timeout waiting for GUI to start")
 }
 
-// runGUI runs the Wails application (called from GUI subprocess)
-func runGUI(entry FileEntry, windowID string, isWindowIDMode bool) {
+// tempFileArg returns the -p path in GUI subprocess args if it's a temp file
+// the child is expected to delete, or "" otherwise
+func tempFileArg(args []string) string {
+	path := ""
+	isTemp := false
+	for i, a := range args {
+		switch {
+		case a == "-p" && i+1 < len(args):
+			path = args[i+1]
+		case a == "--temp-file":
+			isTemp = true
+		}
+	}
+	if !isTemp {
+		return ""
+	}
+	return path
+}
+
+// guiOptions holds the settings passed on to a GUI subprocess
+type guiOptions struct {
+	Name          string        // display name, if explicitly set
+	Title         string        // --title, if explicitly set
+	WindowID      string        // target window ID (empty for sidebar mode)
+	FromStdin     bool          // content has no path, pass it via a temp file
+	Anchor        string        // element ID to scroll to after rendering
+	Follow        time.Duration // --follow poll interval (0 to disable)
+	ContentType   string        // --content-type override
+	NoLocalFiles  bool          // --no-local-files
+	LogFile       string        // --log-file, so windows the child spawns log there too
+	MinSize       string        // --min-size
+	Geometry      string        // --geometry
+	ForceGeometry bool          // --force-geometry
+	Instance      string        // --instance
+	Viewport      string        // --viewport, if given
+	Line          int           // --line (0 = none)
+	NoActivate    bool          // --no-activate
+	Safe          bool          // --safe
+	ReloadSignal  bool          // --reload-on-signal
+	PrintCSS      bool          // --print-css
+}
+
+// guiProcessArgs builds the command-line arguments for a GUI subprocess.
+// Content without a path (stdin) is written to a temp file that the child
+// deletes after reading.
+func guiProcessArgs(entry FileEntry, opts guiOptions) ([]string, error) {
+	args := []string{"--internal-gui"}
+
+	// Handle content: if from stdin, write to temp file; otherwise use original path
+	if opts.FromStdin {
+		tmpPath, err := writeTempContent(entry.Content)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-p", tmpPath, "--temp-file")
+	} else {
+		path := entry.Path
+		if opts.Anchor != "" {
+			path += "#" + opts.Anchor
+		}
+		args = append(args, "-p", path)
+	}
+
+	if opts.Name != "" {
+		args = append(args, "-n", opts.Name)
+	}
+	if opts.Title != "" {
+		args = append(args, "--title", opts.Title)
+	}
+
+	// Pass window ID if set
+	if opts.WindowID != "" {
+		args = append(args, "--id", opts.WindowID)
+	}
+
+	if entry.BasePath != "" {
+		args = append(args, "--base-path", entry.BasePath)
+	}
+
+	// Following only makes sense for real files
+	if opts.Follow > 0 && !opts.FromStdin {
+		args = append(args, "--follow", opts.Follow.String())
+	}
+
+	// Stdin content is already transformed before it's written to the temp
+	// file; real files are re-read and transformed by the child
+	if opts.ContentType != "" && !opts.FromStdin {
+		args = append(args, "--content-type", opts.ContentType)
+	}
+
+	if opts.NoLocalFiles {
+		args = append(args, "--no-local-files")
+	}
+
+	if opts.Safe {
+		args = append(args, "--safe")
+	}
+
+	// Only real files can be re-read
+	if opts.ReloadSignal && !opts.FromStdin {
+		args = append(args, "--reload-on-signal")
+	}
+
+	if opts.PrintCSS {
+		args = append(args, "--print-css")
+	}
+
+	if opts.LogFile != "" {
+		args = append(args, "--log-file", opts.LogFile)
+	}
+
+	if opts.MinSize != "" {
+		args = append(args, "--min-size", opts.MinSize)
+	}
+
+	if opts.Line > 0 {
+		args = append(args, "--line", strconv.Itoa(opts.Line))
+	}
+
+	if opts.Viewport != "" {
+		args = append(args, "--viewport", opts.Viewport)
+	}
+
+	if opts.NoActivate {
+		args = append(args, "--no-activate")
+	}
+
+	if opts.Geometry != "" {
+		args = append(args, "--geometry", opts.Geometry)
+		if opts.ForceGeometry {
+			args = append(args, "--force-geometry")
+		}
+	}
+
+	if opts.Instance != "" {
+		args = append(args, "--instance", opts.Instance)
+	}
+
+	return args, nil
+}
+
+// tempFilePattern names the temp files that carry stdin content to a window
+const tempFilePattern = "fenestro-*.html"
+
+// tempFileMaxAge is how old a leftover temp file must be before
+// sweepTempFiles removes it; any window reading it has long since started
+const tempFileMaxAge = 24 * time.Hour
+
+// writeTempContent writes content to a new temp file and returns its path
+func writeTempContent(content string) (string, error) {
+	tmpFile, err := os.CreateTemp("", tempFilePattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := tmpFile.WriteString(content); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	tmpFile.Close()
+	return tmpFile.Name(), nil
+}
+
+// sweepTempFiles removes temp files in dir older than maxAge, left behind by
+// windows that were killed or crashed before reading them. It returns the
+// number of files removed.
+func sweepTempFiles(dir string, maxAge time.Duration) int {
+	matches, err := filepath.Glob(filepath.Join(dir, tempFilePattern))
+	if err != nil {
+		return 0
+	}
+	manifests, _ := filepath.Glob(filepath.Join(dir, manifestFilePattern))
+	matches = append(matches, manifests...)
+	removed := 0
+	cutoff := time.Now().Add(-maxAge)
+	for _, path := range matches {
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() || info.ModTime().After(cutoff) {
+			continue
+		}
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// guiProcessStarter returns a processStarter that starts detached GUI
+// subprocesses, rotating the --log-file they write to at logMaxSize bytes
+func guiProcessStarter(logMaxSize int64) processStarter {
+	return func(args []string) error {
+		return startGUIProcess(args, logMaxSize)
+	}
+}
+
+// startGUIProcess starts a detached GUI subprocess with the given arguments
+func startGUIProcess(args []string, logMaxSize int64) error {
+	primary, err := os.Executable()
+	exe, err := resolveExecutable(primary, err, fileExists, exec.LookPath)
+	if err != nil {
+		return err
+	}
+
+	var output *os.File
+	if logFile != "" {
+		output, err = openLogFile(logFile, logMaxSize)
+		if err != nil {
+			return err
+		}
+		// The child has its own copy of the descriptor once started
+		defer output.Close()
+	}
+
+	if err := guiCommand(exe, args, output).Start(); err != nil {
+		return fmt.Errorf("failed to start GUI process: %w", err)
+	}
+	return nil
+}
+
+// errBinaryMoved is returned when the running binary can no longer be found
+// to start a GUI subprocess from, e.g. after an upgrade replaced it
+var errBinaryMoved = errors.New("fenestro binary moved; please restart")
+
+// deletedSuffix is appended by Linux to /proc/self/exe when the running
+// binary has been deleted or replaced
+const deletedSuffix = " (deleted)"
+
+// resolveExecutable picks the binary to start a GUI subprocess from. The
+// path from os.Executable is used while it still exists; if the binary was
+// moved or replaced while running, fenestro is looked up on PATH instead.
+func resolveExecutable(primary string, primaryErr error, exists func(string) bool, lookPath func(string) (string, error)) (string, error) {
+	if primaryErr == nil && !strings.HasSuffix(primary, deletedSuffix) && exists(primary) {
+		return primary, nil
+	}
+	if fallback, err := lookPath("fenestro"); err == nil {
+		return fallback, nil
+	}
+	if primaryErr != nil {
+		return "", fmt.Errorf("failed to get executable path: %w", primaryErr)
+	}
+	return "", errBinaryMoved
+}
+
+// fileExists reports whether path names an existing file
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// guiCommand builds the command for a detached GUI subprocess. The child's
+// output (including Wails logs) goes to output if set, otherwise to stderr.
+func guiCommand(exe string, args []string, output *os.File) *exec.Cmd {
+	cmd := exec.Command(exe, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid: true, // Create new session so child survives parent exit
+	}
+	cmd.Env = childEnv(os.Environ(), childDepth(currentSpawnDepth(), internalGUI))
+	// Don't inherit stdin (child reads from file), but keep stderr for errors
+	if output != nil {
+		cmd.Stdout = output
+		cmd.Stderr = output
+	} else {
+		cmd.Stderr = os.Stderr
+	}
+	return cmd
+}
+
+// openLogFile opens path for appending, first rotating it to path.1 if it has
+// grown past maxSize bytes (0 = never rotate)
+func openLogFile(path string, maxSize int64) (*os.File, error) {
+	if maxSize > 0 {
+		if info, err := os.Stat(path); err == nil && info.Size() >= maxSize {
+			if err := os.Rename(path, path+".1"); err != nil {
+				return nil, fmt.Errorf("failed to rotate log file: %w", err)
+			}
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return f, nil
+}
+
+// windowTitleFor returns the title for a window opened on entry: --title
+// when given, otherwise the entry's display name
+func windowTitleFor(title string, entry FileEntry) string {
+	if title != "" {
+		return title
+	}
+	return entry.Name
+}
+
+// runGUI runs the Wails application (called from GUI subprocess) with the
+// config main loaded
+func runGUI(entry FileEntry, windowID string, isWindowIDMode bool, anchor string, title string, config Config) {
+	// --geometry, --min-size and --no-local-files take precedence over
+	// config defaults (validated in main)
+	overrides := configOverrides{
+		MinSize:      minSize,
+		Geometry:     geometry,
+		NoLocalFiles: noLocalFiles,
+	}
+	config, _ = config.applyOverrides(overrides)
+
 	// Create app with the file entry
-	app := NewApp(entry, windowID)
+	app := newAppWithConfig(entry, windowID, config)
+	app.overrides = overrides
+	app.pendingAnchor = anchor
+	app.pendingLine = lineNumber
+	app.instance = instance
+	app.forcedContentType = contentType
+	app.safe = safeMode
+	app.printMedia = printCSS
+	if seed != nil {
+		app.seedSession(*seed)
+	}
 
 	// Load saved window state
-	state := LoadWindowState()
-	config := app.config
+	state := LoadWindowState(app.instance)
+
+	// --viewport (validated in main) replaces the saved content width
+	if viewport >= 0 {
+		app.viewportWidth = viewport
+	} else if state != nil {
+		app.viewportWidth = normalizeViewport(state.Viewport)
+	}
+	if state != nil {
+		app.zoom = normalizeZoom(state.Zoom)
+	}
+
+	// A forced geometry also wins over saved state
+	if geometry != "" && forceGeometry {
+		state = nil
+	}
 
 	// Determine window dimensions
 	width, height := GetWindowDimensions(state, config)
@@ -254,26 +1209,67 @@ func runGUI(entry FileEntry, windowID string, isWindowIDMode bool) {
 	} else {
 		ipcServer, err = StartSidebarServer(app)
 	}
+	if errors.Is(err, errSocketInUse) && !isWindowIDMode {
+		// Another sidebar window started first; hand the file to it instead
+		// of opening a second window
+		sent, sendErr := TrySendToSidebarInstance(entry, replaceOrAdd, lineNumber, config.IPCToken)
+		if sendErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", sendErr)
+			os.Exit(1)
+		}
+		if sent {
+			os.Exit(0)
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Could not start IPC server: %v\n", err)
 	}
+	app.ipcServer = ipcServer
+
+	// Poll the file for changes if --follow was given
+	if follow > 0 && entry.Path != "" && !tempFile {
+		app.followFile(entry.Path, follow)
+	}
+
+	// Re-read the file on SIGUSR1 if --reload-on-signal was given
+	var stopReloadSignal func()
+	if reloadSignal && entry.Path != "" && !tempFile {
+		stopReloadSignal = startReloadOnSignal(app.Reload)
+	}
+
+	// Restyle the window when the chrome CSS file changes
+	var cssFollower *FileFollower
+	if config.WatchChromeCSS && config.ChromeCSS != "" {
+		initial, _ := os.ReadFile(config.ChromeCSS)
+		cssFollower = NewFileFollower(config.ChromeCSS, chromeCSSPollInterval, string(initial), func(string) {
+			app.ReloadChromeCSS()
+		})
+		cssFollower.Start()
+	}
 
 	// Create local file handler for serving relative assets
 	localFileHandler := NewLocalFileHandler(app)
+	localFileHandler.disabled = noLocalFiles
 
-	// Run Wails application
-	err = wails.Run(&options.App{
-		Title:     entry.Name,
+	appOptions := &options.App{
+		Title:     windowTitleFor(title, entry),
 		Width:     width,
 		Height:    height,
-		MinWidth:  MinWindowWidth,
-		MinHeight: MinWindowHeight,
+		MinWidth:  app.minWidth,
+		MinHeight: app.minHeight,
 		AssetServer: &assetserver.Options{
 			Assets:  assets,
 			Handler: localFileHandler,
 		},
 		OnStartup: app.startup,
 		OnShutdown: func(ctx context.Context) {
+			app.stopFollowing()
+			if cssFollower != nil {
+				cssFollower.Stop()
+			}
+			if stopReloadSignal != nil {
+				stopReloadSignal()
+			}
 			if ipcServer != nil {
 				ipcServer.Close()
 			}
@@ -283,10 +1279,18 @@ func runGUI(entry FileEntry, windowID string, isWindowIDMode bool) {
 		},
 		Mac: &mac.Options{
 			TitleBar:             mac.TitleBarDefault(),
+			Appearance:           macAppearance(config.Theme),
 			WebviewIsTransparent: false,
 			WindowIsTranslucent:  false,
 		},
-	})
+	}
+	applyNoActivate(appOptions, noActivate, showInBackground)
+	if noActivate {
+		keepFrontmostApp()
+	}
+
+	// Run Wails application
+	err = wails.Run(appOptions)
 
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -0,0 +1,583 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	flag "github.com/spf13/pflag"
+)
+
+// TestMain runs fenestro's main instead of the tests when
+// FENESTRO_TEST_MAIN=1, so runMain can drive the command line in a
+// subprocess
+func TestMain(m *testing.M) {
+	if os.Getenv("FENESTRO_TEST_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs fenestro with args in a subprocess, returning what it wrote
+// to stderr and its exit code
+func runMain(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "FENESTRO_TEST_MAIN=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("running fenestro %v failed: %v", args, err)
+	}
+	return stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+func TestMainRejectsDisallowedExtension(t *testing.T) {
+	configDir := filepath.Join(useTempConfigDir(t), "fenestro")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(`allowed_extensions = [".html", ".md"]`+"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	useTempSocketDir(t)
+	script := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(script, []byte("echo hi\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	for _, args := range [][]string{{"-p", script}, {script}} {
+		stderr, code := runMain(t, args...)
+		if code != 1 {
+			t.Errorf("fenestro %v exited with %d, want 1", args, code)
+		}
+		want := "Error: file type not allowed: run.sh (allowed_extensions: .html, .md)"
+		if !strings.Contains(stderr, want) {
+			t.Errorf("fenestro %v stderr = %q, want %q", args, stderr, want)
+		}
+	}
+}
+
+func TestMainReportsWindowRejection(t *testing.T) {
+	useTempConfigDir(t)
+	useTempSocketDir(t)
+	app := NewApp(FileEntry{Name: "a.md", Path: "/tmp/a.md", Content: "<p>a</p>"}, "")
+	app.config.AllowedExtensions = []string{".md"}
+	startTestServer(t, app, getSidebarSocketPath())
+	page := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(page, []byte("<p>page</p>"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	stderr, code := runMain(t, "-p", page)
+	if code != 1 {
+		t.Errorf("fenestro exited with %d, want 1 when the window rejects the file", code)
+	}
+	want := "Error: window rejected add-file: file type not allowed: page.html"
+	if !strings.Contains(stderr, want) {
+		t.Errorf("stderr = %q, want %q", stderr, want)
+	}
+	if len(app.GetFiles()) != 1 {
+		t.Errorf("the rejected file was added: %+v", app.GetFiles())
+	}
+}
+
+func TestUsageTextIncludesUserFlags(t *testing.T) {
+	usage := usageText(flag.CommandLine)
+
+	for _, name := range []string{"--path", "--name", "--id", "--version", "--base-path", "--follow", "--group-by-dir", "--content-type", "--no-local-files", "--log-file", "--min-size", "--gc", "--diff", "--geometry", "--force-geometry", "--url", "--header", "--user-agent", "--instance", "--replace-or-add", "--stats", "--no-reuse", "--pipe-name", "--print-config", "--json", "--split", "--wait-render", "--viewport", "--add", "--line", "--no-activate", "--doctor", "--close-all", "--safe", "--reload-on-signal", "--title", "--print-css", "--select", "--screenshot", "--width", "--height", "--export-html"} {
+		if !strings.Contains(usage, name) {
+			t.Errorf("Usage text should include %s", name)
+		}
+	}
+}
+
+func TestUsageTextExcludesHiddenFlags(t *testing.T) {
+	usage := usageText(flag.CommandLine)
+
+	for _, name := range []string{"--internal-gui", "--temp-file"} {
+		if strings.Contains(usage, name) {
+			t.Errorf("Usage text should not include hidden flag %s", name)
+		}
+	}
+}
+
+func TestUsageTextIncludesModes(t *testing.T) {
+	usage := usageText(flag.CommandLine)
+
+	if !strings.Contains(usage, "Modes:") {
+		t.Error("Usage text should include a Modes section")
+	}
+	if !strings.Contains(usage, "Sidebar mode") || !strings.Contains(usage, "Window ID mode") {
+		t.Error("Usage text should describe sidebar and window ID modes")
+	}
+}
+
+// argValue returns the value following flag in args, or "" if absent
+func argValue(args []string, flag string) string {
+	for i, a := range args {
+		if a == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func hasArg(args []string, flag string) bool {
+	for _, a := range args {
+		if a == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGUIProcessArgsFile(t *testing.T) {
+	entry := FileEntry{Name: "a.html", Path: "/tmp/a.html", BasePath: "/tmp/assets"}
+
+	args, err := guiProcessArgs(entry, guiOptions{
+		Name:         "Report",
+		Title:        "Quarterly Report",
+		WindowID:     "abc-123",
+		Anchor:       "intro",
+		Follow:       2 * time.Second,
+		ContentType:  ContentTypeMarkdown,
+		NoLocalFiles: true,
+		LogFile:      "/tmp/fenestro.log",
+		MinSize:      "320x200",
+		Instance:     "docs",
+	})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+
+	if args[0] != "--internal-gui" {
+		t.Errorf("args[0] = %q, want --internal-gui", args[0])
+	}
+	if got := argValue(args, "-p"); got != "/tmp/a.html#intro" {
+		t.Errorf("-p = %q, want /tmp/a.html#intro", got)
+	}
+	if got := argValue(args, "-n"); got != "Report" {
+		t.Errorf("-n = %q, want Report", got)
+	}
+	if got := argValue(args, "--title"); got != "Quarterly Report" {
+		t.Errorf("--title = %q, want Quarterly Report", got)
+	}
+	if got := argValue(args, "--id"); got != "abc-123" {
+		t.Errorf("--id = %q, want abc-123", got)
+	}
+	if got := argValue(args, "--base-path"); got != "/tmp/assets" {
+		t.Errorf("--base-path = %q, want /tmp/assets", got)
+	}
+	if got := argValue(args, "--follow"); got != "2s" {
+		t.Errorf("--follow = %q, want 2s", got)
+	}
+	if got := argValue(args, "--content-type"); got != ContentTypeMarkdown {
+		t.Errorf("--content-type = %q, want %s", got, ContentTypeMarkdown)
+	}
+	if got := argValue(args, "--log-file"); got != "/tmp/fenestro.log" {
+		t.Errorf("--log-file = %q, want /tmp/fenestro.log", got)
+	}
+	if got := argValue(args, "--min-size"); got != "320x200" {
+		t.Errorf("--min-size = %q, want 320x200", got)
+	}
+	if got := argValue(args, "--instance"); got != "docs" {
+		t.Errorf("--instance = %q, want docs", got)
+	}
+	if !hasArg(args, "--no-local-files") {
+		t.Error("--no-local-files should be passed to the child")
+	}
+	if hasArg(args, "--temp-file") {
+		t.Error("file content should not use --temp-file")
+	}
+}
+
+func TestWindowTitleFor(t *testing.T) {
+	entry := FileEntry{Name: "results.html"}
+
+	if got := windowTitleFor("", entry); got != "results.html" {
+		t.Errorf("windowTitleFor(\"\") = %q, want the entry name", got)
+	}
+	if got := windowTitleFor("Search Results", entry); got != "Search Results" {
+		t.Errorf("windowTitleFor(title) = %q, want the --title value", got)
+	}
+}
+
+func TestGUIProcessArgsOmitsUnsetOptions(t *testing.T) {
+	args, err := guiProcessArgs(FileEntry{Path: "/tmp/a.html"}, guiOptions{})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+
+	want := []string{"--internal-gui", "-p", "/tmp/a.html"}
+	if strings.Join(args, " ") != strings.Join(want, " ") {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
+
+func TestGUIProcessArgsStdinUsesTempFile(t *testing.T) {
+	entry := FileEntry{Name: "stdin", Content: "<p>piped</p>"}
+
+	args, err := guiProcessArgs(entry, guiOptions{
+		FromStdin:   true,
+		Follow:      2 * time.Second,
+		ContentType: ContentTypeMarkdown,
+	})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+
+	tmpPath := argValue(args, "-p")
+	defer os.Remove(tmpPath)
+
+	content, err := os.ReadFile(tmpPath)
+	if err != nil {
+		t.Fatalf("failed to read temp file: %v", err)
+	}
+	if string(content) != entry.Content {
+		t.Errorf("temp file content = %q, want %q", content, entry.Content)
+	}
+	if !hasArg(args, "--temp-file") {
+		t.Error("stdin content should pass --temp-file")
+	}
+	if hasArg(args, "--follow") {
+		t.Error("stdin content should not be followed")
+	}
+	if hasArg(args, "--content-type") {
+		t.Error("stdin content is transformed before spawning and should not pass --content-type")
+	}
+}
+
+func TestGUICommandLogFile(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "fenestro.log")
+	output, err := openLogFile(logPath, 0)
+	if err != nil {
+		t.Fatalf("openLogFile() error = %v", err)
+	}
+	defer output.Close()
+
+	cmd := guiCommand("/usr/bin/fenestro", []string{"--internal-gui"}, output)
+
+	if cmd.Stdout != output || cmd.Stderr != output {
+		t.Error("child stdout and stderr should go to the log file")
+	}
+	if cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setsid {
+		t.Error("child should be started in a new session")
+	}
+}
+
+func TestGUICommandWithoutLogFile(t *testing.T) {
+	cmd := guiCommand("/usr/bin/fenestro", []string{"--internal-gui"}, nil)
+
+	if cmd.Stderr != os.Stderr {
+		t.Error("child stderr should go to the parent's stderr without a log file")
+	}
+	if cmd.Stdout != nil {
+		t.Error("child stdout should not be inherited without a log file")
+	}
+}
+
+func TestOpenLogFileRotates(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "fenestro.log")
+	if err := os.WriteFile(logPath, []byte("0123456789"), 0644); err != nil {
+		t.Fatalf("Could not write log file: %v", err)
+	}
+
+	// Below the limit: appended to
+	f, err := openLogFile(logPath, 100)
+	if err != nil {
+		t.Fatalf("openLogFile() error = %v", err)
+	}
+	f.WriteString("more")
+	f.Close()
+	if data, _ := os.ReadFile(logPath); string(data) != "0123456789more" {
+		t.Errorf("log content = %q, expected appended output", data)
+	}
+
+	// At the limit: rotated to .1 and started fresh
+	f, err = openLogFile(logPath, 10)
+	if err != nil {
+		t.Fatalf("openLogFile() error = %v", err)
+	}
+	f.Close()
+	if data, _ := os.ReadFile(logPath + ".1"); string(data) != "0123456789more" {
+		t.Errorf("rotated log content = %q, expected the previous log", data)
+	}
+	if info, err := os.Stat(logPath); err != nil || info.Size() != 0 {
+		t.Errorf("log file should be empty after rotation (err = %v)", err)
+	}
+}
+
+func TestTempFileArg(t *testing.T) {
+	if got := tempFileArg([]string{"--internal-gui", "-p", "/tmp/fenestro-1.html", "--temp-file"}); got != "/tmp/fenestro-1.html" {
+		t.Errorf("tempFileArg() = %q, want the -p path", got)
+	}
+	if got := tempFileArg([]string{"--internal-gui", "-p", "/tmp/a.html"}); got != "" {
+		t.Errorf("tempFileArg() without --temp-file = %q, want empty", got)
+	}
+}
+
+func TestLaunchGUIRemovesTempFileOnTimeout(t *testing.T) {
+	args, err := guiProcessArgs(FileEntry{Content: "<p>piped</p>"}, guiOptions{FromStdin: true})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	tmpPath := argValue(args, "-p")
+	defer os.Remove(tmpPath)
+
+	// The child "starts" but never creates its socket
+	start := func(args []string) error { return nil }
+	socketPath := filepath.Join(t.TempDir(), "never.sock")
+
+	if err := launchGUI(start, args, socketPath, 50*time.Millisecond); err == nil {
+		t.Error("launchGUI() should time out when the socket never appears")
+	}
+	if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+		t.Errorf("temp file should be removed after a startup timeout (err = %v)", err)
+	}
+}
+
+func TestLaunchGUIRemovesTempFileOnStartError(t *testing.T) {
+	args, err := guiProcessArgs(FileEntry{Content: "<p>piped</p>"}, guiOptions{FromStdin: true})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	tmpPath := argValue(args, "-p")
+	defer os.Remove(tmpPath)
+
+	start := func(args []string) error { return errors.New("exec failed") }
+	if err := launchGUI(start, args, filepath.Join(t.TempDir(), "never.sock"), time.Second); err == nil {
+		t.Error("launchGUI() should return the start error")
+	}
+	if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+		t.Errorf("temp file should be removed when the child fails to start (err = %v)", err)
+	}
+}
+
+func TestLaunchGUIKeepsTempFileForChild(t *testing.T) {
+	args, err := guiProcessArgs(FileEntry{Content: "<p>piped</p>"}, guiOptions{FromStdin: true})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	tmpPath := argValue(args, "-p")
+	defer os.Remove(tmpPath)
+
+	socketPath := filepath.Join(t.TempDir(), "ready.sock")
+	start := func(args []string) error {
+		return os.WriteFile(socketPath, nil, 0600)
+	}
+
+	if err := launchGUI(start, args, socketPath, time.Second); err != nil {
+		t.Fatalf("launchGUI() error = %v", err)
+	}
+	if _, err := os.Stat(tmpPath); err != nil {
+		t.Errorf("temp file should be left for the child to read: %v", err)
+	}
+}
+
+func TestSweepTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "fenestro-old.html")
+	recent := filepath.Join(dir, "fenestro-recent.html")
+	other := filepath.Join(dir, "other-old.html")
+	for _, path := range []string{old, recent, other} {
+		if err := os.WriteFile(path, []byte("<p>x</p>"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := time.Now().Add(-48 * time.Hour)
+	for _, path := range []string{old, other} {
+		if err := os.Chtimes(path, stale, stale); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if removed := sweepTempFiles(dir, 24*time.Hour); removed != 1 {
+		t.Errorf("sweepTempFiles() removed %d, want 1", removed)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("old fenestro temp file should be removed")
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Error("recent temp file should be kept")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Error("files not created by fenestro should be kept")
+	}
+}
+
+func TestResolvePathArg(t *testing.T) {
+	tests := []struct {
+		name        string
+		flagPath    string
+		args        []string
+		wantPath    string
+		wantWarning bool
+	}{
+		{"positional only", "", []string{"index.html"}, "index.html", false},
+		{"flag only", "report.html", nil, "report.html", false},
+		{"flag and positional", "report.html", []string{"index.html"}, "report.html", true},
+		{"extra positionals", "", []string{"a.html", "b.html"}, "a.html", true},
+		{"neither", "", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, warning := resolvePathArg(tt.flagPath, tt.args)
+			if path != tt.wantPath {
+				t.Errorf("resolvePathArg() path = %q, want %q", path, tt.wantPath)
+			}
+			if (warning != "") != tt.wantWarning {
+				t.Errorf("resolvePathArg() warning = %q, wantWarning %v", warning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestResolveWindowID(t *testing.T) {
+	const existing = "6f1c2a54-3b8e-4c1d-9a7e-2d5b8c9f0e13"
+	alive := func(id string) bool { return id == existing }
+	dead := func(id string) bool { return false }
+
+	tests := []struct {
+		name          string
+		id            string
+		noReuse       bool
+		alive         func(string) bool
+		wantGenerated bool
+	}{
+		{"live window is reused", existing, false, alive, false},
+		{"live window with --no-reuse gets a new ID", existing, true, alive, true},
+		{"dead window with --no-reuse keeps its ID", existing, true, dead, false},
+		{"new always generates", "new", false, dead, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, generated := resolveWindowID(tt.id, tt.noReuse, tt.alive)
+			if generated != tt.wantGenerated {
+				t.Fatalf("resolveWindowID() generated = %v, want %v", generated, tt.wantGenerated)
+			}
+			if !generated && id != tt.id {
+				t.Errorf("resolveWindowID() = %q, want %q", id, tt.id)
+			}
+			if generated {
+				if _, err := uuid.Parse(id); err != nil || id == existing {
+					t.Errorf("resolveWindowID() = %q, want a fresh UUID", id)
+				}
+			}
+		})
+	}
+}
+
+func TestWindowAliveWithLiveSocket(t *testing.T) {
+	id := uuid.New().String()
+	app := NewApp(FileEntry{Name: "initial"}, id)
+	server, err := StartWindowServer(app, id)
+	if err != nil {
+		t.Fatalf("StartWindowServer() error = %v", err)
+	}
+	defer server.Close()
+
+	if !windowAlive(id, "") {
+		t.Error("windowAlive() should report a window whose socket answers with its ID")
+	}
+	if newID, generated := resolveWindowID(id, true, func(id string) bool { return windowAlive(id, "") }); !generated || newID == id {
+		t.Errorf("resolveWindowID() with a live socket and --no-reuse = %q, %v; want a new ID", newID, generated)
+	}
+	server.Close()
+	if windowAlive(id, "") {
+		t.Error("windowAlive() should be false once the window is gone")
+	}
+}
+
+func TestResolveExecutable(t *testing.T) {
+	existing := map[string]bool{"/usr/local/bin/fenestro": true}
+	exists := func(path string) bool { return existing[path] }
+	onPath := func(string) (string, error) { return "/opt/bin/fenestro", nil }
+	notOnPath := func(string) (string, error) { return "", errors.New("not found") }
+
+	tests := []struct {
+		name       string
+		primary    string
+		primaryErr error
+		lookPath   func(string) (string, error)
+		want       string
+		wantErr    error
+	}{
+		{"primary exists", "/usr/local/bin/fenestro", nil, onPath, "/usr/local/bin/fenestro", nil},
+		{"primary moved, found on PATH", "/old/fenestro", nil, onPath, "/opt/bin/fenestro", nil},
+		{"primary deleted, found on PATH", "/usr/local/bin/fenestro (deleted)", nil, onPath, "/opt/bin/fenestro", nil},
+		{"primary moved, not on PATH", "/old/fenestro", nil, notOnPath, "", errBinaryMoved},
+		{"primary deleted, not on PATH", "/usr/local/bin/fenestro (deleted)", nil, notOnPath, "", errBinaryMoved},
+		{"no primary, found on PATH", "", errors.New("unsupported"), onPath, "/opt/bin/fenestro", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveExecutable(tt.primary, tt.primaryErr, exists, tt.lookPath)
+			if got != tt.want {
+				t.Errorf("resolveExecutable() = %q, want %q", got, tt.want)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("resolveExecutable() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveExecutableReportsPrimaryError(t *testing.T) {
+	notOnPath := func(string) (string, error) { return "", errors.New("not found") }
+	primaryErr := errors.New("unsupported platform")
+
+	_, err := resolveExecutable("", primaryErr, func(string) bool { return false }, notOnPath)
+	if !errors.Is(err, primaryErr) {
+		t.Errorf("resolveExecutable() error = %v, want it to wrap %v", err, primaryErr)
+	}
+}
+
+func TestResolveLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		flagLine int
+		wantPath string
+		wantLine int
+	}{
+		{"suffix", "build.log:120", 0, "build.log", 120},
+		{"flag", "build.log", 7, "build.log", 7},
+		{"flag wins over suffix", "build.log:120", 7, "build.log", 7},
+		{"neither", "build.log", 0, "build.log", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, line := resolveLine(tt.path, tt.flagLine)
+			if path != tt.wantPath || line != tt.wantLine {
+				t.Errorf("resolveLine(%q, %d) = (%q, %d), want (%q, %d)", tt.path, tt.flagLine, path, line, tt.wantPath, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestGUIProcessArgsLine(t *testing.T) {
+	args, err := guiProcessArgs(FileEntry{Path: "/tmp/build.log"}, guiOptions{Line: 120})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	if argValue(args, "--line") != "120" || argValue(args, "-p") != "/tmp/build.log" {
+		t.Errorf("args = %v, want -p with the bare path and --line 120", args)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+// applyNoActivate picks how the window first appears. Normally Wails shows
+// the window as soon as the app launches and makes fenestro the active app.
+// With --no-activate the window starts hidden and show is called once the
+// content has loaded instead, to put it on screen without taking focus (see
+// showInBackground).
+func applyNoActivate(opts *options.App, noActivate bool, show func(context.Context)) {
+	if !noActivate {
+		return
+	}
+	opts.StartHidden = true
+	opts.OnDomReady = show
+}
+
+// checkNoActivate reports whether --no-activate can be honored on goos.
+// Only macOS lets a window be ordered in without activating its app; GTK
+// has no equivalent Wails exposes, so elsewhere the flag is refused rather
+// than opening a window that takes focus anyway.
+func checkNoActivate(goos string) error {
+	if goos != "darwin" {
+		return fmt.Errorf("--no-activate is only supported on macOS, not %s", goos)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+#import <Cocoa/Cocoa.h>
+
+static NSRunningApplication *launchedFrom;
+
+// keepFrontmostApp hands activation back to the app that was active when
+// fenestro started (usually the terminal that ran it) once launching is
+// done. Wails activates the app when it finishes launching, so activation
+// is handed back after its handler has run.
+static void keepFrontmostApp(void) {
+	NSRunningApplication *front = [[NSWorkspace sharedWorkspace] frontmostApplication];
+	if (front == nil || [front isEqual:[NSRunningApplication currentApplication]]) {
+		return;
+	}
+	launchedFrom = [front retain];
+	[[NSNotificationCenter defaultCenter] addObserverForName:NSApplicationDidFinishLaunchingNotification
+		object:nil
+		queue:nil
+		usingBlock:^(NSNotification *note) {
+			dispatch_async(dispatch_get_main_queue(), ^{
+				[launchedFrom activateWithOptions:0];
+			});
+		}];
+}
+
+// orderFrontInBackground puts the app's window on screen without making it
+// key or activating the app
+static void orderFrontInBackground(void) {
+	dispatch_async(dispatch_get_main_queue(), ^{
+		for (NSWindow *window in [NSApp windows]) {
+			if ([window canBecomeMainWindow]) {
+				[window orderFrontRegardless];
+				break;
+			}
+		}
+	});
+}
+*/
+import "C"
+
+import "context"
+
+// keepFrontmostApp keeps the app that is active now active once the GUI has
+// launched (--no-activate). It must be called before wails.Run.
+func keepFrontmostApp() {
+	C.keepFrontmostApp()
+}
+
+// showInBackground shows a window that was started hidden without
+// activating fenestro: unlike WindowShow, which makes the window key and
+// activates the app, it's only ordered in.
+func showInBackground(ctx context.Context) {
+	C.orderFrontInBackground()
+}
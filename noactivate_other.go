@@ -0,0 +1,19 @@
+//go:build !darwin
+
+package main
+
+import (
+	"context"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// keepFrontmostApp does nothing: --no-activate is refused before the GUI
+// starts (see checkNoActivate)
+func keepFrontmostApp() {}
+
+// showInBackground shows a window that was started hidden. Only macOS can
+// do so without activating it, so this is a plain WindowShow.
+func showInBackground(ctx context.Context) {
+	runtime.WindowShow(ctx)
+}
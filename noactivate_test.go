@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wailsapp/wails/v2/pkg/options"
+)
+
+func TestApplyNoActivateDefault(t *testing.T) {
+	opts := &options.App{}
+	applyNoActivate(opts, false, func(context.Context) {
+		t.Error("show should not be used without --no-activate")
+	})
+	if opts.StartHidden {
+		t.Error("window should start visible without --no-activate")
+	}
+	if opts.OnDomReady != nil {
+		t.Error("OnDomReady should be left unset without --no-activate")
+	}
+}
+
+func TestApplyNoActivateEnabled(t *testing.T) {
+	opts := &options.App{}
+	shown := false
+	applyNoActivate(opts, true, func(context.Context) { shown = true })
+
+	if !opts.StartHidden {
+		t.Error("--no-activate should start the window hidden so Wails doesn't show and activate it")
+	}
+	if opts.OnDomReady == nil {
+		t.Fatal("--no-activate should show the window once the DOM is ready")
+	}
+	if shown {
+		t.Error("window should not be shown before the DOM is ready")
+	}
+	opts.OnDomReady(context.Background())
+	if !shown {
+		t.Error("OnDomReady should show the window in the background")
+	}
+}
+
+func TestCheckNoActivate(t *testing.T) {
+	if err := checkNoActivate("darwin"); err != nil {
+		t.Errorf("checkNoActivate(darwin) = %v, want nil", err)
+	}
+	for _, goos := range []string{"linux", "windows"} {
+		if err := checkNoActivate(goos); err == nil {
+			t.Errorf("checkNoActivate(%s) should refuse a flag it can't honor", goos)
+		}
+	}
+}
+
+func TestGUIProcessArgsNoActivate(t *testing.T) {
+	args, err := guiProcessArgs(FileEntry{Path: "/tmp/a.html"}, guiOptions{NoActivate: true})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	if !hasArg(args, "--no-activate") {
+		t.Errorf("args %v should pass --no-activate to the child", args)
+	}
+
+	args, err = guiProcessArgs(FileEntry{Path: "/tmp/a.html"}, guiOptions{})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	if hasArg(args, "--no-activate") {
+		t.Errorf("args %v should not include --no-activate by default", args)
+	}
+}
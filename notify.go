@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// notifier shows a native desktop notification (desktopNotify, replaced in
+// tests). It shouldn't block on the notification being dismissed.
+type notifier func(title, body string) error
+
+// SetWindowFocused is called by the frontend when the window gains or loses
+// focus, so background windows can announce updates (notify_on_replace)
+func (a *App) SetWindowFocused(focused bool) {
+	a.mu.Lock()
+	a.unfocused = !focused
+	a.mu.Unlock()
+}
+
+// shouldNotifyReplace reports whether replaced content is announced with a
+// desktop notification: only with notify_on_replace set, once the window is
+// running, and while it's in the background
+func shouldNotifyReplace(config Config, running, focused bool) bool {
+	return config.NotifyOnReplace && running && !focused
+}
+
+// notifyReplaced announces that the file called name was updated, if
+// shouldNotifyReplace says so. Failures are reported to stderr.
+func (a *App) notifyReplaced(name string) {
+	a.mu.RLock()
+	notify := shouldNotifyReplace(a.config, a.ctx != nil, !a.unfocused)
+	a.mu.RUnlock()
+	if !notify || a.notify == nil {
+		return
+	}
+	if err := a.notify("fenestro", name+" updated"); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not show notification: %v\n", err)
+	}
+}
+
+// desktopNotify shows a notification with osascript on macOS and
+// notify-send on Linux, without waiting for it to finish. Wails v2 has no
+// notification API of its own.
+func desktopNotify(title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux":
+		cmd = exec.Command("notify-send", title, body)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestShouldNotifyReplace(t *testing.T) {
+	enabled := Config{NotifyOnReplace: true}
+
+	tests := []struct {
+		name    string
+		config  Config
+		running bool
+		focused bool
+		want    bool
+	}{
+		{"background window", enabled, true, false, true},
+		{"focused window", enabled, true, true, false},
+		{"not running yet", enabled, false, false, false},
+		{"disabled", Config{}, true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldNotifyReplace(tt.config, tt.running, tt.focused); got != tt.want {
+				t.Errorf("shouldNotifyReplace() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// recordNotifications replaces app's notifier and returns the bodies it's
+// asked to show
+func recordNotifications(app *App) *[]string {
+	var bodies []string
+	app.notify = func(title, body string) error {
+		bodies = append(bodies, body)
+		return nil
+	}
+	return &bodies
+}
+
+func TestReplaceFileContentNotifiesInBackground(t *testing.T) {
+	app := NewApp(FileEntry{Name: "report.html", Path: "/tmp/report.html", Content: "<p>v1</p>"}, "")
+	app.config.NotifyOnReplace = true
+	recordEvents(app)
+	notified := recordNotifications(app)
+
+	app.ReplaceFileContent("/tmp/report.html", "<p>v2</p>", "")
+	if len(*notified) != 0 {
+		t.Fatalf("focused window notified %v, want nothing", *notified)
+	}
+
+	app.SetWindowFocused(false)
+	app.ReplaceFileContent("/tmp/report.html", "<p>v3</p>", "")
+	if len(*notified) != 1 || (*notified)[0] != "report.html updated" {
+		t.Errorf("notifications = %v, want one for report.html", *notified)
+	}
+
+	app.SetWindowFocused(true)
+	app.ReplaceFileContent("/tmp/report.html", "<p>v4</p>", "")
+	if len(*notified) != 1 {
+		t.Errorf("notifications = %v, refocused window should not notify", *notified)
+	}
+}
+
+func TestReplaceFileContentNoNotifyBeforeStartup(t *testing.T) {
+	app := NewApp(FileEntry{Name: "report.html", Path: "/tmp/report.html", Content: "<p>v1</p>"}, "")
+	app.config.NotifyOnReplace = true
+	app.SetWindowFocused(false)
+	notified := recordNotifications(app)
+
+	app.ReplaceFileContent("/tmp/report.html", "<p>v2</p>", "")
+
+	if len(*notified) != 0 {
+		t.Errorf("notifications = %v, want none without a running window", *notified)
+	}
+}
+
+func TestAppleScriptString(t *testing.T) {
+	if got := appleScriptString(`say "hi" \ bye`); got != `"say \"hi\" \\ bye"` {
+		t.Errorf("appleScriptString() = %s", got)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// previewLength caps the characters in a file's preview snippet
+const previewLength = 200
+
+var (
+	// previewHidden matches elements whose text is never displayed
+	previewHidden = regexp.MustCompile(`(?is)<(script|style|head|template)\b.*?</(script|style|head|template)\s*>`)
+	// previewComment matches HTML comments
+	previewComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// previewTag matches any remaining tag
+	previewTag = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// contentPreview returns the first previewLength characters of the visible
+// text in content, with tags stripped and whitespace collapsed. Truncated
+// previews end in an ellipsis.
+func contentPreview(content string) string {
+	text := previewHidden.ReplaceAllString(content, " ")
+	text = previewComment.ReplaceAllString(text, " ")
+	text = previewTag.ReplaceAllString(text, " ")
+	text = strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+
+	runes := []rune(text)
+	if len(runes) <= previewLength {
+		return text
+	}
+	return strings.TrimRight(string(runes[:previewLength]), " ") + "…"
+}
+
+// GetFileSummaries returns the file list without content, for UI that only
+// needs names. Each summary carries a short text preview of the file,
+// computed on request.
+func (a *App) GetFileSummaries() []fileSummary {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	summaries := make([]fileSummary, len(a.files))
+	for i := range a.files {
+		summaries[i] = a.files[i].summary()
+		summaries[i].Preview = contentPreview(a.files[i].content())
+	}
+	return summaries
+}
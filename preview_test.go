@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestContentPreview(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"strips tags", "<h1>Title</h1><p>Some <b>bold</b> text</p>", "Title Some bold text"},
+		{"collapses whitespace", "<p>one\n\n   two\t three</p>", "one two three"},
+		{"unescapes entities", "<p>a &amp; b &lt;c&gt;</p>", "a & b <c>"},
+		{"drops scripts and styles", "<style>p { color: red }</style><p>shown</p><script>alert(1)</script>", "shown"},
+		{"drops head", "<html><head><title>T</title></head><body>body</body></html>", "body"},
+		{"drops comments", "<!-- hidden --><p>shown</p>", "shown"},
+		{"tags spanning lines", "<div\n class=\"x\">text</div>", "text"},
+		{"plain text", "just text", "just text"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := contentPreview(tt.content); got != tt.want {
+				t.Errorf("contentPreview(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentPreviewLengthCap(t *testing.T) {
+	long := "<p>" + strings.Repeat("word ", 100) + "</p>"
+	got := contentPreview(long)
+	if !strings.HasSuffix(got, "…") {
+		t.Errorf("truncated preview should end in an ellipsis: %q", got)
+	}
+	if n := utf8.RuneCountInString(got); n > previewLength+1 {
+		t.Errorf("preview has %d characters, want at most %d", n, previewLength+1)
+	}
+
+	exact := strings.Repeat("x", previewLength)
+	if got := contentPreview(exact); got != exact {
+		t.Errorf("a preview of exactly %d characters should not be truncated", previewLength)
+	}
+
+	// Multi-byte text is cut on character boundaries
+	if got := contentPreview(strings.Repeat("é", previewLength+10)); !utf8.ValidString(got) {
+		t.Errorf("preview %q is not valid UTF-8", got)
+	}
+}
+
+func TestGetFileSummaries(t *testing.T) {
+	big := "<p>" + strings.Repeat("content ", 1000) + "</p>"
+	app := &App{files: []FileEntry{
+		{Name: "a.html", Path: "/tmp/a.html", Content: "<h1>Hello</h1>", ContentType: ContentTypeHTML, Updated: true},
+		{Name: "b.html", Path: "/tmp/b.html", Content: big},
+	}}
+
+	summaries := app.GetFileSummaries()
+	if len(summaries) != 2 {
+		t.Fatalf("GetFileSummaries() returned %d summaries, want 2", len(summaries))
+	}
+	first := summaries[0]
+	if first.Name != "a.html" || first.Path != "/tmp/a.html" || !first.Updated || first.ContentType != ContentTypeHTML {
+		t.Errorf("summary = %+v, want the entry's metadata", first)
+	}
+	if first.Preview != "Hello" {
+		t.Errorf("Preview = %q, want %q", first.Preview, "Hello")
+	}
+	if strings.Contains(summaries[1].Preview, "<p>") || len(summaries[1].Preview) >= len(big) {
+		t.Errorf("summary should carry a short preview, not the content: %q", summaries[1].Preview)
+	}
+}
+
+func TestGetFileSummariesCompressed(t *testing.T) {
+	app := &App{
+		config: Config{CompressInactive: true},
+		files:  []FileEntry{{Name: "a", Content: "<p>first</p>"}, {Name: "b", Content: "<p>second</p>"}},
+	}
+	app.compactFiles()
+
+	if got := app.GetFileSummaries()[1].Preview; got != "second" {
+		t.Errorf("Preview of a compressed file = %q, want %q", got, "second")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// styleElement matches a <style> element, capturing its opening tag,
+	// its CSS, and its closing tag
+	styleElement = regexp.MustCompile(`(?is)(<style\b[^>]*>)(.*?)(</style\s*>)`)
+	// linkTag matches a <link> tag, where stylesheet media attributes live
+	linkTag = regexp.MustCompile(`(?i)<link\b[^>]*>`)
+	// mediaAttr matches a media="..." attribute, capturing the query list
+	mediaAttr = regexp.MustCompile(`(?i)(\bmedia\s*=\s*)("[^"]*"|'[^']*')`)
+	// mediaRule matches the prelude of an @media rule up to its brace
+	mediaRule = regexp.MustCompile(`(?i)@media\s+([^{;]+)\{`)
+)
+
+// emulatePrintMedia rewrites the document's stylesheets so they apply as
+// they would when printing: print media queries match and screen ones
+// don't. Wails doesn't expose the webview's media type, so this covers
+// <style> elements and media attributes on <link> and <style>; @media rules
+// inside linked stylesheets are left as-is.
+func emulatePrintMedia(content string) string {
+	content = styleElement.ReplaceAllStringFunc(content, func(element string) string {
+		parts := styleElement.FindStringSubmatch(element)
+		css := mediaRule.ReplaceAllStringFunc(parts[2], func(rule string) string {
+			query := mediaRule.FindStringSubmatch(rule)[1]
+			return "@media " + printMediaQuery(query) + " {"
+		})
+		return rewriteMediaAttr(parts[1]) + css + parts[3]
+	})
+	return linkTag.ReplaceAllStringFunc(content, rewriteMediaAttr)
+}
+
+// rewriteMediaAttr applies printMediaQuery to a tag's media attribute
+func rewriteMediaAttr(tag string) string {
+	return mediaAttr.ReplaceAllStringFunc(tag, func(attr string) string {
+		parts := mediaAttr.FindStringSubmatch(attr)
+		quote := parts[2][:1]
+		query := parts[2][1 : len(parts[2])-1]
+		return parts[1] + quote + printMediaQuery(query) + quote
+	})
+}
+
+// printMediaQuery rewrites a media query list as it evaluates for print:
+// queries for print (or all) become "all", keeping any feature conditions,
+// and queries for other media types become "not all". A negated query keeps
+// its conditions when its type is print, since the negation then depends on
+// them. Queries without a media type are kept unchanged.
+func printMediaQuery(list string) string {
+	queries := strings.Split(list, ",")
+	for i, query := range queries {
+		queries[i] = printQuery(strings.TrimSpace(query))
+	}
+	return strings.Join(queries, ", ")
+}
+
+// printQuery rewrites a single media query for printMediaQuery
+func printQuery(query string) string {
+	words := strings.Fields(query)
+	negated := false
+	if len(words) > 0 && (strings.EqualFold(words[0], "only") || strings.EqualFold(words[0], "not")) {
+		negated = strings.EqualFold(words[0], "not")
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return query
+	}
+
+	var matches bool
+	switch strings.ToLower(words[0]) {
+	case "print", "all":
+		matches = true
+	case "screen", "speech", "tty", "tv", "projection", "handheld", "braille", "embossed", "aural":
+		matches = false
+	default:
+		// A bare condition such as (min-width: 600px) has no media type
+		return query
+	}
+
+	switch {
+	case negated && matches:
+		// The negation covers the conditions too, so they still decide it:
+		// not print and (color) holds when printing without color
+		return strings.Join(append([]string{"not", "all"}, words[1:]...), " ")
+	case negated:
+		// The negation covers the conditions too, so it holds for print
+		return "all"
+	case matches:
+		return strings.Join(append([]string{"all"}, words[1:]...), " ")
+	default:
+		return "not all"
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrintMediaQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"print", "all"},
+		{"screen", "not all"},
+		{"only screen and (min-width: 600px)", "not all"},
+		{"print and (orientation: landscape)", "all and (orientation: landscape)"},
+		{"not print", "not all"},
+		{"not screen and (color)", "all"},
+		{"not print and (color)", "not all and (color)"},
+		{"not all and (orientation: portrait)", "not all and (orientation: portrait)"},
+		{"screen, print", "not all, all"},
+		{"(min-width: 600px)", "(min-width: 600px)"},
+		{"all", "all"},
+	}
+
+	for _, tt := range tests {
+		if got := printMediaQuery(tt.query); got != tt.want {
+			t.Errorf("printMediaQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestEmulatePrintMedia(t *testing.T) {
+	content := `<html><head>` +
+		`<link rel="stylesheet" href="print.css" media="print">` +
+		`<link rel="stylesheet" href='screen.css' media='screen'>` +
+		`<style media="screen">nav { display: block; }</style>` +
+		`<style>@media print { nav { display: none; } } @media screen and (max-width: 600px) { body { margin: 0; } }</style>` +
+		`</head><body><p>@media print { stays }</p></body></html>`
+
+	got := emulatePrintMedia(content)
+
+	for _, want := range []string{
+		`href="print.css" media="all"`,
+		`href='screen.css' media='not all'`,
+		`<style media="not all">nav`,
+		`@media all { nav { display: none; } }`,
+		`@media not all { body { margin: 0; } }`,
+		`<p>@media print { stays }</p>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("emulatePrintMedia() = %q, should contain %q", got, want)
+		}
+	}
+}
+
+func TestDisplayContentPrintMedia(t *testing.T) {
+	content := `<style>@media print { h1 { color: black; } }</style><h1>Report</h1>`
+	app := NewApp(FileEntry{Name: "a.html", Content: content, ContentType: ContentTypeHTML}, "")
+
+	if got := app.GetHTMLContent(); !strings.Contains(got, "@media print") {
+		t.Errorf("GetHTMLContent() = %q, should leave media queries alone by default", got)
+	}
+
+	app.printMedia = true
+	if got := app.GetHTMLContent(); !strings.Contains(got, "@media all {") {
+		t.Errorf("GetHTMLContent() = %q, should apply print rules with --print-css", got)
+	}
+}
+
+func TestGUIProcessArgsPrintCSS(t *testing.T) {
+	args, err := guiProcessArgs(FileEntry{Path: "/tmp/a.html"}, guiOptions{PrintCSS: true})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	if !hasArg(args, "--print-css") {
+		t.Errorf("args = %v, want --print-css passed to the child", args)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// maxRecentFiles caps the number of paths kept in the recents list
+const maxRecentFiles = 20
+
+// getRecentsPath returns the path to the recents file
+func getRecentsPath() string {
+	configDir := getConfigDir()
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "recents.json")
+}
+
+// LoadRecentFiles loads the recently opened file paths, most recent first
+// Returns nil if no recents exist or can't be read
+func LoadRecentFiles() []string {
+	recentsPath := getRecentsPath()
+	if recentsPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(recentsPath)
+	if err != nil {
+		// File doesn't exist or can't be read - that's fine
+		return nil
+	}
+
+	var recents []string
+	if err := json.Unmarshal(data, &recents); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to parse recents file %s: %v\n", recentsPath, err)
+		return nil
+	}
+
+	return recents
+}
+
+// SaveRecentFiles saves the recently opened file paths to the recents file
+func SaveRecentFiles(recents []string) error {
+	recentsPath := getRecentsPath()
+	if recentsPath == "" {
+		return fmt.Errorf("could not determine recents file path")
+	}
+
+	// Ensure config directory exists
+	configDir := filepath.Dir(recentsPath)
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(recents, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal recents: %w", err)
+	}
+
+	if err := os.WriteFile(recentsPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write recents file: %w", err)
+	}
+
+	return nil
+}
+
+// RecordRecentFile moves path to the front of the persisted recents list.
+// Entries without a path (stdin) are ignored.
+func RecordRecentFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	return SaveRecentFiles(addRecent(LoadRecentFiles(), path, maxRecentFiles))
+}
+
+// rememberFile records path as RecordRecentFile does, unless recents_enabled
+// is off
+func rememberFile(path string, config Config) error {
+	if !config.RecentsEnabled {
+		return nil
+	}
+	return RecordRecentFile(path)
+}
+
+// ClearRecents empties the recents list and sends the empty list to the
+// frontend with a recents-changed event
+func (a *App) ClearRecents() error {
+	if err := SaveRecentFiles([]string{}); err != nil {
+		return err
+	}
+	a.emitEvent("recents-changed", []string{})
+	return nil
+}
+
+// addRecent returns recents with path moved to the front, without duplicates,
+// capped at max entries
+func addRecent(recents []string, path string, max int) []string {
+	result := []string{path}
+	for _, p := range recents {
+		if len(result) >= max {
+			break
+		}
+		if p != path {
+			result = append(result, p)
+		}
+	}
+	return result
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useTempConfigDir points XDG_CONFIG_HOME at a fresh temp directory for the
+// duration of the test
+func useTempConfigDir(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+	return tmpDir
+}
+
+func TestAddRecent(t *testing.T) {
+	tests := []struct {
+		name     string
+		recents  []string
+		path     string
+		max      int
+		expected []string
+	}{
+		{"empty list", nil, "/a.html", 3, []string{"/a.html"}},
+		{"prepends", []string{"/a.html"}, "/b.html", 3, []string{"/b.html", "/a.html"}},
+		{"dedupes", []string{"/a.html", "/b.html", "/c.html"}, "/b.html", 3, []string{"/b.html", "/a.html", "/c.html"}},
+		{"caps", []string{"/a.html", "/b.html", "/c.html"}, "/d.html", 3, []string{"/d.html", "/a.html", "/b.html"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := addRecent(tt.recents, tt.path, tt.max)
+			if fmt.Sprint(result) != fmt.Sprint(tt.expected) {
+				t.Errorf("addRecent() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadRecentFilesNoFile(t *testing.T) {
+	useTempConfigDir(t)
+
+	if recents := LoadRecentFiles(); recents != nil {
+		t.Errorf("Expected nil recents when file doesn't exist, got %v", recents)
+	}
+}
+
+func TestRecordRecentFileRoundTrip(t *testing.T) {
+	useTempConfigDir(t)
+
+	for _, path := range []string{"/a.html", "/b.html", "/a.html"} {
+		if err := RecordRecentFile(path); err != nil {
+			t.Fatalf("RecordRecentFile(%q) error = %v", path, err)
+		}
+	}
+
+	expected := []string{"/a.html", "/b.html"}
+	if recents := LoadRecentFiles(); fmt.Sprint(recents) != fmt.Sprint(expected) {
+		t.Errorf("LoadRecentFiles() = %v, expected %v", recents, expected)
+	}
+}
+
+func TestRecordRecentFileCaps(t *testing.T) {
+	useTempConfigDir(t)
+
+	for i := 0; i < maxRecentFiles+5; i++ {
+		if err := RecordRecentFile(fmt.Sprintf("/file%d.html", i)); err != nil {
+			t.Fatalf("RecordRecentFile() error = %v", err)
+		}
+	}
+
+	recents := LoadRecentFiles()
+	if len(recents) != maxRecentFiles {
+		t.Fatalf("len(recents) = %d, expected %d", len(recents), maxRecentFiles)
+	}
+	if want := fmt.Sprintf("/file%d.html", maxRecentFiles+4); recents[0] != want {
+		t.Errorf("recents[0] = %q, expected %q", recents[0], want)
+	}
+}
+
+func TestRecordRecentFileIgnoresStdin(t *testing.T) {
+	configDir := useTempConfigDir(t)
+
+	if err := RecordRecentFile(""); err != nil {
+		t.Fatalf("RecordRecentFile(\"\") error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(configDir, "fenestro", "recents.json")); !os.IsNotExist(err) {
+		t.Error("stdin entries should not create a recents file")
+	}
+}
+
+func TestRememberFileSkippedWhenDisabled(t *testing.T) {
+	configDir := useTempConfigDir(t)
+	config := DefaultConfig()
+	config.RecentsEnabled = false
+
+	if err := rememberFile("/docs/a.html", config); err != nil {
+		t.Fatalf("rememberFile() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "fenestro", "recents.json")); !os.IsNotExist(err) {
+		t.Error("recording should be skipped with recents_enabled = false")
+	}
+
+	config.RecentsEnabled = true
+	if err := rememberFile("/docs/a.html", config); err != nil {
+		t.Fatalf("rememberFile() error = %v", err)
+	}
+	if recents := LoadRecentFiles(); len(recents) != 1 || recents[0] != "/docs/a.html" {
+		t.Errorf("LoadRecentFiles() = %v, want the recorded file", recents)
+	}
+}
+
+func TestClearRecents(t *testing.T) {
+	configDir := useTempConfigDir(t)
+	for _, path := range []string{"/docs/a.html", "/docs/b.html"} {
+		if err := RecordRecentFile(path); err != nil {
+			t.Fatalf("RecordRecentFile(%q) error = %v", path, err)
+		}
+	}
+	app := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, "")
+	rec := recordEvents(app)
+
+	if err := app.ClearRecents(); err != nil {
+		t.Fatalf("ClearRecents() error = %v", err)
+	}
+
+	if recents := app.GetRecentFiles(); len(recents) != 0 {
+		t.Errorf("GetRecentFiles() = %v, want an empty list", recents)
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "fenestro", "recents.json"))
+	if err != nil || strings.TrimSpace(string(data)) != "[]" {
+		t.Errorf("recents file = %q, %v, want an empty list", data, err)
+	}
+	events := rec.named("recents-changed")
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 recents-changed event, got %d", len(events))
+	}
+	if list, _ := events[0].data[0].([]string); list == nil || len(list) != 0 {
+		t.Errorf("recents-changed data = %#v, want an empty list", events[0].data[0])
+	}
+}
+
+func TestOpenRecent(t *testing.T) {
+	useTempConfigDir(t)
+	path := filepath.Join(t.TempDir(), "report.html")
+	if err := os.WriteFile(path, []byte("<p>report</p>"), 0644); err != nil {
+		t.Fatalf("Could not write file: %v", err)
+	}
+
+	app := NewApp(FileEntry{Name: "stdin", Content: "<p>piped</p>"}, "")
+	if err := app.OpenRecent(path); err != nil {
+		t.Fatalf("OpenRecent() error = %v", err)
+	}
+
+	if got := app.GetHTMLContent(); got != "<p>report</p>" {
+		t.Errorf("GetHTMLContent() = %q, expected the recent file's content", got)
+	}
+	if recents := app.GetRecentFiles(); len(recents) != 1 || recents[0] != path {
+		t.Errorf("GetRecentFiles() = %v, expected [%s]", recents, path)
+	}
+}
+
+func TestOpenRecentAppliesSafe(t *testing.T) {
+	useTempConfigDir(t)
+	path := filepath.Join(t.TempDir(), "report.html")
+	if err := os.WriteFile(path, []byte("<p>report</p><script>alert(1)</script>"), 0644); err != nil {
+		t.Fatalf("Could not write file: %v", err)
+	}
+
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "")
+	app.safe = true
+	if err := app.OpenRecent(path); err != nil {
+		t.Fatalf("OpenRecent() error = %v", err)
+	}
+
+	got := app.GetHTMLContent()
+	if strings.Contains(got, "<script") || !strings.Contains(got, "<p>report</p>") {
+		t.Errorf("GetHTMLContent() = %q, want the report without its script in a --safe window", got)
+	}
+}
+
+func TestOpenRecentMissingFile(t *testing.T) {
+	useTempConfigDir(t)
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html"}, "")
+
+	if err := app.OpenRecent(filepath.Join(t.TempDir(), "missing.html")); err == nil {
+		t.Error("OpenRecent() should fail for a missing file")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Reload re-reads the selected file from disk and re-renders it, keeping its
+// name and base path. Content without a path (stdin) can't be reloaded.
+func (a *App) Reload() error {
+	a.mu.RLock()
+	path := ""
+	if a.currentIndex >= 0 && a.currentIndex < len(a.files) {
+		path = a.files[a.currentIndex].Path
+	}
+	forced, safe, config := a.forcedContentType, a.safe, a.config
+	a.mu.RUnlock()
+
+	if path == "" {
+		return fmt.Errorf("the current file has no path to reload from")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	entry := FileEntry{Path: path, Content: string(content), ContentType: loadContentType(path)}
+	applyContentType(&entry, forced, config.NormalizeLineEndings)
+	sanitizeEntry(&entry, false, safe, config)
+	a.replaceEntry(FileEntry{Path: path, Content: entry.Content})
+	return nil
+}
+
+// handleReloadSignals calls reload for each signal received until signals is
+// closed. Failures are reported to stderr; the window keeps running.
+func handleReloadSignals(signals <-chan os.Signal, reload func() error, stderr io.Writer) {
+	for range signals {
+		if err := reload(); err != nil {
+			fmt.Fprintf(stderr, "Warning: reload on signal failed: %v\n", err)
+		}
+	}
+}
+
+// startReloadOnSignal calls reload whenever the process receives SIGUSR1
+// (--reload-on-signal). The returned function stops listening.
+func startReloadOnSignal(reload func() error) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	go handleReloadSignals(signals, reload, os.Stderr)
+	return func() {
+		signal.Stop(signals)
+		close(signals)
+	}
+}
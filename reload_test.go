@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReloadRereadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.md")
+	os.WriteFile(path, []byte("# Old"), 0644)
+	entry := FileEntry{Name: "Build report", Path: path, Content: "# Old", ContentType: ContentTypeMarkdown}
+	applyContentType(&entry, "", true)
+	app := NewApp(entry, "")
+	rec := recordEvents(app)
+
+	os.WriteFile(path, []byte("# New"), 0644)
+	if err := app.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if got := app.GetHTMLContent(); !strings.Contains(got, "<h1>New</h1>") {
+		t.Errorf("GetHTMLContent() = %q, want the re-read file rendered as Markdown", got)
+	}
+	if got := app.GetCurrentFileName(); got != "Build report" {
+		t.Errorf("name = %q, want the display name kept", got)
+	}
+	if len(rec.named("content-replaced")) != 1 {
+		t.Error("Reload() should tell the frontend to re-render")
+	}
+}
+
+func TestReloadAppliesSafe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.html")
+	os.WriteFile(path, []byte("<p>a</p>"), 0644)
+	app := NewApp(FileEntry{Name: "a.html", Path: path, Content: "<p>a</p>"}, "")
+	app.safe = true
+
+	os.WriteFile(path, []byte("<p>b</p><script>alert(1)</script>"), 0644)
+	if err := app.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := app.GetHTMLContent(); !strings.Contains(got, "<p>b</p>") || strings.Contains(got, "<script") {
+		t.Errorf("GetHTMLContent() = %q, want --safe reapplied", got)
+	}
+}
+
+func TestReloadWithoutPath(t *testing.T) {
+	app := NewApp(FileEntry{Name: "stdin", Content: "<p>piped</p>"}, "")
+	if err := app.Reload(); err == nil {
+		t.Error("Reload() should fail for content without a path")
+	}
+}
+
+func TestHandleReloadSignals(t *testing.T) {
+	signals := make(chan os.Signal, 2)
+	reloads := 0
+	reload := func() error {
+		reloads++
+		if reloads == 2 {
+			return errors.New("file is gone")
+		}
+		return nil
+	}
+	signals <- syscall.SIGUSR1
+	signals <- syscall.SIGUSR1
+	close(signals)
+
+	var stderr bytes.Buffer
+	handleReloadSignals(signals, reload, &stderr)
+	if reloads != 2 {
+		t.Errorf("reload called %d times, want once per signal", reloads)
+	}
+	if !strings.Contains(stderr.String(), "file is gone") {
+		t.Errorf("stderr = %q, want the failed reload reported", stderr.String())
+	}
+}
+
+func TestStartReloadOnSignal(t *testing.T) {
+	reloaded := make(chan struct{}, 1)
+	stop := startReloadOnSignal(func() error {
+		reloaded <- struct{}{}
+		return nil
+	})
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("SIGUSR1 should trigger a reload")
+	}
+}
+
+func TestGUIProcessArgsReloadOnSignal(t *testing.T) {
+	args, err := guiProcessArgs(FileEntry{Path: "/tmp/a.html"}, guiOptions{ReloadSignal: true})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	if !hasArg(args, "--reload-on-signal") {
+		t.Errorf("args %v should pass --reload-on-signal to the child", args)
+	}
+
+	args, err = guiProcessArgs(FileEntry{Content: "<p>piped</p>"}, guiOptions{FromStdin: true, ReloadSignal: true})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	defer os.Remove(tempFileArg(args))
+	if hasArg(args, "--reload-on-signal") {
+		t.Errorf("args %v: piped content has no file to reload", args)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// renderTimeout bounds how long a wait-render command waits for the frontend
+const renderTimeout = 10 * time.Second
+
+// renderState tracks whether the frontend has rendered the window's current
+// content. Each content change moves it to pending and NotifyRendered moves
+// it to rendered, waking any waiters. The zero value is pending, since a new
+// window hasn't rendered anything yet.
+type renderState struct {
+	mu        sync.Mutex
+	rendered  bool
+	done      chan struct{} // closed when the pending content is rendered
+	callbacks []func()
+}
+
+// doneChan returns the channel for the current pending content. Must be
+// called with r.mu held.
+func (r *renderState) doneChan() chan struct{} {
+	if r.done == nil {
+		r.done = make(chan struct{})
+		if r.rendered {
+			close(r.done)
+		}
+	}
+	return r.done
+}
+
+// markPending records that new content is waiting to be rendered
+func (r *renderState) markPending() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rendered {
+		r.rendered = false
+		r.done = nil
+	}
+}
+
+// markRendered records that the current content is on screen and returns
+// the callbacks to run
+func (r *renderState) markRendered() []func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.rendered {
+		close(r.doneChan())
+		r.rendered = true
+	}
+	return append([]func(){}, r.callbacks...)
+}
+
+// wait blocks until the current content is rendered or timeout passes
+func (r *renderState) wait(timeout time.Duration) error {
+	r.mu.Lock()
+	done := r.doneChan()
+	r.mu.Unlock()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("timed out waiting for the content to render")
+	}
+}
+
+// NotifyRendered is called by the frontend once the current content's DOM
+// has settled. It releases any wait-render senders and runs the callbacks
+// registered with OnContentRendered.
+func (a *App) NotifyRendered() {
+	for _, fn := range a.render.markRendered() {
+		fn()
+	}
+}
+
+// OnContentRendered registers fn to be called each time the frontend
+// reports that it has rendered the current content
+func (a *App) OnContentRendered(fn func()) {
+	a.render.mu.Lock()
+	defer a.render.mu.Unlock()
+	a.render.callbacks = append(a.render.callbacks, fn)
+}
+
+// WaitRendered blocks until the frontend has rendered the current content.
+// Content that was already rendered returns immediately.
+func (a *App) WaitRendered(timeout time.Duration) error {
+	return a.render.wait(timeout)
+}
+
+// waitForRender asks the window behind socketPath to reply once its current
+// content is rendered (--wait-render)
+func waitForRender(socketPath, token string) error {
+	resp, err := sendCommandTimeout(socketPath, IPCCommand{Cmd: "wait-render", Token: token}, renderTimeout+time.Second)
+	if err != nil {
+		return err
+	}
+	if !resp.OK {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
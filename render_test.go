@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRenderStateStartsPending(t *testing.T) {
+	var r renderState
+	if err := r.wait(10 * time.Millisecond); err == nil {
+		t.Error("a new window should be pending until the frontend renders")
+	}
+}
+
+func TestRenderStatePendingToRendered(t *testing.T) {
+	var r renderState
+
+	result := make(chan error, 1)
+	go func() { result <- r.wait(time.Second) }()
+	time.Sleep(20 * time.Millisecond)
+	r.markRendered()
+
+	if err := <-result; err != nil {
+		t.Fatalf("wait() error = %v, want it released by markRendered", err)
+	}
+	if err := r.wait(time.Millisecond); err != nil {
+		t.Errorf("wait() on rendered content error = %v, want immediate return", err)
+	}
+}
+
+func TestRenderStateNewContentIsPendingAgain(t *testing.T) {
+	var r renderState
+	r.markRendered()
+	r.markPending()
+
+	if err := r.wait(10 * time.Millisecond); err == nil {
+		t.Error("content changed after a render should be pending")
+	}
+	r.markRendered()
+	if err := r.wait(time.Millisecond); err != nil {
+		t.Errorf("wait() error = %v after the new content rendered", err)
+	}
+}
+
+func TestRenderStateRepeatedTransitions(t *testing.T) {
+	var r renderState
+	// Repeated notifications and changes must not double-close the channel
+	r.markRendered()
+	r.markRendered()
+	r.markPending()
+	r.markPending()
+	r.markRendered()
+	if err := r.wait(time.Millisecond); err != nil {
+		t.Errorf("wait() error = %v", err)
+	}
+}
+
+func TestContentChangesMarkPending(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(app *App)
+	}{
+		{"select", func(app *App) { app.SelectFile(1) }},
+		{"select by path", func(app *App) { app.SetCurrentByPath("/b.html") }},
+		{"replace", func(app *App) { app.ReplaceFileContent("/a.html", "new", "") }},
+		{"set files", func(app *App) { app.SetFiles([]FileEntry{{Name: "c"}}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{files: []FileEntry{{Name: "a", Path: "/a.html"}, {Name: "b", Path: "/b.html"}}}
+			app.NotifyRendered()
+			tt.change(app)
+			if err := app.WaitRendered(10 * time.Millisecond); err == nil {
+				t.Error("content change should leave the window pending a render")
+			}
+		})
+	}
+}
+
+func TestAddFileKeepsRendered(t *testing.T) {
+	app := &App{files: []FileEntry{{Name: "a"}}}
+	app.NotifyRendered()
+	// New files only appear in the sidebar; the displayed content is unchanged
+	app.AddFile(FileEntry{Name: "b"})
+	if err := app.WaitRendered(time.Millisecond); err != nil {
+		t.Errorf("WaitRendered() error = %v after add-file", err)
+	}
+}
+
+func TestOnContentRendered(t *testing.T) {
+	app := &App{}
+	calls := 0
+	app.OnContentRendered(func() { calls++ })
+
+	app.NotifyRendered()
+	app.NotifyRendered()
+	if calls != 2 {
+		t.Errorf("callback ran %d times, want once per notification", calls)
+	}
+}
+
+func TestIPCServerWaitRender(t *testing.T) {
+	app := NewApp(FileEntry{Name: "initial", Content: "<p>initial</p>"}, "")
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-waitrender.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		app.NotifyRendered()
+	}()
+
+	start := time.Now()
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "replace", Name: "initial", Content: "<p>new</p>", WaitRender: true})
+	if err != nil {
+		t.Fatalf("SendCommand() failed: %v", err)
+	}
+	if !resp.OK {
+		t.Fatalf("replace with wait_render failed: %s", resp.Error)
+	}
+	if time.Since(start) < 40*time.Millisecond {
+		t.Error("response should wait for the frontend to render")
+	}
+
+	// The content is now rendered, so wait-render answers right away
+	if err := waitForRender(socketPath, ""); err != nil {
+		t.Errorf("waitForRender() error = %v", err)
+	}
+}
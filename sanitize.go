@@ -0,0 +1,292 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// sanitizeCSP is the Content-Security-Policy a sanitized document carries,
+// so script that somehow got past the sanitizer still can't run wherever the
+// document is loaded as a page of its own (an --export-html copy, say)
+const sanitizeCSP = "script-src 'none'"
+
+// maxSanitizePasses bounds how often sanitized output is parsed again to
+// check that a browser reading it back sees the same, safe tree
+const maxSanitizePasses = 3
+
+// stringSet returns the space-separated words as a set
+func stringSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+var (
+	// allowedElements are the HTML, SVG and MathML elements sanitized content
+	// keeps, by namespace and lowercase name
+	allowedElements = map[string]map[string]bool{
+		"": stringSet(`html head body title meta link style base
+			a abbr address area article aside audio b bdi bdo big blockquote br button
+			canvas caption center cite code col colgroup data datalist dd del details
+			dfn dialog div dl dt em fieldset figcaption figure font footer form
+			h1 h2 h3 h4 h5 h6 header hgroup hr i img input ins kbd label legend li main
+			map mark menu meter nav ol optgroup option output p picture pre progress q
+			rp rt ruby s samp section select small source span strike strong sub
+			summary sup table tbody td textarea tfoot th thead time tr track tt u ul
+			var video wbr`),
+		"svg": stringSet(`svg a g defs symbol use path rect circle ellipse line polyline
+			polygon text tspan textpath image lineargradient radialgradient stop
+			clippath mask pattern marker title desc filter foreignobject
+			feblend fecolormatrix fecomposite fedropshadow feflood fegaussianblur
+			femerge femergenode femorphology feoffset`),
+		"math": stringSet(`math mi mn mo ms mtext mrow mspace mstyle mpadded mphantom
+			menclose mfrac msqrt mroot msub msup msubsup munder mover munderover
+			mtable mtr mtd semantics annotation`),
+	}
+	// droppedElements are removed along with everything inside them, since
+	// what they hold is script, a separate document, or only shown without
+	// script. Other HTML elements outside allowedElements are unwrapped,
+	// keeping their content.
+	droppedElements = stringSet(`script noscript template iframe frame frameset
+		object embed applet noembed noframes xmp plaintext`)
+	// allowedAttrs are the attributes sanitized content keeps, besides data-*
+	// and aria-* ones and the URL attributes in urlAttrs
+	allowedAttrs = stringSet(`id class style title lang dir hidden tabindex
+		accesskey translate draggable spellcheck role slot
+		abbr accept align alt autocomplete autoplay bgcolor border cellpadding
+		cellspacing charset checked clear color cols colspan content controls
+		coords crossorigin datetime decoding default disabled download enctype
+		face for frame headers height high hreflang http-equiv integrity ismap
+		kind label list loading loop low max maxlength media method min minlength
+		multiple muted name noshade nowrap open optimum pattern placeholder
+		playsinline preload property readonly referrerpolicy rel required
+		reversed rows rowspan rules scope selected shape size sizes span
+		srclang start step summary target type usemap valign value vspace width
+		wrap
+		xmlns xmlns:xlink xml:space version viewbox preserveaspectratio d x y x1 y1
+		x2 y2 cx cy r rx ry fx fy points transform fill fill-opacity fill-rule
+		stroke stroke-width stroke-linecap stroke-linejoin stroke-dasharray
+		stroke-dashoffset stroke-miterlimit stroke-opacity opacity offset
+		stop-color stop-opacity gradientunits gradienttransform spreadmethod
+		patternunits patterncontentunits patterntransform clip-path clip-rule
+		clippathunits mask maskunits maskcontentunits marker-start marker-mid
+		marker-end markerwidth markerheight markerunits refx refy orient
+		text-anchor dominant-baseline font-family font-size font-weight
+		font-style letter-spacing word-spacing dx dy rotate textlength
+		lengthadjust visibility display overflow vector-effect filter
+		flood-color flood-opacity in in2 result stddeviation mode operator
+		focusable shape-rendering color-interpolation-filters pathlength
+		mathvariant displaystyle scriptlevel encoding`)
+	// urlAttrs are the attributes holding a URL, kept only with a safe scheme
+	// (see safeURL)
+	urlAttrs = stringSet(`href xlink:href src srcset poster action formaction cite background`)
+	// safeSchemes are the URL schemes sanitized content may link to or load
+	safeSchemes = stringSet(`http https mailto tel ftp file about`)
+	// mediaDataTypes prefix the data: URIs allowed as images and media
+	mediaDataTypes = []string{"image/", "video/", "audio/", "font/"}
+)
+
+// sanitizeHTML removes the ways content can run script. The content is
+// parsed as a browser would and only allowed elements and attributes are
+// kept (see allowedElements and allowedAttrs): <script> and other embedding
+// elements are dropped, as are inline event handlers, srcdoc and
+// <meta http-equiv="refresh">, and URLs with an unsafe scheme such as
+// javascript: are made inert (checked after character references are
+// decoded). The result is a whole document carrying sanitizeCSP. It's
+// applied to --safe content and, with trust_stdin = false, to piped content.
+func sanitizeHTML(content string) string {
+	for pass := 0; pass < maxSanitizePasses; pass++ {
+		doc, err := html.Parse(strings.NewReader(content))
+		if err != nil {
+			return plainToHTML(content)
+		}
+		sanitizeChildren(doc)
+		addSanitizeCSP(doc)
+		var b strings.Builder
+		if err := html.Render(&b, doc); err != nil {
+			return plainToHTML(content)
+		}
+		// Output that parses back to itself is what the webview will see
+		if b.String() == content {
+			return content
+		}
+		content = b.String()
+	}
+	// Markup that keeps changing each time it's parsed is shown as source
+	return plainToHTML(content)
+}
+
+// sanitizeChildren sanitizes the children of n, removing comments and
+// elements that aren't allowed
+func sanitizeChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch c.Type {
+		case html.CommentNode:
+			n.RemoveChild(c)
+		case html.ElementNode:
+			name := strings.ToLower(c.Data)
+			switch {
+			case allowedElements[c.Namespace][name]:
+				if sanitizeAttrs(c) {
+					sanitizeChildren(c)
+				} else {
+					n.RemoveChild(c)
+				}
+			case c.Namespace == "" && !droppedElements[name]:
+				// Unwrap unknown HTML elements; their children are
+				// sanitized in turn as the loop reaches them
+				if c.FirstChild != nil {
+					next = c.FirstChild
+				}
+				for c.FirstChild != nil {
+					child := c.FirstChild
+					c.RemoveChild(child)
+					n.InsertBefore(child, c)
+				}
+				n.RemoveChild(c)
+			default:
+				// Disallowed SVG and MathML elements go with their content,
+				// which could parse differently once they're gone
+				n.RemoveChild(c)
+			}
+		}
+		c = next
+	}
+}
+
+// sanitizeAttrs drops the attributes of n that aren't allowed and makes
+// unsafe URLs inert. It returns false if n has to go entirely, as a
+// <meta http-equiv="refresh"> does.
+func sanitizeAttrs(n *html.Node) bool {
+	kept := n.Attr[:0]
+	for _, attr := range n.Attr {
+		name := strings.ToLower(attr.Key)
+		if attr.Namespace != "" {
+			name = attr.Namespace + ":" + name
+		}
+		switch {
+		case name == "http-equiv" && strings.EqualFold(strings.TrimSpace(attr.Val), "refresh"):
+			return false
+		case name == "srcset":
+			attr.Val = safeSrcset(attr.Val)
+		case urlAttrs[name]:
+			if !safeURL(attr.Val, allowsMediaData(n, name)) {
+				attr.Val = "about:blank#" + attr.Val[strings.Index(attr.Val, ":")+1:]
+			}
+		case allowedAttrs[name] || strings.HasPrefix(name, "data-") || strings.HasPrefix(name, "aria-"):
+		default:
+			continue
+		}
+		kept = append(kept, attr)
+	}
+	n.Attr = kept
+	return true
+}
+
+// allowsMediaData reports whether the named attribute of n loads an image or
+// media file, and so may hold a data: URI of one
+func allowsMediaData(n *html.Node, name string) bool {
+	switch name {
+	case "src", "poster":
+		return true
+	case "href", "xlink:href":
+		return n.Namespace == "svg" && strings.EqualFold(n.Data, "image")
+	}
+	return false
+}
+
+// safeSrcset drops the image candidates of a srcset whose URL isn't safe
+func safeSrcset(value string) string {
+	var kept []string
+	for _, candidate := range strings.Split(value, ",") {
+		if fields := strings.Fields(candidate); len(fields) > 0 && safeURL(fields[0], true) {
+			kept = append(kept, strings.Join(fields, " "))
+		}
+	}
+	return strings.Join(kept, ", ")
+}
+
+// safeURL reports whether value, an attribute value with its character
+// references already decoded, is relative or has a scheme in safeSchemes.
+// data: URIs are only safe as images and media, when media is set. As a
+// browser does, tabs and newlines anywhere and leading spaces and control
+// characters are ignored, so "java\tscript:" is still javascript:.
+func safeURL(value string, media bool) bool {
+	value = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, value)
+	value = strings.TrimLeftFunc(value, func(r rune) bool { return r <= ' ' })
+	scheme := urlScheme.FindString(value)
+	if scheme == "" {
+		return true
+	}
+	scheme = strings.ToLower(strings.TrimSuffix(scheme, ":"))
+	if scheme == "data" {
+		if !media {
+			return false
+		}
+		dataType := strings.ToLower(value[len("data:"):])
+		for _, prefix := range mediaDataTypes {
+			if strings.HasPrefix(dataType, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return safeSchemes[scheme]
+}
+
+// addSanitizeCSP makes a <meta> with sanitizeCSP the first element of the
+// document's <head>, unless an earlier pass already did
+func addSanitizeCSP(doc *html.Node) {
+	head := findElement(doc, "head")
+	if head == nil {
+		return
+	}
+	if first := head.FirstChild; first != nil && first.Type == html.ElementNode && first.Data == "meta" &&
+		len(first.Attr) == 2 && first.Attr[1].Val == sanitizeCSP {
+		return
+	}
+	head.InsertBefore(&html.Node{
+		Type: html.ElementNode,
+		Data: "meta",
+		Attr: []html.Attribute{
+			{Key: "http-equiv", Val: "Content-Security-Policy"},
+			{Key: "content", Val: sanitizeCSP},
+		},
+	}, head.FirstChild)
+}
+
+// findElement returns the first HTML element named name in the tree under n
+func findElement(n *html.Node, name string) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Namespace == "" && c.Data == name {
+			return c
+		}
+		if found := findElement(c, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// shouldSanitize reports whether content is rendered with its scripts
+// stripped: always with --safe, and for piped or fetched content when the
+// config doesn't trust it
+func shouldSanitize(piped, safe bool, config Config) bool {
+	return safe || (piped && !config.TrustStdin)
+}
+
+// sanitizeEntry strips scripts from entry's content if shouldSanitize says so
+func sanitizeEntry(entry *FileEntry, piped, safe bool, config Config) {
+	if shouldSanitize(piped, safe, config) {
+		entry.Content = sanitizeHTML(entry.Content)
+	}
+}
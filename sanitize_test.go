@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// sanitizedPage is the document sanitizeHTML returns for content whose body
+// sanitizes to body
+func sanitizedPage(body string) string {
+	return `<html><head><meta http-equiv="Content-Security-Policy" content="script-src &#39;none&#39;"/></head><body>` +
+		body + `</body></html>`
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"script element", `<p>a</p><script>alert(1)</script><p>b</p>`, `<p>a</p><p>b</p>`},
+		{"script with attributes", `<SCRIPT src="x.js" type="module"></SCRIPT>ok`, `ok`},
+		{"multiline script", "<script>\nlet a = '</p>';\n</script>ok", `ok`},
+		// As in a browser, an unclosed script takes the rest of the document
+		{"unclosed script", `<script src="x.js">ok`, ``},
+		{"double-quoted handler", `<img src="a.png" onerror="alert(1)">`, `<img src="a.png"/>`},
+		{"single-quoted handler", `<body onload='go()'>`, ``},
+		{"unquoted handler", `<div onclick=go() class="x">`, `<div class="x"></div>`},
+		{"javascript URL", `<a href="javascript:alert(1)">x</a>`, `<a href="about:blank#alert(1)">x</a>`},
+		{"unquoted javascript URL", `<a href= JavaScript:go()>x</a>`, `<a href="about:blank#go()">x</a>`},
+		{"plain markup untouched", `<p class="online">one <b>two</b></p>`, `<p class="online">one <b>two</b></p>`},
+		{"text mentioning onclick untouched", `<p>set onclick=handler in code</p>`, `<p>set onclick=handler in code</p>`},
+		{"handler without whitespace", `<svg/onload=alert(1)>`, `<svg></svg>`},
+		{"script split by a nested script", `<scr<script></script>ipt>alert(1)</script>`, `ipt&gt;alert(1)`},
+		{"character references in scheme", `<a href="jav&#x61;script:alert(1)">x</a>`, `<a href="about:blank#alert(1)">x</a>`},
+		{"tab in scheme", "<a href=\"java\tscript:alert(1)\">x</a>", "<a href=\"about:blank#alert(1)\">x</a>"},
+		{"iframe srcdoc", `<iframe srcdoc="<script>alert(1)</script>"></iframe>ok`, `ok`},
+		{"meta refresh", `<meta http-equiv=refresh content="0;url=javascript:alert(1)">ok`, `ok`},
+		{"unknown element unwrapped", `<blink onclick="go()">hi</blink>`, `hi`},
+		{"script inside svg", `<svg><script>alert(1)</script><circle r="4"></circle></svg>`, `<svg><circle r="4"></circle></svg>`},
+		{"markup in svg title", `<svg><title><img src=x onerror=alert(1)></title></svg>`, `<svg><title><img src="x"/></title></svg>`},
+		{"svg animation of href", `<svg><a><animate attributeName="href" values="javascript:alert(1)"/><text>x</text></a></svg>`, `<svg><a><text>x</text></a></svg>`},
+		{"data image kept", `<img src="data:image/png;base64,AAAA">`, `<img src="data:image/png;base64,AAAA"/>`},
+		{"data page link", `<a href="data:text/html,<script>alert(1)</script>">x</a>`, `<a href="about:blank#text/html,&lt;script&gt;alert(1)&lt;/script&gt;">x</a>`},
+		{"srcset candidates", `<img srcset="a.png 1x, javascript:alert(1) 2x">`, `<img srcset="a.png 1x"/>`},
+		{"comment dropped", `<!-- note -->ok`, `ok`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := sanitizeHTML(tt.input), sanitizedPage(tt.want); got != want {
+				t.Errorf("sanitizeHTML(%q) = %q, want %q", tt.input, got, want)
+			}
+		})
+	}
+}
+
+func TestSanitizeHTMLKeepsDocument(t *testing.T) {
+	input := `<!DOCTYPE html><html lang="en"><head><title>Report</title><style>p > b { color: red; }</style></head>` +
+		`<body><p>one <b>two</b></p></body></html>`
+	want := `<!DOCTYPE html><html lang="en"><head><meta http-equiv="Content-Security-Policy" content="script-src &#39;none&#39;"/>` +
+		`<title>Report</title><style>p > b { color: red; }</style></head><body><p>one <b>two</b></p></body></html>`
+	if got := sanitizeHTML(input); got != want {
+		t.Errorf("sanitizeHTML() = %q, want %q", got, want)
+	}
+	// Sanitized content is stable and carries a single policy
+	if again := sanitizeHTML(want); again != want {
+		t.Errorf("sanitizing twice = %q, want it unchanged", again)
+	}
+}
+
+func TestSanitizeEntryTrustStdin(t *testing.T) {
+	const content = `<p>hi</p><script>alert(1)</script>`
+	untrusting := DefaultConfig()
+	untrusting.TrustStdin = false
+
+	tests := []struct {
+		name   string
+		piped  bool
+		safe   bool
+		config Config
+		strip  bool
+	}{
+		{"piped, trusted by default", true, false, DefaultConfig(), false},
+		{"piped, trust_stdin=false", true, false, untrusting, true},
+		{"-p file, trust_stdin=false", false, false, untrusting, false},
+		{"-p file with --safe", false, true, DefaultConfig(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := FileEntry{Content: content, ContentType: ContentTypeHTML}
+			sanitizeEntry(&entry, tt.piped, tt.safe, tt.config)
+			if stripped := !strings.Contains(entry.Content, "<script>"); stripped != tt.strip {
+				t.Errorf("content = %q, want scripts stripped = %v", entry.Content, tt.strip)
+			}
+		})
+	}
+}
+
+func TestGUIProcessArgsSafe(t *testing.T) {
+	args, err := guiProcessArgs(FileEntry{Path: "/tmp/a.html"}, guiOptions{Safe: true})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	if !hasArg(args, "--safe") {
+		t.Errorf("args %v should pass --safe to the child, which re-reads the file", args)
+	}
+}
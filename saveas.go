@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// SaveAs asks where to save the selected file's content with the system
+// save dialog, then writes it there (see saveTo). It returns the path saved
+// to, or "" if the dialog was cancelled. The path only ever comes from the
+// dialog, so rendered pages calling this binding can't write files the user
+// didn't pick; replacing an existing file is confirmed in the dialog.
+func (a *App) SaveAs() (string, error) {
+	if a.ctx == nil || a.saveDialog == nil {
+		return "", errNoWindow
+	}
+	a.mu.RLock()
+	if a.currentIndex < 0 || a.currentIndex >= len(a.files) {
+		a.mu.RUnlock()
+		return "", fmt.Errorf("no file to save")
+	}
+	current := a.files[a.currentIndex]
+	a.mu.RUnlock()
+
+	options := runtime.SaveDialogOptions{
+		Title:                "Save As",
+		DefaultFilename:      current.Name,
+		CanCreateDirectories: true,
+	}
+	if current.Path != "" {
+		options.DefaultDirectory = filepath.Dir(current.Path)
+		options.DefaultFilename = filepath.Base(current.Path)
+	}
+	path, err := a.saveDialog(a.ctx, options)
+	if err != nil || path == "" {
+		return "", err
+	}
+	if err := a.saveTo(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// saveTo writes the selected file's content to path, creating missing parent
+// directories, and makes path the file's location so relative assets and
+// Reload work from there. The content is saved as displayed, so transformed
+// content such as Markdown is written as HTML.
+func (a *App) saveTo(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	if err := a.GetConfig().checkExtensionAllowed(absPath); err != nil {
+		return err
+	}
+
+	a.mu.RLock()
+	if a.currentIndex < 0 || a.currentIndex >= len(a.files) {
+		a.mu.RUnlock()
+		return fmt.Errorf("no file to save")
+	}
+	saved := a.files[a.currentIndex]
+	content := saved.content()
+	a.mu.RUnlock()
+
+	if err := writeFileCreatingDirs(absPath, content); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	index := a.indexOfFile(saved.Name, saved.Path)
+	if index < 0 {
+		// The file was removed while it was being written
+		a.mu.Unlock()
+		return nil
+	}
+	a.files[index].Path = absPath
+	a.files[index].Name = filepath.Base(absPath)
+	a.files[index].BasePath = ""
+	sortFilesByName(a.files)
+	a.currentIndex = a.indexOfFile(filepath.Base(absPath), absPath)
+	filesCopy := a.filesWithContent()
+	currentIndex := a.currentIndex
+	a.mu.Unlock()
+
+	a.emitEvent("files-changed", map[string]interface{}{
+		"files":        filesCopy,
+		"currentIndex": currentIndex,
+	})
+	return nil
+}
+
+// writeFileCreatingDirs writes content to path, creating its parent
+// directories
+func writeFileCreatingDirs(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+func TestSaveAsWritesContent(t *testing.T) {
+	app := NewApp(FileEntry{Name: "stdin", Content: "<p>piped</p>", BasePath: "/tmp"}, "")
+	rec := recordEvents(app)
+	path := filepath.Join(t.TempDir(), "reports", "today.html")
+
+	if err := app.saveTo(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("saved file should exist: %v", err)
+	}
+	if string(data) != "<p>piped</p>" {
+		t.Errorf("saved content = %q, want the entry's content", data)
+	}
+	if len(rec.named("files-changed")) != 1 {
+		t.Error("SaveAs() should send the renamed file to the frontend")
+	}
+}
+
+func TestSaveAsUpdatesEntryPath(t *testing.T) {
+	app := NewApp(FileEntry{Name: "stdin", Content: "<p>piped</p>", BasePath: "/tmp"}, "")
+	app.AddFile(FileEntry{Name: "z.html", Path: "/tmp/z.html", Content: "<p>z</p>"})
+	path := filepath.Join(t.TempDir(), "saved.html")
+
+	if err := app.saveTo(path); err != nil {
+		t.Fatalf("SaveAs() error = %v", err)
+	}
+
+	files := app.GetFiles()
+	current := files[app.GetCurrentIndex()]
+	if current.Path != path || current.Name != "saved.html" {
+		t.Errorf("current entry = %q at %q, want saved.html at %s", current.Name, current.Path, path)
+	}
+	if current.BasePath != "" {
+		t.Errorf("BasePath = %q, want assets resolved from the saved location", current.BasePath)
+	}
+
+	// The entry now reloads from where it was saved
+	if err := os.WriteFile(path, []byte("<p>edited</p>"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := app.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := app.GetHTMLContent(); !strings.Contains(got, "edited") {
+		t.Errorf("GetHTMLContent() = %q, want the reloaded content", got)
+	}
+}
+
+func TestSaveAsWritesToDialogPath(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/docs/a.html", Content: "<p>new</p>"}, "")
+	recordEvents(app)
+	path := filepath.Join(t.TempDir(), "existing.html")
+	if err := os.WriteFile(path, []byte("<p>old</p>"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	var options runtime.SaveDialogOptions
+	app.saveDialog = func(ctx context.Context, opts runtime.SaveDialogOptions) (string, error) {
+		options = opts
+		return path, nil
+	}
+
+	saved, err := app.SaveAs()
+	if err != nil || saved != path {
+		t.Fatalf("SaveAs() = %q, %v, want %s", saved, err, path)
+	}
+	if options.DefaultDirectory != "/tmp/docs" || options.DefaultFilename != "a.html" {
+		t.Errorf("dialog opened at %q with %q, want the current file's location", options.DefaultDirectory, options.DefaultFilename)
+	}
+	// Replacing the file was confirmed in the dialog
+	if data, _ := os.ReadFile(path); string(data) != "<p>new</p>" {
+		t.Errorf("saved file = %q, want the new content", data)
+	}
+}
+
+func TestSaveAsCancelled(t *testing.T) {
+	app := NewApp(FileEntry{Name: "stdin", Content: "<p>piped</p>"}, "")
+	recordEvents(app)
+	app.saveDialog = func(ctx context.Context, opts runtime.SaveDialogOptions) (string, error) {
+		return "", nil
+	}
+
+	if saved, err := app.SaveAs(); saved != "" || err != nil {
+		t.Errorf("SaveAs() = %q, %v, want nothing saved when the dialog is cancelled", saved, err)
+	}
+	if app.GetFiles()[0].Path != "" {
+		t.Error("a cancelled save should not change the entry's path")
+	}
+}
+
+func TestSaveAsNeedsWindow(t *testing.T) {
+	app := NewApp(FileEntry{Name: "stdin", Content: "<p>piped</p>"}, "")
+	if _, err := app.SaveAs(); !errors.Is(err, errNoWindow) {
+		t.Errorf("SaveAs() before startup error = %v, want errNoWindow", err)
+	}
+}
+
+func TestSaveAsRejectsDisallowedExtension(t *testing.T) {
+	app := NewApp(FileEntry{Name: "stdin", Content: "<p>piped</p>"}, "")
+	app.config.AllowedExtensions = []string{".html"}
+	path := filepath.Join(t.TempDir(), "script.sh")
+
+	if err := app.saveTo(path); err == nil {
+		t.Error("SaveAs() should reject a path outside allowed_extensions")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("nothing should be written for a rejected path")
+	}
+}
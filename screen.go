@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -14,6 +18,54 @@ const (
 	MinWindowHeight     = 300
 )
 
+// WindowGeometry is a window size with an optional position
+type WindowGeometry struct {
+	Width, Height int
+	X, Y          int
+	HasPosition   bool
+}
+
+// geometryOffset matches the "X+Y" position after the size in a geometry
+// (offsets may be negative, e.g. +-20+0)
+var geometryOffset = regexp.MustCompile(`^(-?\d+)\+(-?\d+)$`)
+
+// ParseGeometry parses a geometry string such as "1200x800+100+50"
+func ParseGeometry(s string) (WindowGeometry, error) {
+	invalid := fmt.Errorf("invalid geometry %q (expected WxH or WxH+X+Y with a positive size, e.g. 1200x800+100+50)", s)
+	size, offset, hasOffset := strings.Cut(s, "+")
+	width, height, err := parseSize(size)
+	if err != nil {
+		return WindowGeometry{}, invalid
+	}
+	g := WindowGeometry{Width: width, Height: height}
+	if hasOffset {
+		m := geometryOffset.FindStringSubmatch(offset)
+		if m == nil {
+			return WindowGeometry{}, invalid
+		}
+		g.X, _ = strconv.Atoi(m[1])
+		g.Y, _ = strconv.Atoi(m[2])
+		g.HasPosition = true
+	}
+	return g, nil
+}
+
+// ApplyGeometry makes a launch geometry take precedence over config defaults.
+// Saved state still wins unless force is set, in which case it is ignored.
+func ApplyGeometry(state *WindowState, config Config, g WindowGeometry, force bool) (*WindowState, Config) {
+	config.DefaultWidth = g.Width
+	config.DefaultHeight = g.Height
+	if g.HasPosition {
+		config.DefaultX = g.X
+		config.DefaultY = g.Y
+		config.hasDefaultPosition = true
+	}
+	if force {
+		state = nil
+	}
+	return state, config
+}
+
 // GetWindowDimensions returns the window width and height to use based on
 // saved state, config defaults, and hardcoded defaults (in priority order)
 func GetWindowDimensions(state *WindowState, config Config) (width, height int) {
@@ -36,19 +88,20 @@ func GetWindowDimensions(state *WindowState, config Config) (width, height int)
 	}
 
 	// Ensure minimum dimensions
-	if width < MinWindowWidth {
-		width = MinWindowWidth
+	minWidth, minHeight := config.MinWindowSize()
+	if width < minWidth {
+		width = minWidth
 	}
-	if height < MinWindowHeight {
-		height = MinWindowHeight
+	if height < minHeight {
+		height = minHeight
 	}
 
 	return width, height
 }
 
 // GetWindowPosition returns the window X and Y position to use based on
-// saved state and config defaults. Returns (0, 0, false) if no position
-// should be explicitly set (let OS decide).
+// saved state, a --geometry position, and config defaults. Returns
+// (0, 0, false) if no position should be explicitly set (let OS decide).
 func GetWindowPosition(state *WindowState, config Config) (x, y int, shouldSet bool) {
 	// Check saved state first
 	if state != nil && state.IsValid() {
@@ -56,7 +109,7 @@ func GetWindowPosition(state *WindowState, config Config) (x, y int, shouldSet b
 	}
 
 	// Check config defaults
-	if config.DefaultX != 0 || config.DefaultY != 0 {
+	if config.hasDefaultPosition || config.DefaultX != 0 || config.DefaultY != 0 {
 		return config.DefaultX, config.DefaultY, true
 	}
 
@@ -64,6 +117,71 @@ func GetWindowPosition(state *WindowState, config Config) (x, y int, shouldSet b
 	return 0, 0, false
 }
 
+// minVisible is how much of a window, in each direction, must be on screen
+// for a saved position to be used
+const minVisible = 100
+
+// screenRect is an area of the desktop in global coordinates, where the
+// primary screen starts at 0,0 and screens to its left or above it have
+// negative origins
+type screenRect struct {
+	X, Y, Width, Height int
+}
+
+// overlap returns how far the spans [a, a+aLen) and [b, b+bLen) overlap
+func overlap(a, aLen, b, bLen int) int {
+	return min(a+aLen, b+bLen) - max(a, b)
+}
+
+// windowVisible reports whether a window at x,y of the given size overlaps
+// any of the screens by at least minVisible pixels in both directions (or
+// its whole width or height, for windows smaller than that)
+func windowVisible(screens []screenRect, x, y, width, height int) bool {
+	needX, needY := min(minVisible, width), min(minVisible, height)
+	for _, s := range screens {
+		if overlap(x, width, s.X, s.Width) >= needX && overlap(y, height, s.Y, s.Height) >= needY {
+			return true
+		}
+	}
+	return false
+}
+
+// desktopBounds returns the area the screens could cover. Wails v2 reports
+// each screen's size but not where it's placed, so the other screens may
+// sit on any side of the primary: the area extends past the primary by
+// their combined width and height in each direction. That keeps windows
+// on a monitor left of or above the primary (negative coordinates) and on
+// portrait screens, while rejecting positions no arrangement could show,
+// such as one left from a disconnected monitor.
+func desktopBounds(screens []runtime.Screen) screenRect {
+	primary := 0
+	for i, s := range screens {
+		if s.IsPrimary {
+			primary = i
+			break
+		}
+	}
+	var otherWidth, otherHeight int
+	for i, s := range screens {
+		if i != primary {
+			otherWidth += s.Size.Width
+			otherHeight += s.Size.Height
+		}
+	}
+	p := screens[primary].Size
+	return screenRect{
+		X:      -otherWidth,
+		Y:      -otherHeight,
+		Width:  p.Width + 2*otherWidth,
+		Height: p.Height + 2*otherHeight,
+	}
+}
+
+// positionVisible reports whether a window at x,y could be seen on screens
+func positionVisible(screens []runtime.Screen, x, y, width, height int) bool {
+	return windowVisible([]screenRect{desktopBounds(screens)}, x, y, width, height)
+}
+
 // ValidateAndSetWindowPosition sets the window position.
 // Validates that at least part of the window would be visible on the current
 // screen setup. This handles the case where an external monitor was disconnected.
@@ -75,29 +193,7 @@ func ValidateAndSetWindowPosition(ctx context.Context, x, y, width, height int)
 		return
 	}
 
-	// Calculate total screen dimensions
-	// For multi-monitor: screens can be arranged horizontally, vertically, or mixed
-	// We estimate total bounds as sum of widths and max height (common horizontal layout)
-	// This is imperfect but handles the main case: external monitor disconnected
-	var totalWidth, maxHeight int
-	for _, screen := range screens {
-		totalWidth += screen.Size.Width
-		if screen.Size.Height > maxHeight {
-			maxHeight = screen.Size.Height
-		}
-	}
-
-	// Check if window would be at least partially visible
-	// Allow window to be partially off-screen but require some portion visible
-	const minVisible = 100
-	windowRight := x + width
-	windowBottom := y + height
-
-	// Window must have at least minVisible pixels potentially on-screen
-	// For X: window's right edge must be > minVisible, left edge must be < totalWidth - minVisible
-	// For Y: window's bottom must be > minVisible, top must be < maxHeight - minVisible
-	if windowRight < minVisible || x > totalWidth-minVisible ||
-		windowBottom < minVisible || y > maxHeight-minVisible {
+	if !positionVisible(screens, x, y, width, height) {
 		// Position would be mostly/entirely off-screen, let OS decide
 		return
 	}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"testing"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 func TestGetWindowDimensions(t *testing.T) {
@@ -99,12 +101,12 @@ func TestGetWindowDimensions(t *testing.T) {
 
 func TestGetWindowPosition(t *testing.T) {
 	tests := []struct {
-		name         string
-		state        *WindowState
-		config       Config
-		expectedX    int
-		expectedY    int
-		shouldSet    bool
+		name      string
+		state     *WindowState
+		config    Config
+		expectedX int
+		expectedY int
+		shouldSet bool
 	}{
 		{
 			name:      "no state or config - don't set",
@@ -213,3 +215,222 @@ func TestConstants(t *testing.T) {
 		t.Errorf("MinWindowHeight should be positive, got %d", MinWindowHeight)
 	}
 }
+
+func TestGetWindowDimensionsCustomMinimums(t *testing.T) {
+	tests := []struct {
+		name           string
+		state          *WindowState
+		config         Config
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{
+			name:           "smaller minimums allow smaller windows",
+			state:          nil,
+			config:         Config{DefaultWidth: 200, DefaultHeight: 150, MinWidth: 160, MinHeight: 120},
+			expectedWidth:  200,
+			expectedHeight: 150,
+		},
+		{
+			name:           "custom minimums clamp saved state",
+			state:          &WindowState{Width: 100, Height: 80},
+			config:         Config{MinWidth: 160, MinHeight: 120},
+			expectedWidth:  160,
+			expectedHeight: 120,
+		},
+		{
+			name:           "larger minimums clamp defaults",
+			state:          nil,
+			config:         Config{MinWidth: 1000, MinHeight: 800},
+			expectedWidth:  1000,
+			expectedHeight: 800,
+		},
+		{
+			name:           "non-positive minimums fall back to app defaults",
+			state:          nil,
+			config:         Config{DefaultWidth: 200, DefaultHeight: 150, MinWidth: -1, MinHeight: 0},
+			expectedWidth:  MinWindowWidth,
+			expectedHeight: MinWindowHeight,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height := GetWindowDimensions(tt.state, tt.config)
+			if width != tt.expectedWidth {
+				t.Errorf("width = %d, expected %d", width, tt.expectedWidth)
+			}
+			if height != tt.expectedHeight {
+				t.Errorf("height = %d, expected %d", height, tt.expectedHeight)
+			}
+		})
+	}
+}
+
+func TestParseGeometry(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  WindowGeometry
+		expectErr bool
+	}{
+		{"1200x800", WindowGeometry{Width: 1200, Height: 800}, false},
+		{"1200x800+100+50", WindowGeometry{Width: 1200, Height: 800, X: 100, Y: 50, HasPosition: true}, false},
+		{"1200X800+-20+0", WindowGeometry{Width: 1200, Height: 800, X: -20, Y: 0, HasPosition: true}, false},
+		{"0x800", WindowGeometry{}, true},
+		{"1200x800+100", WindowGeometry{}, true},
+		{"1200x800+0+0", WindowGeometry{Width: 1200, Height: 800, HasPosition: true}, false},
+		{"1200x800+1+2+3", WindowGeometry{}, true},
+		{"1200", WindowGeometry{}, true},
+		{"big", WindowGeometry{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			g, err := ParseGeometry(tt.input)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ParseGeometry(%q) error = %v, expectErr %v", tt.input, err, tt.expectErr)
+			}
+			if g != tt.expected {
+				t.Errorf("ParseGeometry(%q) = %+v, expected %+v", tt.input, g, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyGeometryPrecedence(t *testing.T) {
+	saved := &WindowState{Width: 1000, Height: 600, X: 10, Y: 20}
+	config := Config{DefaultWidth: 900, DefaultHeight: 700, DefaultX: 5, DefaultY: 5}
+	g := WindowGeometry{Width: 1200, Height: 800, X: 100, Y: 50, HasPosition: true}
+
+	tests := []struct {
+		name      string
+		state     *WindowState
+		force     bool
+		expectedW int
+		expectedH int
+		expectedX int
+		expectedY int
+	}{
+		{"overrides config defaults", nil, false, 1200, 800, 100, 50},
+		{"saved state still wins", saved, false, 1000, 600, 10, 20},
+		{"force overrides saved state", saved, true, 1200, 800, 100, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, cfg := ApplyGeometry(tt.state, config, g, tt.force)
+
+			width, height := GetWindowDimensions(state, cfg)
+			if width != tt.expectedW || height != tt.expectedH {
+				t.Errorf("dimensions = %dx%d, expected %dx%d", width, height, tt.expectedW, tt.expectedH)
+			}
+			x, y, shouldSet := GetWindowPosition(state, cfg)
+			if !shouldSet || x != tt.expectedX || y != tt.expectedY {
+				t.Errorf("position = (%d, %d, %v), expected (%d, %d, true)", x, y, shouldSet, tt.expectedX, tt.expectedY)
+			}
+		})
+	}
+}
+
+func TestApplyGeometryWithoutPosition(t *testing.T) {
+	config := Config{DefaultX: 5, DefaultY: 5}
+	_, cfg := ApplyGeometry(nil, config, WindowGeometry{Width: 1200, Height: 800}, false)
+
+	if cfg.DefaultX != 5 || cfg.DefaultY != 5 {
+		t.Errorf("position = (%d, %d), expected config defaults to be kept", cfg.DefaultX, cfg.DefaultY)
+	}
+}
+
+func TestApplyGeometryAtOrigin(t *testing.T) {
+	g, err := ParseGeometry("1200x800+0+0")
+	if err != nil {
+		t.Fatalf("ParseGeometry() error = %v", err)
+	}
+	_, cfg := ApplyGeometry(nil, Config{}, g, false)
+	if x, y, shouldSet := GetWindowPosition(nil, cfg); !shouldSet || x != 0 || y != 0 {
+		t.Errorf("position = (%d, %d, %v), want +0+0 to be set", x, y, shouldSet)
+	}
+}
+
+// testScreen returns a screen of the given logical size
+func testScreen(width, height int, primary bool) runtime.Screen {
+	s := runtime.Screen{IsPrimary: primary}
+	s.Size.Width, s.Size.Height = width, height
+	return s
+}
+
+func TestWindowVisibleSixScreens(t *testing.T) {
+	// A video wall: a landscape row with a portrait screen at each end,
+	// one of them left of the primary at a negative origin
+	screens := []screenRect{
+		{X: -1080, Y: -420, Width: 1080, Height: 1920},
+		{X: 0, Y: 0, Width: 1920, Height: 1080},
+		{X: 1920, Y: 0, Width: 2560, Height: 1440},
+		{X: 4480, Y: 0, Width: 1920, Height: 1080},
+		{X: 6400, Y: -420, Width: 1080, Height: 1920},
+		{X: 0, Y: 1080, Width: 1920, Height: 1080},
+	}
+
+	tests := []struct {
+		name string
+		x, y int
+		want bool
+	}{
+		{"on the negative-origin screen", -900, -300, true},
+		{"on the rightmost portrait screen", 6500, 1000, true},
+		{"on the screen below the primary", 200, 1500, true},
+		{"straddling two screens", 1500, 100, true},
+		{"only a sliver on screen", -1080 - 850, 0, false},
+		{"below the short screens", 4500, 1200, false},
+		{"far off to the right", 9000, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windowVisible(screens, tt.x, tt.y, 900, 700); got != tt.want {
+				t.Errorf("windowVisible(%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowVisibleSmallWindow(t *testing.T) {
+	screens := []screenRect{{X: 0, Y: 0, Width: 1920, Height: 1080}}
+	if !windowVisible(screens, 10, 10, 50, 50) {
+		t.Error("a window smaller than minVisible that's fully on screen should be visible")
+	}
+}
+
+func TestPositionVisibleSixScreens(t *testing.T) {
+	screens := []runtime.Screen{
+		testScreen(1080, 1920, false),
+		testScreen(1920, 1080, true),
+		testScreen(2560, 1440, false),
+		testScreen(1920, 1080, false),
+		testScreen(1080, 1920, false),
+		testScreen(1920, 1080, false),
+	}
+
+	// A saved position on a monitor left of the primary has negative x
+	if !positionVisible(screens, -900, 100, 900, 700) {
+		t.Error("a window on a screen left of the primary should be visible")
+	}
+	// Screens may be stacked, so a position below a landscape primary can be right
+	if !positionVisible(screens, 0, 2500, 900, 700) {
+		t.Error("a window below the primary should be visible with stacked screens")
+	}
+	if positionVisible(screens, 50000, 0, 900, 700) {
+		t.Error("a window beyond every screen should not be visible")
+	}
+}
+
+func TestPositionVisibleAfterMonitorDisconnected(t *testing.T) {
+	screens := []runtime.Screen{testScreen(1920, 1080, true)}
+
+	if positionVisible(screens, -1700, 100, 900, 700) {
+		t.Error("a window left on a disconnected monitor should not be visible")
+	}
+	if !positionVisible(screens, 100, 100, 900, 700) {
+		t.Error("a window on the only screen should be visible")
+	}
+}
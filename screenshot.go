@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// screenshotTimeout is how long a window gets to capture itself once its
+// content is rendered
+const screenshotTimeout = 10 * time.Second
+
+// screenCapturer saves the window's area of the screen as a PNG at path
+// (captureWindow, replaced in tests)
+type screenCapturer func(ctx context.Context, path string) error
+
+// checkScreenshotArgs returns an error if --screenshot, --width and --height
+// can't be used together with the other flags given. width and height are 0
+// when not set.
+func checkScreenshotArgs(path string, width, height int, windowID, geometry string) error {
+	if path == "" {
+		if width != 0 || height != 0 {
+			return errors.New("--width and --height require --screenshot")
+		}
+		return nil
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".png") {
+		return fmt.Errorf("--screenshot: %s is not a .png file", path)
+	}
+	if width < 0 || height < 0 {
+		return errors.New("--width and --height must be positive")
+	}
+	if windowID != "" {
+		return errors.New("--screenshot opens a window of its own and can't be used with --id")
+	}
+	if geometry != "" && (width != 0 || height != 0) {
+		return errors.New("use either --geometry or --width/--height with --screenshot")
+	}
+	return nil
+}
+
+// screenshotGeometry returns the --geometry that sizes the capture window to
+// width x height, with the configured window size standing in for a
+// dimension that's 0. It returns "" when neither is set.
+func screenshotGeometry(width, height int, config Config) (string, error) {
+	if width == 0 && height == 0 {
+		return "", nil
+	}
+	defaultWidth, defaultHeight := GetWindowDimensions(nil, config)
+	if width == 0 {
+		width = defaultWidth
+	}
+	if height == 0 {
+		height = defaultHeight
+	}
+	minWidth, minHeight := config.MinWindowSize()
+	if width < minWidth || height < minHeight {
+		return "", fmt.Errorf("--width and --height must be at least the minimum window size %dx%d", minWidth, minHeight)
+	}
+	return strconv.Itoa(width) + "x" + strconv.Itoa(height), nil
+}
+
+// takeScreenshot has the window behind socketPath capture itself to path
+// once its content is rendered, then closes it. The window is closed even
+// if the capture fails.
+func takeScreenshot(socketPath, path, token string) error {
+	captureErr := waitForRender(socketPath, token)
+	if captureErr == nil {
+		resp, err := sendCommandTimeout(socketPath, IPCCommand{Cmd: "screenshot", Output: path, Token: token}, screenshotTimeout)
+		if err != nil {
+			captureErr = err
+		} else if !resp.OK {
+			captureErr = errors.New(resp.Error)
+		}
+	}
+	if resp, err := SendCommand(socketPath, IPCCommand{Cmd: "close", Token: token}); err == nil && !resp.OK && captureErr == nil {
+		captureErr = errors.New(resp.Error)
+	}
+	return captureErr
+}
+
+// captureScreenshot saves the rendered window to path. Content that hasn't
+// rendered yet is waited for first.
+func (a *App) captureScreenshot(path string) error {
+	if path == "" {
+		return errors.New("no screenshot path")
+	}
+	if a.ctx == nil {
+		return errNoWindow
+	}
+	if err := a.WaitRendered(renderTimeout); err != nil {
+		return err
+	}
+	if a.capture == nil {
+		return errors.New("screenshots are not supported")
+	}
+	return a.capture(a.ctx, path)
+}
+
+// captureSettleDelay gives the window system time to draw a window that was
+// just shown and raised before it's captured
+const captureSettleDelay = 200 * time.Millisecond
+
+// captureWindow saves the window as a PNG at path (see captureCommand). The
+// window is first shown and kept above the others while it's captured, since
+// other windows may have covered it after it opened.
+func captureWindow(ctx context.Context, path string) error {
+	runtime.WindowUnminimise(ctx)
+	runtime.WindowShow(ctx)
+	runtime.WindowSetAlwaysOnTop(ctx, true)
+	defer runtime.WindowSetAlwaysOnTop(ctx, false)
+	time.Sleep(captureSettleDelay)
+
+	cmd, err := captureCommand(ctx, path)
+	if err != nil {
+		return err
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s failed: %s", cmd.Args[0], msg)
+		}
+		return fmt.Errorf("%s failed: %w", cmd.Args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+/*
+#cgo CFLAGS: -x objective-c
+#cgo LDFLAGS: -framework Cocoa
+#import <Cocoa/Cocoa.h>
+
+// visibleWindowNumber returns the window server number of the app's visible
+// window, or 0 if it has none
+static long visibleWindowNumber(void) {
+	__block long number = 0;
+	dispatch_sync(dispatch_get_main_queue(), ^{
+		for (NSWindow *window in [NSApp windows]) {
+			if ([window isVisible]) {
+				number = [window windowNumber];
+				break;
+			}
+		}
+	});
+	return number;
+}
+*/
+import "C"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// captureCommand returns the command that captures the window to path. On
+// macOS, screencapture -l captures the window itself by its window server
+// number, wherever it is: WindowGetPosition is relative to the visible area
+// of the window's screen, so it can't locate a region to capture.
+func captureCommand(ctx context.Context, path string) (*exec.Cmd, error) {
+	number := int(C.visibleWindowNumber())
+	if number == 0 {
+		return nil, errors.New("the window isn't on screen")
+	}
+	return exec.Command("screencapture", "-x", "-o", fmt.Sprintf("-l%d", number), path), nil
+}
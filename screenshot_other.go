@@ -0,0 +1,25 @@
+//go:build !darwin
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	goruntime "runtime"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// captureCommand returns the command that captures the window to path. On
+// Linux, ImageMagick's import crops the window's area from the root window;
+// GTK reports the window's position in root window coordinates, which span
+// all monitors.
+func captureCommand(ctx context.Context, path string) (*exec.Cmd, error) {
+	if goruntime.GOOS != "linux" {
+		return nil, fmt.Errorf("screenshots are not supported on %s", goruntime.GOOS)
+	}
+	x, y := runtime.WindowGetPosition(ctx)
+	width, height := runtime.WindowGetSize(ctx)
+	return exec.Command("import", "-window", "root", "-crop", fmt.Sprintf("%dx%d+%d+%d", width, height, x, y), path), nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckScreenshotArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		width, height int
+		windowID      string
+		geometry      string
+		wantErr       string
+	}{
+		{"no screenshot", "", 0, 0, "", "", ""},
+		{"png", "out.png", 0, 0, "", "", ""},
+		{"uppercase extension", "OUT.PNG", 1024, 768, "", "", ""},
+		{"geometry without size", "out.png", 0, 0, "", "1200x800", ""},
+		{"size without screenshot", "", 800, 0, "", "", "require --screenshot"},
+		{"not png", "out.jpg", 0, 0, "", "", "not a .png file"},
+		{"negative width", "out.png", -1, 0, "", "", "must be positive"},
+		{"with --id", "out.png", 0, 0, "new", "", "can't be used with --id"},
+		{"geometry and size", "out.png", 800, 600, "", "1200x800", "either --geometry or --width/--height"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkScreenshotArgs(tt.path, tt.width, tt.height, tt.windowID, tt.geometry)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkScreenshotArgs() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkScreenshotArgs() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScreenshotGeometry(t *testing.T) {
+	config := DefaultConfig()
+	config.DefaultWidth = 1000
+	config.DefaultHeight = 700
+
+	tests := []struct {
+		width, height int
+		want          string
+	}{
+		{0, 0, ""},
+		{1280, 720, "1280x720"},
+		{1280, 0, "1280x700"},
+		{0, 900, "1000x900"},
+	}
+	for _, tt := range tests {
+		got, err := screenshotGeometry(tt.width, tt.height, config)
+		if err != nil || got != tt.want {
+			t.Errorf("screenshotGeometry(%d, %d) = %q, %v, want %q", tt.width, tt.height, got, err, tt.want)
+		}
+	}
+
+	if _, err := screenshotGeometry(MinWindowWidth-1, 600, config); err == nil {
+		t.Error("a width below the minimum window size should fail")
+	}
+}
+
+func TestTakeScreenshotWaitsForRender(t *testing.T) {
+	useTempSocketDir(t)
+	app := NewApp(FileEntry{Name: "page.html", Content: "<p>page</p>"}, "")
+	recordEvents(app)
+	quit := make(chan struct{})
+	app.quit = func(ctx context.Context) { close(quit) }
+	captured := make(chan string, 1)
+	app.capture = func(ctx context.Context, path string) error {
+		captured <- path
+		return nil
+	}
+	socketPath := getWindowSocketPath("5f0c8a2e-1b3d-4e6f-9a7b-c8d9e0f1a2b3")
+	startTestServer(t, app, socketPath)
+
+	done := make(chan error, 1)
+	go func() { done <- takeScreenshot(socketPath, "/tmp/out.png", "") }()
+
+	select {
+	case <-captured:
+		t.Fatal("the window was captured before its content rendered")
+	case <-time.After(100 * time.Millisecond):
+	}
+	app.NotifyRendered()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("takeScreenshot() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("takeScreenshot() didn't return after the content rendered")
+	}
+	if path := <-captured; path != "/tmp/out.png" {
+		t.Errorf("captured to %q, want /tmp/out.png", path)
+	}
+	select {
+	case <-quit:
+	case <-time.After(time.Second):
+		t.Error("the window should be closed after the screenshot")
+	}
+}
+
+func TestTakeScreenshotCaptureFails(t *testing.T) {
+	useTempSocketDir(t)
+	app := NewApp(FileEntry{Name: "page.html", Content: "<p>page</p>"}, "")
+	recordEvents(app)
+	quit := make(chan struct{})
+	app.quit = func(ctx context.Context) { close(quit) }
+	app.capture = func(ctx context.Context, path string) error {
+		return errors.New("import failed: no display")
+	}
+	app.NotifyRendered()
+	socketPath := getWindowSocketPath("5f0c8a2e-1b3d-4e6f-9a7b-c8d9e0f1a2b3")
+	startTestServer(t, app, socketPath)
+
+	err := takeScreenshot(socketPath, "/tmp/out.png", "")
+	if err == nil || !strings.Contains(err.Error(), "no display") {
+		t.Errorf("takeScreenshot() error = %v, want the capture error", err)
+	}
+	select {
+	case <-quit:
+	case <-time.After(time.Second):
+		t.Error("the window should be closed even when the capture fails")
+	}
+}
+
+func TestCaptureScreenshotNeedsWindow(t *testing.T) {
+	app := NewApp(FileEntry{Name: "page.html"}, "")
+	if err := app.captureScreenshot("/tmp/out.png"); !errors.Is(err, errNoWindow) {
+		t.Errorf("captureScreenshot() before startup error = %v, want errNoWindow", err)
+	}
+}
+
+// TestScreenshotIntegration builds fenestro and captures a real window. It
+// needs a desktop session, so it only runs with FENESTRO_INTEGRATION=1.
+func TestScreenshotIntegration(t *testing.T) {
+	if os.Getenv("FENESTRO_INTEGRATION") != "1" {
+		t.Skip("set FENESTRO_INTEGRATION=1 to capture a real window")
+	}
+	if reason := headlessReason(runtime.GOOS, os.Getenv); reason != "" {
+		t.Skipf("no display: %s", reason)
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "fenestro")
+	if out, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		t.Fatalf("go build failed: %v\n%s", err, out)
+	}
+	page := filepath.Join(dir, "page.html")
+	if err := os.WriteFile(page, []byte(`<h1 style="color: red">Screenshot</h1>`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	shot := filepath.Join(dir, "shot.png")
+
+	cmd := exec.Command(binary, "-p", page, "--screenshot", shot, "--width", "800", "--height", "600")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("fenestro --screenshot failed: %v\n%s", err, out)
+	}
+	data, err := os.ReadFile(shot)
+	if err != nil {
+		t.Fatalf("screenshot wasn't written: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		t.Error("screenshot isn't a PNG file")
+	}
+}
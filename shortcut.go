@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isShortcutPath reports whether path is a URL shortcut (macOS .webloc or
+// Windows .url) that's opened by fetching its URL rather than displayed
+func isShortcutPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".webloc", ".url":
+		return true
+	}
+	return false
+}
+
+// readShortcut returns the URL a .webloc or .url file points to
+func readShortcut(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var target string
+	if strings.EqualFold(filepath.Ext(path), ".webloc") {
+		target, err = parseWebloc(data)
+	} else {
+		target, err = parseURLShortcut(data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("%s: unsupported URL %q (expected http or https)", filepath.Base(path), target)
+	}
+	return target, nil
+}
+
+// parseWebloc extracts the URL from a .webloc property list, the string
+// following the URL key. Binary property lists aren't supported.
+func parseWebloc(data []byte) (string, error) {
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		return "", errors.New("binary .webloc files are not supported (convert with plutil -convert xml1)")
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	afterURLKey := false
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			return "", errors.New("no URL found in .webloc file")
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid .webloc file: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "key":
+			var key string
+			if err := decoder.DecodeElement(&key, &start); err != nil {
+				return "", fmt.Errorf("invalid .webloc file: %w", err)
+			}
+			afterURLKey = key == "URL"
+		case "string":
+			var value string
+			if err := decoder.DecodeElement(&value, &start); err != nil {
+				return "", fmt.Errorf("invalid .webloc file: %w", err)
+			}
+			if afterURLKey {
+				return strings.TrimSpace(value), nil
+			}
+		default:
+			afterURLKey = false
+		}
+	}
+}
+
+// parseURLShortcut extracts the URL= value from the [InternetShortcut]
+// section of a Windows .url file
+func parseURLShortcut(data []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	inSection := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.EqualFold(line, "[InternetShortcut]")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if inSection && ok && strings.EqualFold(strings.TrimSpace(key), "URL") {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("no URL found in [InternetShortcut] section")
+}
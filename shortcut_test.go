@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testWebloc = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>URL</key>
+	<string>https://example.com/report?id=7&amp;view=full</string>
+</dict>
+</plist>
+`
+
+func TestParseWebloc(t *testing.T) {
+	got, err := parseWebloc([]byte(testWebloc))
+	if err != nil {
+		t.Fatalf("parseWebloc() error = %v", err)
+	}
+	if got != "https://example.com/report?id=7&view=full" {
+		t.Errorf("parseWebloc() = %q, want the URL string", got)
+	}
+}
+
+func TestParseWeblocErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"other keys only", `<plist><dict><key>Name</key><string>https://example.com</string></dict></plist>`},
+		{"not XML", `<plist><dict><key>URL</key>`},
+		{"binary plist", "bplist00\x00\x01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := parseWebloc([]byte(tt.data)); err == nil {
+				t.Errorf("parseWebloc() = %q, want an error", got)
+			}
+		})
+	}
+}
+
+func TestParseURLShortcut(t *testing.T) {
+	data := "[{000214A0-0000-0000-C000-000000000046}]\r\nProp3=19,11\r\n[InternetShortcut]\r\nIDList=\r\nURL=https://example.com/docs?page=2\r\nIconIndex=0\r\n"
+
+	got, err := parseURLShortcut([]byte(data))
+	if err != nil {
+		t.Fatalf("parseURLShortcut() error = %v", err)
+	}
+	if got != "https://example.com/docs?page=2" {
+		t.Errorf("parseURLShortcut() = %q, want the URL= value", got)
+	}
+}
+
+func TestParseURLShortcutIgnoresOtherSections(t *testing.T) {
+	data := "[Other]\nURL=https://wrong.example.com\n"
+	if got, err := parseURLShortcut([]byte(data)); err == nil {
+		t.Errorf("parseURLShortcut() = %q, want an error without an [InternetShortcut] URL", got)
+	}
+}
+
+func TestReadShortcut(t *testing.T) {
+	dir := t.TempDir()
+	webloc := filepath.Join(dir, "Report.webloc")
+	url := filepath.Join(dir, "Docs.URL")
+	file := filepath.Join(dir, "Local.url")
+	for path, content := range map[string]string{
+		webloc: testWebloc,
+		url:    "[InternetShortcut]\nURL=http://example.com/\n",
+		file:   "[InternetShortcut]\nURL=file:///etc/passwd\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	if got, err := readShortcut(webloc); err != nil || !strings.HasPrefix(got, "https://example.com/report") {
+		t.Errorf("readShortcut(.webloc) = %q, %v", got, err)
+	}
+	if got, err := readShortcut(url); err != nil || got != "http://example.com/" {
+		t.Errorf("readShortcut(.URL) = %q, %v", got, err)
+	}
+	if _, err := readShortcut(file); err == nil || !strings.Contains(err.Error(), "Local.url") {
+		t.Errorf("readShortcut(file URL) error = %v, want an error naming the shortcut", err)
+	}
+}
+
+func TestIsShortcutPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"Report.webloc": true,
+		"docs.URL":      true,
+		"page.html":     false,
+		"url":           false,
+	} {
+		if got := isShortcutPath(path); got != want {
+			t.Errorf("isShortcutPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
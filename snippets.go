@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"regexp"
+)
+
+var (
+	bodyOpenPattern  = regexp.MustCompile(`(?i)<body[^>]*>`)
+	bodyClosePattern = regexp.MustCompile(`(?i)</body\s*>`)
+)
+
+// loadSnippet reads an HTML snippet file (header_html/footer_html)
+// Returns empty string if no path is configured, or if the file can't be
+// read, along with the error (see App.loadSnippets)
+func loadSnippet(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// wrapContent places header at the start of the document body and footer at
+// its end. Documents without a <body> tag are wrapped as a whole.
+func wrapContent(content, header, footer string) string {
+	if header == "" && footer == "" {
+		return content
+	}
+
+	if footer != "" {
+		if locs := bodyClosePattern.FindAllStringIndex(content, -1); len(locs) > 0 {
+			end := locs[len(locs)-1][0]
+			content = content[:end] + footer + content[end:]
+		} else {
+			content += footer
+		}
+	}
+
+	if header != "" {
+		if loc := bodyOpenPattern.FindStringIndex(content); loc != nil {
+			content = content[:loc[1]] + header + content[loc[1]:]
+		} else {
+			content = header + content
+		}
+	}
+
+	return content
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSnippet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "header.html")
+	if err := os.WriteFile(path, []byte("<header>Brand</header>"), 0644); err != nil {
+		t.Fatalf("Could not write snippet: %v", err)
+	}
+
+	if got, err := loadSnippet(path); got != "<header>Brand</header>" || err != nil {
+		t.Errorf("loadSnippet() = %q, %v, expected the file's content", got, err)
+	}
+	if got, err := loadSnippet(""); got != "" || err != nil {
+		t.Errorf("loadSnippet(\"\") = %q, %v, expected empty string", got, err)
+	}
+	if got, err := loadSnippet(filepath.Join(t.TempDir(), "missing.html")); got != "" || err == nil {
+		t.Errorf("loadSnippet(missing) = %q, %v, expected missing files to be skipped with an error", got, err)
+	}
+}
+
+func TestWrapContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		header   string
+		footer   string
+		expected string
+	}{
+		{
+			name:     "no snippets",
+			content:  "<p>doc</p>",
+			expected: "<p>doc</p>",
+		},
+		{
+			name:     "inside body",
+			content:  `<html><body class="x"><p>doc</p></body></html>`,
+			header:   "<header>H</header>",
+			footer:   "<footer>F</footer>",
+			expected: `<html><body class="x"><header>H</header><p>doc</p><footer>F</footer></body></html>`,
+		},
+		{
+			name:     "uppercase body tags",
+			content:  "<BODY><p>doc</p></BODY>",
+			header:   "H",
+			footer:   "F",
+			expected: "<BODY>H<p>doc</p>F</BODY>",
+		},
+		{
+			name:     "fragment without body",
+			content:  "<p>doc</p>",
+			header:   "H",
+			footer:   "F",
+			expected: "H<p>doc</p>F",
+		},
+		{
+			name:     "header only",
+			content:  "<body><p>doc</p></body>",
+			header:   "H",
+			expected: "<body>H<p>doc</p></body>",
+		},
+		{
+			name:     "footer only",
+			content:  "<body><p>doc</p></body>",
+			footer:   "F",
+			expected: "<body><p>doc</p>F</body>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapContent(tt.content, tt.header, tt.footer); got != tt.expected {
+				t.Errorf("wrapContent() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetHTMLContentWrapsSnippets(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Content: "<body><p>doc</p></body>"}, "")
+	app.headerHTML = "<header>H</header>"
+	app.footerHTML = "<footer>F</footer>"
+
+	expected := "<body><header>H</header><p>doc</p><footer>F</footer></body>"
+	if got := app.GetHTMLContent(); got != expected {
+		t.Errorf("GetHTMLContent() = %q, expected %q", got, expected)
+	}
+	if got := app.SelectFile(0); got != expected {
+		t.Errorf("SelectFile() = %q, expected %q", got, expected)
+	}
+	// The stored content is left as-is
+	if got := app.GetFiles()[0].Content; got != "<body><p>doc</p></body>" {
+		t.Errorf("stored content = %q, expected it unchanged", got)
+	}
+}
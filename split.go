@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// unescapeDelimiter interprets Go-style escapes such as \f and \n in a
+// --split delimiter, so they can be typed on the command line. Delimiters
+// that aren't valid escapes are used verbatim.
+func unescapeDelimiter(delim string) string {
+	if unquoted, err := strconv.Unquote(`"` + delim + `"`); err == nil {
+		return unquoted
+	}
+	return delim
+}
+
+// splitDocuments splits content on delim, dropping segments that are empty
+// or only whitespace
+func splitDocuments(content, delim string) []string {
+	var docs []string
+	for _, segment := range strings.Split(content, delim) {
+		if strings.TrimSpace(segment) != "" {
+			docs = append(docs, segment)
+		}
+	}
+	return docs
+}
+
+// splitEntries turns piped content into one entry per document, named
+// <name>-1, <name>-2, and so on after the original entry
+func splitEntries(entry FileEntry, delim string) []FileEntry {
+	docs := splitDocuments(entry.Content, delim)
+	entries := make([]FileEntry, len(docs))
+	for i, doc := range docs {
+		entries[i] = entry
+		entries[i].Name = fmt.Sprintf("%s-%d", entry.Name, i+1)
+		entries[i].Content = doc
+	}
+	return entries
+}
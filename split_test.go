@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnescapeDelimiter(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`\f`, "\f"},
+		{`\n---\n`, "\n---\n"},
+		{"---", "---"},
+		{`\x00`, "\x00"},
+		{`"`, `"`},
+		{`\q`, `\q`},
+	}
+
+	for _, tt := range tests {
+		if got := unescapeDelimiter(tt.input); got != tt.want {
+			t.Errorf("unescapeDelimiter(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSplitDocuments(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		delim   string
+		want    []string
+	}{
+		{"form feed", "<p>one</p>\f<p>two</p>", "\f", []string{"<p>one</p>", "<p>two</p>"}},
+		{"multi-character", "a\n---\nb\n---\nc", "\n---\n", []string{"a", "b", "c"}},
+		{"leading and trailing empties", "\fa\fb\f", "\f", []string{"a", "b"}},
+		{"consecutive delimiters", "a\f\f\fb", "\f", []string{"a", "b"}},
+		{"whitespace-only segments", "a\f \n\t\fb", "\f", []string{"a", "b"}},
+		{"no delimiter present", "just one", "\f", []string{"just one"}},
+		{"only delimiters", "\f\f", "\f", nil},
+		{"empty content", "", "\f", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitDocuments(tt.content, tt.delim); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitDocuments(%q, %q) = %q, want %q", tt.content, tt.delim, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitEntriesNaming(t *testing.T) {
+	entry := FileEntry{Name: "stdin", Content: "one\ftwo\f\fthree", ContentType: ContentTypeMarkdown}
+
+	entries := splitEntries(entry, "\f")
+	if len(entries) != 3 {
+		t.Fatalf("splitEntries() returned %d entries, want 3", len(entries))
+	}
+	for i, want := range []struct{ name, content string }{{"stdin-1", "one"}, {"stdin-2", "two"}, {"stdin-3", "three"}} {
+		if entries[i].Name != want.name || entries[i].Content != want.content {
+			t.Errorf("entry %d = %q/%q, want %q/%q", i, entries[i].Name, entries[i].Content, want.name, want.content)
+		}
+		if entries[i].ContentType != ContentTypeMarkdown {
+			t.Errorf("entry %d ContentType = %q, want it copied from the original", i, entries[i].ContentType)
+		}
+	}
+}
+
+func TestOpenDocumentsSelectsInRunningSidebar(t *testing.T) {
+	useTempSocketDir(t)
+	app := NewApp(FileEntry{Name: "old.html", Path: "/tmp/old.html", Content: "<p>old</p>"}, "")
+	recordEvents(app)
+	startTestServer(t, app, getSidebarSocketPath())
+
+	oldSelect, oldName := selectFile, displayName
+	selectFile = "stdin-2"
+	t.Cleanup(func() { selectFile, displayName = oldSelect, oldName })
+
+	documents := splitEntries(FileEntry{Name: "stdin", Content: "one\ftwo\fthree"}, "\f")
+	if err := openDocuments(documents, "", DefaultConfig()); err != nil {
+		t.Fatalf("openDocuments() error = %v", err)
+	}
+
+	if got := len(app.GetFiles()); got != 4 {
+		t.Fatalf("sidebar has %d files, want the 3 documents added to the existing one", got)
+	}
+	if got := app.GetCurrentFileName(); got != "stdin-2" {
+		t.Errorf("selected file = %q, want stdin-2 from --select", got)
+	}
+}
@@ -13,6 +13,10 @@ type WindowState struct {
 	Height int `json:"height"`
 	X      int `json:"x"`
 	Y      int `json:"y"`
+	// Viewport is the content width set with --viewport (0 = full window)
+	Viewport int `json:"viewport,omitempty"`
+	// Zoom is the page zoom factor (0 = unset, meaning 1.0; see normalizeZoom)
+	Zoom float64 `json:"zoom,omitempty"`
 }
 
 // IsValid returns true if the state has valid dimensions
@@ -20,19 +24,23 @@ func (s *WindowState) IsValid() bool {
 	return s != nil && s.Width > 0 && s.Height > 0
 }
 
-// getStatePath returns the path to the state file
-func getStatePath() string {
+// getStatePathForInstance returns the state file path for a named instance
+// (state-<name>.json), or the shared state file if name is empty
+func getStatePathForInstance(name string) string {
 	configDir := getConfigDir()
 	if configDir == "" {
 		return ""
 	}
-	return filepath.Join(configDir, "state.json")
+	if name == "" {
+		return filepath.Join(configDir, "state.json")
+	}
+	return filepath.Join(configDir, "state-"+name+".json")
 }
 
-// LoadWindowState loads the window state from the state file
+// LoadWindowState loads the window state from the instance's state file
 // Returns nil if no state exists or can't be read
-func LoadWindowState() *WindowState {
-	statePath := getStatePath()
+func LoadWindowState(instance string) *WindowState {
+	statePath := getStatePathForInstance(instance)
 	if statePath == "" {
 		return nil
 	}
@@ -56,13 +64,29 @@ func LoadWindowState() *WindowState {
 	return &state
 }
 
-// SaveWindowState saves the window state to the state file
-func SaveWindowState(state WindowState) error {
+// RemoveWindowState deletes a named instance's state file. A file that
+// doesn't exist isn't an error.
+func RemoveWindowState(instance string) error {
+	if instance == "" {
+		return fmt.Errorf("only windows opened with --instance have their own saved state")
+	}
+	statePath := getStatePathForInstance(instance)
+	if statePath == "" {
+		return fmt.Errorf("could not determine state file path")
+	}
+	if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove state file: %w", err)
+	}
+	return nil
+}
+
+// SaveWindowState saves the window state to the instance's state file
+func SaveWindowState(instance string, state WindowState) error {
 	if !state.IsValid() {
 		return nil // Don't save invalid state
 	}
 
-	statePath := getStatePath()
+	statePath := getStatePathForInstance(instance)
 	if statePath == "" {
 		return fmt.Errorf("could not determine state file path")
 	}
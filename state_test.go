@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -40,7 +41,7 @@ func TestLoadWindowStateNoFile(t *testing.T) {
 
 	// Point to a directory that doesn't exist
 	os.Setenv("XDG_CONFIG_HOME", "/nonexistent/path")
-	state := LoadWindowState()
+	state := LoadWindowState("")
 
 	if state != nil {
 		t.Errorf("Expected nil state when file doesn't exist, got %+v", state)
@@ -68,7 +69,7 @@ func TestSaveAndLoadWindowState(t *testing.T) {
 		X:      150,
 		Y:      75,
 	}
-	err = SaveWindowState(state)
+	err = SaveWindowState("", state)
 	if err != nil {
 		t.Fatalf("Failed to save state: %v", err)
 	}
@@ -80,7 +81,7 @@ func TestSaveAndLoadWindowState(t *testing.T) {
 	}
 
 	// Load state
-	loaded := LoadWindowState()
+	loaded := LoadWindowState("")
 	if loaded == nil {
 		t.Fatalf("LoadWindowState returned nil")
 	}
@@ -115,7 +116,7 @@ func TestSaveWindowStateInvalid(t *testing.T) {
 
 	// Try to save invalid state
 	state := WindowState{Width: 0, Height: 0}
-	err = SaveWindowState(state)
+	err = SaveWindowState("", state)
 	if err != nil {
 		t.Errorf("SaveWindowState should not return error for invalid state, got: %v", err)
 	}
@@ -151,7 +152,7 @@ func TestLoadWindowStateInvalidJSON(t *testing.T) {
 	defer os.Setenv("XDG_CONFIG_HOME", original)
 
 	os.Setenv("XDG_CONFIG_HOME", tmpDir)
-	state := LoadWindowState()
+	state := LoadWindowState("")
 
 	if state != nil {
 		t.Errorf("Expected nil state on invalid JSON, got %+v", state)
@@ -183,9 +184,97 @@ func TestLoadWindowStateInvalidValues(t *testing.T) {
 	defer os.Setenv("XDG_CONFIG_HOME", original)
 
 	os.Setenv("XDG_CONFIG_HOME", tmpDir)
-	state := LoadWindowState()
+	state := LoadWindowState("")
 
 	if state != nil {
 		t.Errorf("Expected nil state when dimensions are zero, got %+v", state)
 	}
 }
+
+func TestGetStatePathForInstance(t *testing.T) {
+	useTempConfigDir(t)
+	configDir := getConfigDir()
+
+	if got := getStatePathForInstance(""); got != filepath.Join(configDir, "state.json") {
+		t.Errorf("getStatePathForInstance(\"\") = %q, want the shared state.json", got)
+	}
+
+	docs := getStatePathForInstance("docs")
+	diff := getStatePathForInstance("diff")
+	if docs == diff {
+		t.Errorf("different instances should use different state files, both got %q", docs)
+	}
+	if docs != filepath.Join(configDir, "state-docs.json") {
+		t.Errorf("getStatePathForInstance(\"docs\") = %q, want state-docs.json in the config dir", docs)
+	}
+}
+
+func TestSaveAndLoadWindowStatePerInstance(t *testing.T) {
+	useTempConfigDir(t)
+
+	docs := WindowState{Width: 1200, Height: 800, X: 10, Y: 20}
+	diff := WindowState{Width: 600, Height: 400, X: 300, Y: 40}
+	if err := SaveWindowState("docs", docs); err != nil {
+		t.Fatalf("SaveWindowState(docs) error = %v", err)
+	}
+	if err := SaveWindowState("diff", diff); err != nil {
+		t.Fatalf("SaveWindowState(diff) error = %v", err)
+	}
+
+	for _, name := range []string{"docs", "diff"} {
+		if _, err := os.Stat(getStatePathForInstance(name)); err != nil {
+			t.Errorf("state file for %s should exist: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(getStatePathForInstance("")); !os.IsNotExist(err) {
+		t.Errorf("named instances should not write the shared state file (err = %v)", err)
+	}
+
+	if got := LoadWindowState("docs"); got == nil || *got != docs {
+		t.Errorf("LoadWindowState(docs) = %+v, want %+v", got, docs)
+	}
+	if got := LoadWindowState("diff"); got == nil || *got != diff {
+		t.Errorf("LoadWindowState(diff) = %+v, want %+v", got, diff)
+	}
+	if got := LoadWindowState(""); got != nil {
+		t.Errorf("LoadWindowState(\"\") = %+v, want nil", got)
+	}
+}
+
+func TestWindowStateViewportSerialization(t *testing.T) {
+	data, err := json.Marshal(WindowState{Width: 800, Height: 600, Viewport: 375})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if want := `{"width":800,"height":600,"x":0,"y":0,"viewport":375}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	// Full width is the default and is left out of the file
+	data, _ = json.Marshal(WindowState{Width: 800, Height: 600})
+	if want := `{"width":800,"height":600,"x":0,"y":0}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	// State files written before viewport existed load as full width
+	var old WindowState
+	if err := json.Unmarshal([]byte(`{"width":800,"height":600,"x":10,"y":20}`), &old); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if old.Viewport != 0 {
+		t.Errorf("Viewport = %d, want 0 for an old state file", old.Viewport)
+	}
+}
+
+func TestSaveAndLoadWindowStateViewport(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tmpDir)
+
+	if err := SaveWindowState("", WindowState{Width: 1200, Height: 800, Viewport: 768}); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+	loaded := LoadWindowState("")
+	if loaded == nil || loaded.Viewport != 768 {
+		t.Errorf("LoadWindowState() = %+v, want viewport 768", loaded)
+	}
+}
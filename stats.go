@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// AppStats summarizes a window's contents for health monitoring
+type AppStats struct {
+	FileCount    int    `json:"file_count"`
+	SelectedName string `json:"selected_name"`
+	// ContentBytes is the content held in memory, counting compressed files
+	// (compress_inactive) at their compressed size
+	ContentBytes int `json:"content_bytes"`
+}
+
+// GetStats returns the window's file count, selected file, and content size.
+// It only takes the read lock, so it doesn't hold up senders for long.
+func (a *App) GetStats() AppStats {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	stats := AppStats{FileCount: len(a.files)}
+	if a.currentIndex >= 0 && a.currentIndex < len(a.files) {
+		stats.SelectedName = a.files[a.currentIndex].Name
+	}
+	for _, f := range a.files {
+		stats.ContentBytes += len(f.Content) + len(f.compressed)
+	}
+	return stats
+}
+
+// printStats asks the window listening on socketPath for its stats and
+// writes them to w
+func printStats(w io.Writer, socketPath, token string) error {
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "ping", Token: token})
+	if err != nil {
+		return fmt.Errorf("no window is listening on %s", socketPath)
+	}
+	if !resp.OK {
+		return fmt.Errorf("window refused the request: %s", resp.Error)
+	}
+	if resp.Stats == nil {
+		return fmt.Errorf("window did not report stats")
+	}
+	fmt.Fprintf(w, "Files: %d\n", resp.Stats.FileCount)
+	fmt.Fprintf(w, "Selected: %s\n", resp.Stats.SelectedName)
+	fmt.Fprintf(w, "Content bytes: %d\n", resp.Stats.ContentBytes)
+	return nil
+}
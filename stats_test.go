@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetStats(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "12345"}, "")
+	app.AddFile(FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "1234567890"})
+	app.SelectFile(1)
+
+	stats := app.GetStats()
+	if stats.FileCount != 2 {
+		t.Errorf("FileCount = %d, want 2", stats.FileCount)
+	}
+	if stats.SelectedName != "b.html" {
+		t.Errorf("SelectedName = %q, want b.html", stats.SelectedName)
+	}
+	if stats.ContentBytes != 15 {
+		t.Errorf("ContentBytes = %d, want 15", stats.ContentBytes)
+	}
+}
+
+func TestPingReportsStats(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "stats-window")
+	app.AddFile(FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "<p>bb</p>"})
+	app.AddFile(FileEntry{Name: "c.html", Path: "/tmp/c.html", Content: "<p>ccc</p>"})
+
+	socketPath := filepath.Join(os.TempDir(), "fenestro-test-stats.sock")
+	os.Remove(socketPath)
+
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	defer server.Close()
+
+	resp, err := SendCommand(socketPath, IPCCommand{Cmd: "ping"})
+	if err != nil {
+		t.Fatalf("SendCommand() error = %v", err)
+	}
+	want := app.GetStats()
+	if resp.Stats == nil || *resp.Stats != want {
+		t.Fatalf("ping stats = %+v, want %+v", resp.Stats, want)
+	}
+	if want.FileCount != 3 || want.ContentBytes != len("<p>a</p>")+len("<p>bb</p>")+len("<p>ccc</p>") {
+		t.Errorf("stats = %+v, don't match the files added", want)
+	}
+
+	var out bytes.Buffer
+	if err := printStats(&out, socketPath, ""); err != nil {
+		t.Fatalf("printStats() error = %v", err)
+	}
+	for _, line := range []string{"Files: 3", "Selected: a.html", "Content bytes: 27"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("printStats() output %q should contain %q", out.String(), line)
+		}
+	}
+}
+
+func TestPrintStatsNoWindow(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "missing.sock")
+	if err := printStats(&bytes.Buffer{}, socketPath, ""); err == nil {
+		t.Error("printStats() should fail when no window is listening")
+	}
+}
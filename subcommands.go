@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	flag "github.com/spf13/pflag"
+)
+
+// subcommand is a "fenestro <name>" command with its own flags. "open" isn't
+// listed: it's the default behavior, handled by main's flags.
+type subcommand struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var subcommands map[string]subcommand
+
+func init() {
+	subcommands = map[string]subcommand{
+		"list":   {"List running windows", runList},
+		"close":  {"Close the sidebar window, the --id window, or --all windows", runClose},
+		"gc":     {"Remove sockets left behind by windows that are no longer running", runGC},
+		"doctor": {"Print environment details and check the config file and sockets", runDoctor},
+		"stats":  {"Print the sidebar window's (or the --id window's) file count, selection, and content size", runStats},
+	}
+}
+
+// runSubcommand runs the subcommand named by args[0], if there is one, and
+// returns its exit code. Anything else (a path, a flag, or "open") is left to
+// the default behavior, so "fenestro index.html" keeps working. A file that
+// shares a subcommand's name can be opened as ./name or with -p.
+func runSubcommand(args []string, stdout, stderr io.Writer) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	sub, ok := subcommands[args[0]]
+	if !ok {
+		return 0, false
+	}
+	return sub.run(args[1:], stdout, stderr), true
+}
+
+// subcommandUsage lists the subcommands for the main usage text
+func subcommandUsage() string {
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("  open   Open a file, URL, or piped content (the default)\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-6s %s\n", name, subcommands[name].summary)
+	}
+	return b.String()
+}
+
+// newSubcommandFlags creates the flag set for a subcommand. Parse errors
+// are reported to stderr rather than exiting.
+func newSubcommandFlags(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// targetSocketPath returns the socket of the window with the given ID, or
+// of the sidebar window if id is empty
+func targetSocketPath(id string) (string, error) {
+	if id == "" {
+		return getSidebarSocketPath(), nil
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return "", fmt.Errorf("invalid window ID format (expected UUID): %s", id)
+	}
+	return getWindowSocketPath(id), nil
+}
+
+// windowInfo describes a running window for "fenestro list"
+type windowInfo struct {
+	ID       string   `json:"id,omitempty"`       // window ID mode windows
+	Instance string   `json:"instance,omitempty"` // named sidebar windows (--instance)
+	Sidebar  bool     `json:"sidebar"`
+	Stats    AppStats `json:"stats"`
+	socket   string   // socket the window answered on
+}
+
+// label names the window the way it's targeted on the command line
+func (w windowInfo) label() string {
+	switch {
+	case !w.Sidebar:
+		return w.ID
+	case w.Instance != "":
+		return "sidebar (" + w.Instance + ")"
+	}
+	return "sidebar"
+}
+
+// listWindows pings every socket in dir and its windows subdirectory and
+// returns the windows that answer, sidebar windows first. Sockets nobody
+// answers on are skipped; "fenestro gc" removes them.
+func listWindows(dir, token string) []windowInfo {
+	var windows []windowInfo
+	add := func(socketPath string, info windowInfo) {
+		resp, err := SendCommand(socketPath, IPCCommand{Cmd: "ping", Token: token})
+		if err != nil || !resp.OK {
+			return
+		}
+		if resp.Stats != nil {
+			info.Stats = *resp.Stats
+		}
+		info.socket = socketPath
+		windows = append(windows, info)
+	}
+
+	sidebars, _ := filepath.Glob(filepath.Join(dir, "fenestro*.sock"))
+	var instances []string
+	for _, path := range sidebars {
+		name := strings.TrimSuffix(filepath.Base(path), ".sock")
+		instances = append(instances, strings.TrimPrefix(strings.TrimPrefix(name, "fenestro"), "-"))
+	}
+	// The default sidebar ("") sorts before named instances
+	sort.Strings(instances)
+	for _, name := range instances {
+		add(filepath.Join(dir, sidebarSocketFile(name)), windowInfo{Sidebar: true, Instance: name})
+	}
+
+	ids, _ := filepath.Glob(filepath.Join(dir, windowsDir, "*.sock"))
+	sort.Strings(ids)
+	for _, path := range ids {
+		add(path, windowInfo{ID: strings.TrimSuffix(filepath.Base(path), ".sock")})
+	}
+	return windows
+}
+
+// runList implements "fenestro list [--json]"
+func runList(args []string, stdout, stderr io.Writer) int {
+	fs := newSubcommandFlags("list", stderr)
+	asJSON := fs.Bool("json", false, "Print the windows as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	windows := listWindows(getSocketDir(), LoadConfig().IPCToken)
+	if *asJSON {
+		if windows == nil {
+			windows = []windowInfo{}
+		}
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(windows); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(windows) == 0 {
+		fmt.Fprintln(stdout, "No windows running")
+		return 0
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "WINDOW\tFILES\tSELECTED")
+	for _, w := range windows {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", w.label(), w.Stats.FileCount, w.Stats.SelectedName)
+	}
+	tw.Flush()
+	return 0
+}
+
+// runClose implements "fenestro close [--id <uuid>] [--instance name] [--all] [--forget]"
+func runClose(args []string, stdout, stderr io.Writer) int {
+	fs := newSubcommandFlags("close", stderr)
+	id := fs.String("id", "", "Window ID of the window to close")
+	fs.StringVar(&instance, "instance", "", "Close this named sidebar window")
+	all := fs.Bool("all", false, "Close every running window")
+	forget := fs.Bool("forget", false, "Also remove the window's saved size and position (windows opened with --instance)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *all {
+		if *forget {
+			fmt.Fprintln(stderr, "Error: --forget closes a single window and can't be used with --all")
+			return 1
+		}
+		return closeAll(getSocketDir(), LoadConfig().IPCToken, stdout, stderr)
+	}
+	if instance != "" {
+		if err := checkInstanceName(instance); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	token := LoadConfig().IPCToken
+	socketPath, err := targetSocketPath(*id)
+	if err == nil && *id != "" && !verifyWindowIdentity(socketPath, *id, token) {
+		err = fmt.Errorf("no window with ID %s is running", *id)
+	}
+	if err == nil {
+		var resp IPCResponse
+		resp, err = SendCommand(socketPath, IPCCommand{Cmd: "close", Forget: *forget, Token: token})
+		if err != nil {
+			err = fmt.Errorf("no window is listening on %s", socketPath)
+		} else if !resp.OK {
+			err = fmt.Errorf("window refused to close: %s", resp.Error)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// closeAll removes the stale sockets in dir, then asks every window still
+// listening to close and reports the counts (close --all and --close-all).
+// Stale sockets go first so a window that is shutting down isn't counted as
+// both closed and stale.
+func closeAll(dir, token string, stdout, stderr io.Writer) int {
+	removed := 0
+	for _, d := range []string{dir, filepath.Join(dir, windowsDir)} {
+		n, err := CleanStaleSockets(d)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		removed += n
+	}
+
+	closed, failed := 0, 0
+	for _, w := range listWindows(dir, token) {
+		resp, err := SendCommand(w.socket, IPCCommand{Cmd: "close", Token: token})
+		if err != nil || !resp.OK {
+			fmt.Fprintf(stderr, "Warning: could not close %s\n", w.label())
+			failed++
+			continue
+		}
+		closed++
+	}
+
+	fmt.Fprintf(stdout, "Closed %d window(s), removed %d stale socket(s)\n", closed, removed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runGC implements "fenestro gc" (and --gc)
+func runGC(args []string, stdout, stderr io.Writer) int {
+	fs := newSubcommandFlags("gc", stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	removed := 0
+	for _, dir := range []string{getSocketDir(), filepath.Join(getSocketDir(), windowsDir)} {
+		n, err := CleanStaleSockets(dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		removed += n
+	}
+	fmt.Fprintf(stdout, "Removed %d stale socket(s)\n", removed)
+	return 0
+}
+
+// runStats implements "fenestro stats [--id <uuid>] [--instance name]" (and --stats)
+func runStats(args []string, stdout, stderr io.Writer) int {
+	fs := newSubcommandFlags("stats", stderr)
+	id := fs.String("id", "", "Window ID of the window to report on")
+	fs.StringVar(&instance, "instance", "", "Report on this named sidebar window")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if instance != "" {
+		if err := checkInstanceName(instance); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	socketPath, err := targetSocketPath(*id)
+	if err == nil {
+		err = printStats(stdout, socketPath, LoadConfig().IPCToken)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
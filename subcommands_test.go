@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTempSocketDir points getSocketDir at a fresh directory. The path is kept
+// short since Unix socket paths are length-limited.
+func useTempSocketDir(t *testing.T) {
+	t.Helper()
+	home, err := os.MkdirTemp("", "fx")
+	if err != nil {
+		t.Fatalf("MkdirTemp failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(home) })
+	t.Setenv("HOME", home)
+}
+
+// startTestServer starts an IPC server for app on socketPath
+func startTestServer(t *testing.T, app *App, socketPath string) {
+	t.Helper()
+	server, err := NewIPCServer(app, socketPath, false)
+	if err != nil {
+		t.Fatalf("NewIPCServer() failed: %v", err)
+	}
+	server.Start()
+	t.Cleanup(func() { server.Close() })
+}
+
+func TestRunSubcommandLeavesDefaultBehavior(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"index.html"},
+		{"open", "index.html"},
+		{"-p", "list"},
+		{"./list"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if _, ok := runSubcommand(args, &stdout, &stderr); ok {
+			t.Errorf("runSubcommand(%q) was handled, want it left to the default open behavior", args)
+		}
+	}
+}
+
+func TestRunSubcommandList(t *testing.T) {
+	useTempSocketDir(t)
+	windowID := "123e4567-e89b-12d3-a456-426614174000"
+
+	sidebar := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: "<p>a</p>"}, "")
+	sidebar.AddFile(FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "<p>b</p>"})
+	startTestServer(t, sidebar, getSidebarSocketPathForInstance(""))
+	startTestServer(t, NewApp(FileEntry{Name: "report.html", Content: "<p>r</p>"}, windowID), getWindowSocketPath(windowID))
+	startTestServer(t, NewApp(FileEntry{Name: "docs.html", Content: "<p>d</p>"}, ""), getSidebarSocketPathForInstance("docs"))
+
+	var stdout, stderr bytes.Buffer
+	code, ok := runSubcommand([]string{"list"}, &stdout, &stderr)
+	if !ok || code != 0 {
+		t.Fatalf("runSubcommand(list) = %d, %v; stderr: %s", code, ok, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("list output has %d lines, want a header and 3 windows:\n%s", len(lines), stdout.String())
+	}
+	for i, want := range []string{"WINDOW", "sidebar  ", "sidebar (docs)", windowID} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("line %d = %q, want it to start with %q", i, lines[i], want)
+		}
+	}
+	if fields := strings.Fields(lines[1]); len(fields) < 3 || fields[1] != "2" || fields[2] != "a.html" {
+		t.Errorf("sidebar line = %q, want 2 files with a.html selected", lines[1])
+	}
+}
+
+func TestRunSubcommandListJSON(t *testing.T) {
+	useTempSocketDir(t)
+
+	var stdout, stderr bytes.Buffer
+	if code, _ := runSubcommand([]string{"list", "--json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("list --json exited %d: %s", code, stderr.String())
+	}
+	var windows []windowInfo
+	if err := json.Unmarshal(stdout.Bytes(), &windows); err != nil {
+		t.Fatalf("list --json output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if len(windows) != 0 {
+		t.Errorf("got %d windows, want none", len(windows))
+	}
+}
+
+func TestRunSubcommandListSkipsStaleSockets(t *testing.T) {
+	useTempSocketDir(t)
+	if err := ensureSocketDir(); err != nil {
+		t.Fatalf("ensureSocketDir() failed: %v", err)
+	}
+	// A socket file nobody listens on
+	os.WriteFile(getSidebarSocketPathForInstance(""), nil, 0600)
+
+	var stdout, stderr bytes.Buffer
+	runSubcommand([]string{"list"}, &stdout, &stderr)
+	if got := strings.TrimSpace(stdout.String()); got != "No windows running" {
+		t.Errorf("list output = %q, want no windows", got)
+	}
+}
+
+func TestRunSubcommandClose(t *testing.T) {
+	useTempSocketDir(t)
+	windowID := "123e4567-e89b-12d3-a456-426614174001"
+
+	app := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, windowID)
+	app.ctx = context.Background()
+	quit := make(chan struct{}, 1)
+	app.quit = func(ctx context.Context) { quit <- struct{}{} }
+	startTestServer(t, app, getWindowSocketPath(windowID))
+
+	var stdout, stderr bytes.Buffer
+	if code, _ := runSubcommand([]string{"close", "--id", windowID}, &stdout, &stderr); code != 0 {
+		t.Fatalf("close exited %d: %s", code, stderr.String())
+	}
+	select {
+	case <-quit:
+	case <-time.After(time.Second):
+		t.Fatal("close should quit the window")
+	}
+}
+
+func TestRunSubcommandCloseForget(t *testing.T) {
+	for _, forget := range []bool{false, true} {
+		t.Run(fmt.Sprintf("forget=%v", forget), func(t *testing.T) {
+			useTempSocketDir(t)
+			useTempConfigDir(t)
+			if err := SaveWindowState("review", WindowState{Width: 800, Height: 600}); err != nil {
+				t.Fatalf("SaveWindowState() error = %v", err)
+			}
+
+			app := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, "")
+			app.instance = "review"
+			app.ctx = context.Background()
+			quit := make(chan struct{}, 1)
+			app.quit = func(ctx context.Context) { quit <- struct{}{} }
+			startTestServer(t, app, getSidebarSocketPathForInstance("review"))
+
+			args := []string{"close", "--instance", "review"}
+			if forget {
+				args = append(args, "--forget")
+			}
+			var stdout, stderr bytes.Buffer
+			if code, _ := runSubcommand(args, &stdout, &stderr); code != 0 {
+				t.Fatalf("close exited %d: %s", code, stderr.String())
+			}
+			select {
+			case <-quit:
+			case <-time.After(time.Second):
+				t.Fatal("close should quit the window")
+			}
+
+			_, err := os.Stat(getStatePathForInstance("review"))
+			if forget && !os.IsNotExist(err) {
+				t.Errorf("close --forget should remove the state file, Stat() error = %v", err)
+			}
+			if !forget && err != nil {
+				t.Errorf("close should keep the state file, Stat() error = %v", err)
+			}
+
+			// A resize while the window shuts down mustn't bring it back
+			if forget {
+				app.SaveWindowGeometry()
+				if _, err := os.Stat(getStatePathForInstance("review")); !os.IsNotExist(err) {
+					t.Error("a forgotten window shouldn't save its state again")
+				}
+			}
+		})
+	}
+}
+
+func TestCloseForgetNeedsInstance(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, "")
+	app.ctx = context.Background()
+	if resp := (&IPCServer{app: app}).processCommand(IPCCommand{Cmd: "close", Forget: true}); resp.OK {
+		t.Error("close --forget should fail for a window without an instance, which shares the default state")
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code, _ := runSubcommand([]string{"close", "--all", "--forget"}, &stdout, &stderr); code == 0 {
+		t.Error("close --all --forget should be rejected")
+	}
+}
+
+func TestRunSubcommandCloseErrors(t *testing.T) {
+	useTempSocketDir(t)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no window", []string{"close"}},
+		{"unknown ID", []string{"close", "--id", "123e4567-e89b-12d3-a456-426614174002"}},
+		{"invalid ID", []string{"close", "--id", "not-a-uuid"}},
+		{"unknown flag", []string{"close", "--bogus"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code, _ := runSubcommand(tt.args, &stdout, &stderr); code == 0 {
+				t.Errorf("runSubcommand(%q) succeeded, want an error", tt.args)
+			}
+		})
+	}
+}
+
+func TestIPCCloseWithoutWindow(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, "")
+	if resp := (&IPCServer{app: app}).processCommand(IPCCommand{Cmd: "close"}); resp.OK {
+		t.Error("close should fail when the window hasn't started")
+	}
+}
+
+func TestRunSubcommandStatsAndGC(t *testing.T) {
+	useTempSocketDir(t)
+	startTestServer(t, NewApp(FileEntry{Name: "a.html", Content: "<p>a</p>"}, ""), getSidebarSocketPathForInstance(""))
+
+	var stdout, stderr bytes.Buffer
+	if code, _ := runSubcommand([]string{"stats"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("stats exited %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Files: 1") {
+		t.Errorf("stats output = %q, want the file count", stdout.String())
+	}
+
+	stdout.Reset()
+	if code, _ := runSubcommand([]string{"gc"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("gc exited %d: %s", code, stderr.String())
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "Removed 0 stale socket(s)" {
+		t.Errorf("gc output = %q, want the live socket kept", got)
+	}
+}
+
+func TestRunSubcommandCloseAll(t *testing.T) {
+	useTempSocketDir(t)
+	if err := ensureSocketDir(); err != nil {
+		t.Fatalf("ensureSocketDir() failed: %v", err)
+	}
+
+	quit := make(chan string, 2)
+	startWindow := func(name, socketPath string) {
+		app := NewApp(FileEntry{Name: name, Content: "<p>x</p>"}, "")
+		app.ctx = context.Background()
+		app.quit = func(ctx context.Context) { quit <- name }
+		startTestServer(t, app, socketPath)
+	}
+	windowID := "123e4567-e89b-12d3-a456-426614174003"
+	startWindow("sidebar", getSidebarSocketPathForInstance(""))
+	startWindow("window", getWindowSocketPath(windowID))
+
+	// Sockets nobody listens on
+	staleSidebar := getSidebarSocketPathForInstance("docs")
+	staleWindow := getWindowSocketPath("123e4567-e89b-12d3-a456-426614174004")
+	os.WriteFile(staleSidebar, nil, 0600)
+	os.WriteFile(staleWindow, nil, 0600)
+
+	var stdout, stderr bytes.Buffer
+	if code, _ := runSubcommand([]string{"close", "--all"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("close --all exited %d: %s", code, stderr.String())
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "Closed 2 window(s), removed 2 stale socket(s)" {
+		t.Errorf("close --all output = %q", got)
+	}
+
+	closed := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case name := <-quit:
+			closed[name] = true
+		case <-time.After(time.Second):
+			t.Fatalf("close --all should quit every live window, got %v", closed)
+		}
+	}
+
+	for _, path := range []string{staleSidebar, staleWindow} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("stale socket %s should have been removed", path)
+		}
+	}
+	if _, err := os.Stat(getWindowSocketPath(windowID)); err != nil {
+		t.Error("a live window's socket should not be removed as stale")
+	}
+}
+
+func TestRunSubcommandCloseAllNoWindows(t *testing.T) {
+	useTempSocketDir(t)
+
+	var stdout, stderr bytes.Buffer
+	if code, _ := runSubcommand([]string{"close", "--all"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("close --all exited %d: %s", code, stderr.String())
+	}
+	if got := strings.TrimSpace(stdout.String()); got != "Closed 0 window(s), removed 0 stale socket(s)" {
+		t.Errorf("close --all output = %q", got)
+	}
+}
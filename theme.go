@@ -0,0 +1,64 @@
+package main
+
+import "github.com/wailsapp/wails/v2/pkg/options/mac"
+
+// Values for the theme setting
+const (
+	ThemeSystem = "system"
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+)
+
+// validTheme reports whether theme is a supported theme setting
+func validTheme(theme string) bool {
+	return theme == ThemeSystem || theme == ThemeLight || theme == ThemeDark
+}
+
+// effectiveTheme returns the theme in effect, light or dark: the setting
+// itself, or with theme = "system" the system appearance
+func effectiveTheme(setting string, systemDark bool) string {
+	if setting == ThemeLight || setting == ThemeDark {
+		return setting
+	}
+	if systemDark {
+		return ThemeDark
+	}
+	return ThemeLight
+}
+
+// macAppearance returns the window appearance that pins a light or dark
+// theme on macOS, so the webview's prefers-color-scheme matches it
+func macAppearance(theme string) mac.AppearanceType {
+	switch theme {
+	case ThemeLight:
+		return mac.NSAppearanceNameAqua
+	case ThemeDark:
+		return mac.NSAppearanceNameDarkAqua
+	}
+	return mac.DefaultAppearance
+}
+
+// GetTheme returns the theme in effect, "light" or "dark"
+func (a *App) GetTheme() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return effectiveTheme(a.config.Theme, a.systemDark)
+}
+
+// SetSystemAppearance is called by the frontend with the system appearance
+// at startup and whenever it changes. Wails v2 has no appearance-change
+// notification, so the webview's prefers-color-scheme query is watched
+// instead. With theme = "system", a change of effective theme is sent to
+// the frontend as a theme-changed event.
+func (a *App) SetSystemAppearance(dark bool) {
+	a.mu.Lock()
+	previous := effectiveTheme(a.config.Theme, a.systemDark)
+	a.systemDark = dark
+	theme := effectiveTheme(a.config.Theme, dark)
+	follows := a.config.Theme == ThemeSystem
+	a.mu.Unlock()
+
+	if follows && theme != previous {
+		a.emitEvent("theme-changed", theme)
+	}
+}
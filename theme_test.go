@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+func TestEffectiveTheme(t *testing.T) {
+	tests := []struct {
+		setting    string
+		systemDark bool
+		want       string
+	}{
+		{ThemeSystem, false, ThemeLight},
+		{ThemeSystem, true, ThemeDark},
+		{ThemeLight, true, ThemeLight},
+		{ThemeDark, false, ThemeDark},
+	}
+	for _, tt := range tests {
+		if got := effectiveTheme(tt.setting, tt.systemDark); got != tt.want {
+			t.Errorf("effectiveTheme(%q, %v) = %q, want %q", tt.setting, tt.systemDark, got, tt.want)
+		}
+	}
+}
+
+func TestSetSystemAppearanceEmitsOnChange(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html"}, "")
+	app.config.Theme = ThemeSystem
+	rec := recordEvents(app)
+
+	app.SetSystemAppearance(false) // already light: no change
+	app.SetSystemAppearance(true)
+	app.SetSystemAppearance(true) // still dark
+	app.SetSystemAppearance(false)
+
+	events := rec.named("theme-changed")
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 theme-changed events, got %d", len(events))
+	}
+	if events[0].data[0] != ThemeDark || events[1].data[0] != ThemeLight {
+		t.Errorf("theme-changed data = %v, %v, want dark then light", events[0].data, events[1].data)
+	}
+	if got := app.GetTheme(); got != ThemeLight {
+		t.Errorf("GetTheme() = %q, want light", got)
+	}
+}
+
+func TestSetSystemAppearanceFixedTheme(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html"}, "")
+	app.config.Theme = ThemeLight
+	rec := recordEvents(app)
+
+	app.SetSystemAppearance(true)
+
+	if events := rec.named("theme-changed"); len(events) != 0 {
+		t.Errorf("a fixed theme shouldn't follow the system, got %d theme-changed events", len(events))
+	}
+	if got := app.GetTheme(); got != ThemeLight {
+		t.Errorf("GetTheme() = %q, want light", got)
+	}
+}
+
+func TestSetSystemAppearanceBeforeStartup(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html"}, "")
+	app.config.Theme = ThemeSystem
+	app.SetSystemAppearance(true) // no context yet: recorded, not emitted
+
+	if got := app.GetTheme(); got != ThemeDark {
+		t.Errorf("GetTheme() = %q, want dark", got)
+	}
+}
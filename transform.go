@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Content types that are transformed to HTML before display
+const (
+	ContentTypeMarkdown = "text/markdown"
+	ContentTypePlain    = "text/plain"
+	ContentTypeJSON     = "application/json"
+)
+
+// contentTransforms maps a content type to the function that renders it as
+// HTML. Types without an entry are displayed as-is.
+var contentTransforms = map[string]func(string) string{
+	ContentTypeMarkdown: markdownToHTML,
+	ContentTypePlain:    plainToHTML,
+	ContentTypeJSON:     jsonToHTML,
+	ContentTypeDiff:     diffToHTML,
+}
+
+// extensionContentTypes maps the extensions of files that are rendered through
+// contentTransforms when loaded from disk
+var extensionContentTypes = map[string]string{
+	".md":       ContentTypeMarkdown,
+	".markdown": ContentTypeMarkdown,
+	".json":     ContentTypeJSON,
+}
+
+// loadContentType returns the content type a file is read as: Markdown, JSON
+// and images by extension, otherwise contentTypeForPath. Unlike
+// contentTypeForPath, the result may need transforming before the frontend
+// can parse it.
+func loadContentType(path string) string {
+	if contentType, ok := extensionContentTypes[strings.ToLower(filepath.Ext(path))]; ok {
+		return contentType
+	}
+	return contentTypeForPath(path)
+}
+
+// validContentType reports whether contentType can be forced with --content-type
+func validContentType(contentType string) bool {
+	if contentType == ContentTypeHTML {
+		return true
+	}
+	_, ok := contentTransforms[contentType]
+	return ok
+}
+
+// checkContentType returns an error naming the supported values if
+// contentType can't be forced with --content-type
+func checkContentType(contentType string) error {
+	if validContentType(contentType) {
+		return nil
+	}
+	return fmt.Errorf("unsupported content type: %s (expected %s, %s, %s, %s, or %s)",
+		contentType, ContentTypeHTML, ContentTypeMarkdown, ContentTypePlain, ContentTypeJSON, ContentTypeDiff)
+}
+
+// transformContent renders content of the given type as HTML, returning the
+// new content and its content type. Images are wrapped in a preview page;
+// other content without a transform is returned unchanged.
+func transformContent(contentType, content string) (string, string) {
+	if isImageType(contentType) {
+		return imageToHTML(contentType, content), ContentTypeHTML
+	}
+	transform, ok := contentTransforms[contentType]
+	if !ok {
+		return content, contentType
+	}
+	return transform(content), ContentTypeHTML
+}
+
+// applyContentType renders entry's content as HTML according to forced, or to
+// the entry's own content type when forced is empty. With normalizeEOL, CRLF
+// line endings in content that gets transformed (text, code, diffs) are
+// converted to LF first; HTML is never touched.
+func applyContentType(entry *FileEntry, forced string, normalizeEOL bool) {
+	if forced != "" {
+		entry.ContentType = forced
+	} else if entry.ContentType == ContentTypeHTML && (isDiffPath(entry.Path) || looksLikeDiff(entry.Content)) {
+		// Diffs are detected by extension or, for piped output such as
+		// git diff | fenestro, by their hunk headers
+		entry.ContentType = ContentTypeDiff
+	}
+	if _, ok := contentTransforms[entry.ContentType]; ok && normalizeEOL {
+		entry.Content = normalizeLineEndings(entry.Content)
+	}
+	sourceType := entry.ContentType
+	entry.Content, entry.ContentType = transformContent(entry.ContentType, entry.Content)
+	if entry.ContentType != sourceType {
+		entry.SourceType = sourceType
+	}
+}
+
+// normalizeLineEndings converts CRLF line endings to LF
+func normalizeLineEndings(content string) string {
+	return strings.ReplaceAll(content, "\r\n", "\n")
+}
+
+// plainToHTML displays text verbatim
+func plainToHTML(content string) string {
+	return "<pre>" + html.EscapeString(content) + "</pre>"
+}
+
+// jsonToHTML pretty-prints JSON; invalid JSON is shown verbatim
+func jsonToHTML(content string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(content), "", "  "); err != nil {
+		return plainToHTML(content)
+	}
+	return codeBlockHTML("json", buf.String())
+}
+
+// codeBlockHTML renders code as an escaped <pre><code> block, tagged with a
+// language-* class when language is set
+func codeBlockHTML(language, code string) string {
+	class := ""
+	if language != "" {
+		class = fmt.Sprintf(` class="language-%s"`, language)
+	}
+	return "<pre><code" + class + ">" + html.EscapeString(code) + "</code></pre>"
+}
+
+var (
+	mdHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule        = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	mdBullet      = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdOrdered     = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdQuote       = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	mdFence       = regexp.MustCompile("^\\s*(```|~~~)\\s*([\\w+-]*)")
+	mdCodeSpan    = regexp.MustCompile("`([^`]+)`")
+	mdImage       = regexp.MustCompile(`!\[([^\]]*)\]\(`) // up to the ( opening the destination
+	mdLink        = regexp.MustCompile(`\[([^\]]+)\]\(`)  // (see replaceMarkdownLinks)
+	mdStrong      = regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`)
+	mdEmphasis    = regexp.MustCompile(`(^|[^\w*])[*_]([^*_]+)[*_]`)
+	mdPlaceholder = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// markdownToHTML converts the common subset of Markdown (headings, paragraphs,
+// lists, block quotes, rules, fenced code, and inline code, emphasis, links
+// and images) to HTML. Raw HTML in the source is escaped.
+func markdownToHTML(content string) string {
+	var out strings.Builder
+	var para []string
+	listTag := ""
+
+	flushPara := func() {
+		if len(para) > 0 {
+			out.WriteString("<p>" + markdownInline(strings.Join(para, " ")) + "</p>\n")
+			para = nil
+		}
+	}
+	closeList := func() {
+		if listTag != "" {
+			out.WriteString("</" + listTag + ">\n")
+			listTag = ""
+		}
+	}
+	openList := func(tag string) {
+		if listTag != tag {
+			closeList()
+			out.WriteString("<" + tag + ">\n")
+			listTag = tag
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			flushPara()
+			closeList()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				code = append(code, lines[i])
+			}
+			out.WriteString(codeBlockHTML(m[2], strings.Join(code, "\n")) + "\n")
+			continue
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flushPara()
+			closeList()
+		case mdHeading.MatchString(line):
+			flushPara()
+			closeList()
+			m := mdHeading.FindStringSubmatch(line)
+			level := len(m[1])
+			fmt.Fprintf(&out, "<h%d>%s</h%d>\n", level, markdownInline(m[2]), level)
+		case mdRule.MatchString(line):
+			flushPara()
+			closeList()
+			out.WriteString("<hr>\n")
+		case mdBullet.MatchString(line):
+			flushPara()
+			openList("ul")
+			out.WriteString("<li>" + markdownInline(mdBullet.FindStringSubmatch(line)[1]) + "</li>\n")
+		case mdOrdered.MatchString(line):
+			flushPara()
+			openList("ol")
+			out.WriteString("<li>" + markdownInline(mdOrdered.FindStringSubmatch(line)[1]) + "</li>\n")
+		case mdQuote.MatchString(line):
+			flushPara()
+			closeList()
+			out.WriteString("<blockquote>" + markdownInline(mdQuote.FindStringSubmatch(line)[1]) + "</blockquote>\n")
+		default:
+			closeList()
+			para = append(para, strings.TrimSpace(line))
+		}
+	}
+	flushPara()
+	closeList()
+	return out.String()
+}
+
+// replaceMarkdownLinks replaces each image or link that open matches in text
+// with tag, formatted with its text and destination. A destination ends at
+// the first ) outside balanced parentheses, so URLs such as
+// https://en.wikipedia.org/wiki/Go_(programming_language) stay whole.
+func replaceMarkdownLinks(text string, open *regexp.Regexp, tag string) string {
+	var out strings.Builder
+	for {
+		m := open.FindStringSubmatchIndex(text)
+		if m == nil {
+			break
+		}
+		dest, n, ok := linkDestination(text[m[1]:])
+		if !ok {
+			// Not a link after all; look again past its first character
+			out.WriteString(text[:m[0]+1])
+			text = text[m[0]+1:]
+			continue
+		}
+		out.WriteString(text[:m[0]])
+		fmt.Fprintf(&out, tag, text[m[2]:m[3]], dest)
+		text = text[m[1]+n:]
+	}
+	out.WriteString(text)
+	return out.String()
+}
+
+// linkDestination reads a link destination from the start of s, up to the )
+// that closes it, and returns it with the length read including the ). It
+// returns false if the destination is empty, holds whitespace or isn't
+// closed.
+func linkDestination(s string) (string, int, bool) {
+	depth := 0
+	for i, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case r == ')':
+			return s[:i], i + 1, i > 0
+		case unicode.IsSpace(r):
+			return "", 0, false
+		}
+	}
+	return "", 0, false
+}
+
+// markdownInline converts inline Markdown within a single block
+func markdownInline(text string) string {
+	// Pull out code spans first so their contents aren't formatted
+	var spans []string
+	text = mdCodeSpan.ReplaceAllStringFunc(text, func(s string) string {
+		spans = append(spans, "<code>"+html.EscapeString(s[1:len(s)-1])+"</code>")
+		return fmt.Sprintf("\x00%d\x00", len(spans)-1)
+	})
+
+	text = html.EscapeString(text)
+	text = replaceMarkdownLinks(text, mdImage, `<img src="%[2]s" alt="%[1]s">`)
+	text = replaceMarkdownLinks(text, mdLink, `<a href="%[2]s">%[1]s</a>`)
+	text = mdStrong.ReplaceAllString(text, "<strong>$2</strong>")
+	text = mdEmphasis.ReplaceAllString(text, "$1<em>$2</em>")
+
+	return mdPlaceholder.ReplaceAllStringFunc(text, func(s string) string {
+		var i int
+		fmt.Sscanf(s, "\x00%d\x00", &i)
+		return spans[i]
+	})
+}
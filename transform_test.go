@@ -0,0 +1,209 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyContentTypeForcesTransform(t *testing.T) {
+	tests := []struct {
+		name     string
+		forced   string
+		content  string
+		contains string
+	}{
+		{"html", ContentTypeHTML, "<p>raw</p>", "<p>raw</p>"},
+		{"markdown", ContentTypeMarkdown, "# Title", "<h1>Title</h1>"},
+		{"plain", ContentTypePlain, "<b>not bold</b>", "<pre>&lt;b&gt;not bold&lt;/b&gt;</pre>"},
+		{"json", ContentTypeJSON, `{"a":1}`, "{\n  &#34;a&#34;: 1\n}"},
+	}
+
+	// The forced type wins regardless of what the extension implies
+	paths := []string{"/tmp/page.html", "/tmp/notes.txt", "/tmp/data.xml", ""}
+
+	for _, tt := range tests {
+		for _, path := range paths {
+			t.Run(tt.name+" "+path, func(t *testing.T) {
+				entry := FileEntry{Path: path, Content: tt.content, ContentType: contentTypeForPath(path)}
+				applyContentType(&entry, tt.forced, true)
+
+				if !strings.Contains(entry.Content, tt.contains) {
+					t.Errorf("Content = %q, expected it to contain %q", entry.Content, tt.contains)
+				}
+				if entry.ContentType != ContentTypeHTML {
+					t.Errorf("ContentType = %q, expected %q after transform", entry.ContentType, ContentTypeHTML)
+				}
+			})
+		}
+	}
+}
+
+func TestApplyContentTypeWithoutOverride(t *testing.T) {
+	entry := FileEntry{Path: "/tmp/feed.xml", Content: "<feed/>", ContentType: ContentTypeXML}
+	applyContentType(&entry, "", true)
+
+	if entry.Content != "<feed/>" || entry.ContentType != ContentTypeXML {
+		t.Errorf("entry = %+v, expected XML content to be left unchanged", entry)
+	}
+}
+
+func TestLoadContentType(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/tmp/notes.md", ContentTypeMarkdown},
+		{"/tmp/NOTES.MARKDOWN", ContentTypeMarkdown},
+		{"/tmp/data.json", ContentTypeJSON},
+		{"/tmp/page.html", ContentTypeHTML},
+		{"/tmp/page.xhtml", ContentTypeXHTML},
+		{"/tmp/build.log", ContentTypeHTML},
+		{"/tmp/noext", ContentTypeHTML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := loadContentType(tt.path); got != tt.expected {
+				t.Errorf("loadContentType(%q) = %q, want %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyContentTypeByExtension(t *testing.T) {
+	entry := FileEntry{Path: "/tmp/notes.md", Content: "# Notes", ContentType: loadContentType("/tmp/notes.md")}
+	applyContentType(&entry, "", true)
+
+	if !strings.Contains(entry.Content, "<h1>Notes</h1>") {
+		t.Errorf("Content = %q, expected Markdown rendered by extension", entry.Content)
+	}
+	if entry.ContentType != ContentTypeHTML {
+		t.Errorf("ContentType = %q, want %q so the frontend parses it as HTML", entry.ContentType, ContentTypeHTML)
+	}
+}
+
+func TestCheckContentType(t *testing.T) {
+	for _, ct := range []string{ContentTypeHTML, ContentTypeMarkdown, ContentTypePlain, ContentTypeJSON} {
+		if err := checkContentType(ct); err != nil {
+			t.Errorf("checkContentType(%q) error = %v", ct, err)
+		}
+	}
+	if err := checkContentType("image/png"); err == nil {
+		t.Error("checkContentType(\"image/png\") should fail")
+	}
+}
+
+func TestJSONToHTMLInvalid(t *testing.T) {
+	if got := jsonToHTML("{not json"); got != "<pre>{not json</pre>" {
+		t.Errorf("jsonToHTML() = %q, expected invalid JSON verbatim", got)
+	}
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	input := strings.Join([]string{
+		"## Section",
+		"",
+		"Some *emphasis*, **bold**, `code <x>` and a [link](https://example.com).",
+		"",
+		"- one",
+		"- two",
+		"",
+		"1. first",
+		"",
+		"> quoted",
+		"",
+		"```go",
+		"fmt.Println(\"<hi>\")",
+		"```",
+		"",
+		"---",
+		"<script>alert(1)</script>",
+	}, "\n")
+
+	got := markdownToHTML(input)
+
+	for _, want := range []string{
+		"<h2>Section</h2>",
+		"<em>emphasis</em>",
+		"<strong>bold</strong>",
+		"<code>code &lt;x&gt;</code>",
+		`<a href="https://example.com">link</a>`,
+		"<ul>\n<li>one</li>\n<li>two</li>\n</ul>",
+		"<ol>\n<li>first</li>\n</ol>",
+		"<blockquote>quoted</blockquote>",
+		`<pre><code class="language-go">fmt.Println(&#34;&lt;hi&gt;&#34;)</code></pre>`,
+		"<hr>",
+		"&lt;script&gt;",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("markdownToHTML() missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestMarkdownLinks(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"[Go](https://en.wikipedia.org/wiki/Go_(programming_language))",
+			`<a href="https://en.wikipedia.org/wiki/Go_(programming_language)">Go</a>`},
+		{"![chart](img/a(1).png) and [b](b.html)",
+			`<img src="img/a(1).png" alt="chart"> and <a href="b.html">b</a>`},
+		{"([c](c.html))", `(<a href="c.html">c</a>)`},
+		{"[nested](a((b))c) text)", `<a href="a((b))c">nested</a> text)`},
+		{"[![logo](logo.png)](https://example.com)",
+			`<a href="https://example.com"><img src="logo.png" alt="logo"></a>`},
+		{"[open](a(b", "[open](a(b"},
+		{"[space](a b)", "[space](a b)"},
+		{"[empty]() and [x](x.html)", `[empty]() and <a href="x.html">x</a>`},
+	}
+	for _, tt := range tests {
+		if got := markdownInline(tt.input); got != tt.want {
+			t.Errorf("markdownInline(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"a\r\nb\r\n", "a\nb\n"},
+		{"a\nb", "a\nb"},
+		{"mixed\r\nand\nlone\rcr", "mixed\nand\nlone\rcr"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeLineEndings(tt.input); got != tt.want {
+			t.Errorf("normalizeLineEndings(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestApplyContentTypeNormalizesLineEndings(t *testing.T) {
+	for _, ct := range []string{ContentTypePlain, ContentTypeJSON, ContentTypeMarkdown, ContentTypeDiff} {
+		entry := FileEntry{Content: "line one\r\nline two\r\n", ContentType: ct}
+		applyContentType(&entry, "", true)
+		if strings.Contains(entry.Content, "\r") {
+			t.Errorf("%s content still has CR after normalizing: %q", ct, entry.Content)
+		}
+	}
+
+	entry := FileEntry{Content: "line one\r\nline two", ContentType: ContentTypePlain}
+	applyContentType(&entry, "", false)
+	if !strings.Contains(entry.Content, "\r\n") {
+		t.Errorf("content = %q, want CRLF kept with normalization off", entry.Content)
+	}
+}
+
+func TestApplyContentTypeLeavesHTMLLineEndings(t *testing.T) {
+	html := "<html>\r\n<body><pre>a\r\nb</pre></body>\r\n</html>"
+	entry := FileEntry{Path: "/tmp/page.html", Content: html, ContentType: ContentTypeHTML}
+	applyContentType(&entry, "", true)
+	if entry.Content != html {
+		t.Errorf("HTML content = %q, want it byte-identical", entry.Content)
+	}
+}
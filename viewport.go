@@ -0,0 +1,31 @@
+package main
+
+// normalizeViewport returns the width the content is constrained to, where
+// 0 means the full window. Negative widths are treated as full.
+func normalizeViewport(px int) int {
+	if px < 0 {
+		return 0
+	}
+	return px
+}
+
+// SetViewportWidth constrains the content to px pixels wide, for checking
+// responsive layouts; 0 restores the full window width. The frontend is
+// notified with a viewport-changed event, and the width is persisted by the
+// next SaveWindowGeometry.
+func (a *App) SetViewportWidth(px int) {
+	px = normalizeViewport(px)
+	a.mu.Lock()
+	a.viewportWidth = px
+	a.mu.Unlock()
+
+	a.emitEvent("viewport-changed", px)
+}
+
+// GetViewportWidth returns the content width set by SetViewportWidth or
+// --viewport (0 = full window)
+func (a *App) GetViewportWidth() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.viewportWidth
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestNormalizeViewport(t *testing.T) {
+	tests := []struct {
+		input int
+		want  int
+	}{
+		{375, 375},
+		{0, 0},
+		{-1, 0},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeViewport(tt.input); got != tt.want {
+			t.Errorf("normalizeViewport(%d) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSetViewportWidth(t *testing.T) {
+	app := &App{}
+	rec := recordEvents(app)
+
+	if got := app.GetViewportWidth(); got != 0 {
+		t.Errorf("initial viewport = %d, want 0 (full window)", got)
+	}
+
+	app.SetViewportWidth(375)
+	if got := app.GetViewportWidth(); got != 375 {
+		t.Errorf("GetViewportWidth() = %d, want 375", got)
+	}
+
+	// 0 restores the full width, and so does a negative width
+	app.SetViewportWidth(-20)
+	if got := app.GetViewportWidth(); got != 0 {
+		t.Errorf("GetViewportWidth() = %d after a negative width, want 0", got)
+	}
+
+	events := rec.named("viewport-changed")
+	if len(events) != 2 {
+		t.Fatalf("got %d viewport-changed events, want 2", len(events))
+	}
+	for i, want := range []int{375, 0} {
+		if px, _ := events[i].data[0].(int); px != want {
+			t.Errorf("event %d width = %v, want %d", i, events[i].data[0], want)
+		}
+	}
+}
+
+func TestViewportArg(t *testing.T) {
+	tests := []struct {
+		input int
+		want  string
+	}{
+		{-1, ""},
+		{0, "0"},
+		{768, "768"},
+	}
+
+	for _, tt := range tests {
+		if got := viewportArg(tt.input); got != tt.want {
+			t.Errorf("viewportArg(%d) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	args, err := guiProcessArgs(FileEntry{Path: "/tmp/a.html"}, guiOptions{Viewport: "375"})
+	if err != nil {
+		t.Fatalf("guiProcessArgs() error = %v", err)
+	}
+	if argValue(args, "--viewport") != "375" {
+		t.Errorf("args = %v, want --viewport 375 passed to the child", args)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+// sourceViewHTML shows HTML content as its escaped source, rendered like a
+// fenced code block
+func sourceViewHTML(content string) string {
+	return codeBlockHTML("html", content)
+}
+
+// displayContent returns what the frontend shows for the file at index: its
+// source when view-source is on, otherwise the content with the header and
+// footer snippets and, for HTML documents, a UTF-8 declaration. Must be
+// called with a.mu held.
+func (a *App) displayContent(index int) string {
+	entry := &a.files[index]
+	if entry.viewSource {
+		return sourceViewHTML(entry.content())
+	}
+	content := wrapContent(entry.content(), a.headerHTML, a.footerHTML)
+	if entry.ContentType == "" || entry.ContentType == ContentTypeHTML {
+		content = ensureCharset(content)
+		if a.printMedia {
+			content = emulatePrintMedia(content)
+		}
+	}
+	return content
+}
+
+// ToggleViewSource switches the selected file between its rendered content
+// and its source, and returns whether the source is now shown. Each file
+// remembers its own mode.
+func (a *App) ToggleViewSource() bool {
+	a.mu.Lock()
+	if a.currentIndex < 0 || a.currentIndex >= len(a.files) {
+		a.mu.Unlock()
+		return false
+	}
+	index := a.currentIndex
+	a.files[index].viewSource = !a.files[index].viewSource
+	source := a.files[index].viewSource
+	a.render.markPending()
+	a.mu.Unlock()
+
+	a.emitEvent("view-mode-changed", map[string]interface{}{
+		"source":       source,
+		"currentIndex": index,
+	})
+	return source
+}
+
+// IsViewingSource reports whether the selected file is showing its source
+func (a *App) IsViewingSource() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.currentIndex < 0 || a.currentIndex >= len(a.files) {
+		return false
+	}
+	return a.files[a.currentIndex].viewSource
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToggleViewSource(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html", Path: "/tmp/a.html", Content: `<html><head><meta charset="utf-8"></head><body><p>a</p></body></html>`, ContentType: ContentTypeHTML}, "")
+	app.AddFile(FileEntry{Name: "b.html", Path: "/tmp/b.html", Content: "<p>b</p>", ContentType: ContentTypeHTML})
+	rec := recordEvents(app)
+
+	if app.IsViewingSource() {
+		t.Fatal("files should start rendered")
+	}
+	if !app.ToggleViewSource() {
+		t.Fatal("ToggleViewSource() should switch to the source view")
+	}
+	if !app.IsViewingSource() {
+		t.Error("IsViewingSource() should report the source view")
+	}
+
+	events := rec.named("view-mode-changed")
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 view-mode-changed event, got %d", len(events))
+	}
+	data, _ := events[0].data[0].(map[string]interface{})
+	if data["source"] != true || data["currentIndex"] != 0 {
+		t.Errorf("event data = %v, want source view of file 0", data)
+	}
+
+	// The mode belongs to the file it was set on
+	app.SelectFile(1)
+	if app.IsViewingSource() {
+		t.Error("another file should still be rendered")
+	}
+	if got := app.GetHTMLContent(); got != "<p>b</p>" {
+		t.Errorf("GetHTMLContent() = %q, want the rendered content of file 1", got)
+	}
+	if got := app.SelectFile(0); !strings.Contains(got, "&lt;html&gt;") {
+		t.Errorf("SelectFile(0) = %q, want the source view kept for file 0", got)
+	}
+
+	if app.ToggleViewSource() {
+		t.Error("a second ToggleViewSource() should switch back to rendered")
+	}
+	if got := app.GetHTMLContent(); got != `<html><head><meta charset="utf-8"></head><body><p>a</p></body></html>` {
+		t.Errorf("GetHTMLContent() = %q, want the rendered content again", got)
+	}
+}
+
+func TestSourceViewEscapesMarkup(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.xhtml", Path: "/tmp/a.xhtml", Content: `<html><body class="x">&amp;</body></html>`, ContentType: ContentTypeXHTML}, "")
+	app.headerHTML = "<div>header</div>"
+	app.ToggleViewSource()
+
+	got := app.GetHTMLContent()
+	want := `<pre><code class="language-html">&lt;html&gt;&lt;body class=&#34;x&#34;&gt;&amp;amp;&lt;/body&gt;&lt;/html&gt;</code></pre>`
+	if got != want {
+		t.Errorf("GetHTMLContent() = %q, want %q", got, want)
+	}
+	if got := app.GetCurrentContentType(); got != ContentTypeHTML {
+		t.Errorf("GetCurrentContentType() = %q, want the source view parsed as HTML", got)
+	}
+}
+
+func TestToggleViewSourceNoFiles(t *testing.T) {
+	app := NewApp(FileEntry{Name: "a.html"}, "")
+	app.files = nil
+	if app.ToggleViewSource() {
+		t.Error("ToggleViewSource() with no files should report rendered")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+// Zoom limits, matching ZOOM_MIN and ZOOM_MAX in the frontend
+const (
+	minZoom = 0.25
+	maxZoom = 5.0
+)
+
+// normalizeZoom returns the zoom factor to apply: 0 (unset, as in state
+// files saved before zoom was remembered) and negative values mean 1.0, and
+// anything else is clamped to the frontend's range
+func normalizeZoom(zoom float64) float64 {
+	switch {
+	case zoom <= 0:
+		return 1.0
+	case zoom < minZoom:
+		return minZoom
+	case zoom > maxZoom:
+		return maxZoom
+	}
+	return zoom
+}
+
+// GetZoom returns the window's page zoom factor (1.0 = 100%). Zoom scales
+// the whole page and is remembered in the window state; it is separate from
+// the font_size config, which only sets the default text size.
+func (a *App) GetZoom() float64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return normalizeZoom(a.zoom)
+}
+
+// SetZoom records the zoom factor chosen in the frontend. It is persisted by
+// the next SaveWindowGeometry.
+func (a *App) SetZoom(zoom float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.zoom = normalizeZoom(zoom)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNormalizeZoom(t *testing.T) {
+	tests := []struct {
+		input float64
+		want  float64
+	}{
+		{0, 1.0}, // unset
+		{-1, 1.0},
+		{1.5, 1.5},
+		{0.1, minZoom},
+		{10, maxZoom},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeZoom(tt.input); got != tt.want {
+			t.Errorf("normalizeZoom(%v) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGetZoomDefault(t *testing.T) {
+	app := &App{}
+	if got := app.GetZoom(); got != 1.0 {
+		t.Errorf("GetZoom() = %v, want 1.0 when unset", got)
+	}
+
+	app.SetZoom(1.3)
+	if got := app.GetZoom(); got != 1.3 {
+		t.Errorf("GetZoom() = %v, want 1.3", got)
+	}
+}
+
+func TestWindowStateZoomAbsent(t *testing.T) {
+	// State files from before zoom was saved restore at 100%
+	var state WindowState
+	if err := json.Unmarshal([]byte(`{"width":800,"height":600,"x":0,"y":0}`), &state); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got := normalizeZoom(state.Zoom); got != 1.0 {
+		t.Errorf("zoom = %v, want 1.0 for a state file without zoom", got)
+	}
+}
+
+func TestZoomAndFontSizeIndependent(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	app := &App{config: Config{FontSize: 18}}
+	app.SetZoom(1.5)
+	if app.GetConfig().FontSize != 18 {
+		t.Errorf("FontSize = %d after zooming, want 18 unchanged", app.GetConfig().FontSize)
+	}
+
+	// Zoom persists in the window state, which carries no font size
+	if err := SaveWindowState("", WindowState{Width: 800, Height: 600, Zoom: app.GetZoom()}); err != nil {
+		t.Fatalf("SaveWindowState() error = %v", err)
+	}
+	loaded := LoadWindowState("")
+	if loaded == nil || loaded.Zoom != 1.5 {
+		t.Fatalf("LoadWindowState() = %+v, want zoom 1.5", loaded)
+	}
+
+	// A new window restores the zoom, and its font size still comes from
+	// the config
+	restored := &App{config: Config{FontSize: 24}}
+	restored.zoom = normalizeZoom(loaded.Zoom)
+	if restored.GetZoom() != 1.5 || restored.GetConfig().FontSize != 24 {
+		t.Errorf("restored zoom/font size = %v/%d, want 1.5/24", restored.GetZoom(), restored.GetConfig().FontSize)
+	}
+}